@@ -0,0 +1,51 @@
+package canary
+
+import "context"
+
+const (
+	// HeaderKey is the http header key of the canary label.
+	HeaderKey = "X-Canary"
+	// MetadataKey is the grpc metadata key of the canary label.
+	MetadataKey = "x-canary"
+
+	// maxLength is the max length of the canary labels from the clients.
+	maxLength = 64
+)
+
+type labelKey struct{}
+
+// FromContext returns the canary label in ctx, empty string if not set.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if label, ok := ctx.Value(labelKey{}).(string); ok {
+		return label
+	}
+
+	return ""
+}
+
+// IsValid checks if label is acceptable from the clients, which is not empty, not too long,
+// and only with the printable ascii characters.
+func IsValid(label string) bool {
+	if len(label) == 0 || len(label) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(label); i++ {
+		if label[i] <= ' ' || label[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewContext returns a new context with the canary label, the rpc calls with the context
+// are routed to the instances matching label, like v2 to match the version,
+// or env=canary to match the tags.
+func NewContext(ctx context.Context, label string) context.Context {
+	return context.WithValue(ctx, labelKey{}, label)
+}
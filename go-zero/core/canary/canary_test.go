@@ -0,0 +1,41 @@
+package canary
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+	//nolint:staticcheck
+	assert.Empty(t, FromContext(nil))
+	assert.Empty(t, FromContext(context.WithValue(context.Background(), labelKey{}, 1)))
+
+	ctx := NewContext(context.Background(), "v2")
+	assert.Equal(t, "v2", FromContext(ctx))
+}
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		label string
+		valid bool
+	}{
+		{label: "", valid: false},
+		{label: "v2", valid: true},
+		{label: "env=canary", valid: true},
+		{label: "a b", valid: false},
+		{label: "a\nb", valid: false},
+		{label: strings.Repeat("a", maxLength), valid: true},
+		{label: strings.Repeat("a", maxLength+1), valid: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.label, func(t *testing.T) {
+			assert.Equal(t, test.valid, IsValid(test.label))
+		})
+	}
+}
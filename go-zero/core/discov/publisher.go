@@ -1,7 +1,11 @@
 package discov
 
 import (
+	"context"
+	"errors"
+
 	"github.com/zeromicro/go-zero/core/discov/internal"
+	"github.com/zeromicro/go-zero/core/health"
 	"github.com/zeromicro/go-zero/core/lang"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
@@ -10,6 +14,9 @@ import (
 	clientv3 "go.etcd.io/etcd/client/v3"
 )
 
+// ErrNotPublished is an error that indicates the value is not published.
+var ErrNotPublished = errors.New("not published to etcd")
+
 type (
 	// PubOption defines the method to customize a Publisher.
 	PubOption func(client *Publisher)
@@ -25,6 +32,7 @@ type (
 		quit       *syncx.DoneChan
 		pauseChan  chan lang.PlaceholderType
 		resumeChan chan lang.PlaceholderType
+		published  *syncx.AtomicBool
 	}
 )
 
@@ -40,6 +48,7 @@ func NewPublisher(endpoints []string, key, value string, opts ...PubOption) *Pub
 		quit:       syncx.NewDoneChan(),
 		pauseChan:  make(chan lang.PlaceholderType),
 		resumeChan: make(chan lang.PlaceholderType),
+		published:  syncx.NewAtomicBool(),
 	}
 
 	for _, opt := range opts {
@@ -65,7 +74,14 @@ func (p *Publisher) KeepAlive() error {
 		p.Stop()
 	})
 
-	return p.keepAliveAsync(cli)
+	// set before renewing, to avoid overwriting the state set on revoking
+	p.published.Set(true)
+	if err = p.keepAliveAsync(cli); err != nil {
+		p.published.Set(false)
+		return err
+	}
+
+	return nil
 }
 
 // Pause pauses the renewing of key:value.
@@ -140,11 +156,24 @@ func (p *Publisher) register(client internal.EtcdClient) (clientv3.LeaseID, erro
 }
 
 func (p *Publisher) revoke(cli internal.EtcdClient) {
+	p.published.Set(false)
 	if _, err := cli.Revoke(cli.Ctx(), p.lease); err != nil {
 		logx.Error(err)
 	}
 }
 
+// NewHealthProbe returns a health.Probe that checks if the value of p is published,
+// the probe fails if p is paused, stopped or failed to renew the lease.
+func NewHealthProbe(p *Publisher) health.Probe {
+	return func(ctx context.Context) error {
+		if !p.published.True() {
+			return ErrNotPublished
+		}
+
+		return nil
+	}
+}
+
 // WithId customizes a Publisher with the id.
 func WithId(id int64) PubOption {
 	return func(publisher *Publisher) {
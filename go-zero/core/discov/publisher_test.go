@@ -1,6 +1,7 @@
 package discov
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"testing"
@@ -79,7 +80,11 @@ func TestPublisher_revoke(t *testing.T) {
 	cli.EXPECT().Revoke(gomock.Any(), id)
 	pub := NewPublisher(nil, "thekey", "thevalue")
 	pub.lease = id
+	pub.published.Set(true)
+	probe := NewHealthProbe(pub)
+	assert.Nil(t, probe(context.Background()))
 	pub.revoke(cli)
+	assert.Equal(t, ErrNotPublished, probe(context.Background()))
 }
 
 func TestPublisher_revokeError(t *testing.T) {
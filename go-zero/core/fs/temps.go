@@ -1,6 +1,7 @@
 package fs
 
 import (
+	"io"
 	"io/ioutil"
 	"os"
 
@@ -40,3 +41,25 @@ func TempFilenameWithText(text string) (string, error) {
 
 	return filename, nil
 }
+
+// TempFilenameWithReader creates the file with the content read from reader,
+// and returns the filename (full path) and the written size.
+// The content is streamed into the file, the caller should remove the file after use.
+func TempFilenameWithReader(reader io.Reader) (string, int64, error) {
+	tmpfile, err := ioutil.TempFile(os.TempDir(), "")
+	if err != nil {
+		return "", 0, err
+	}
+
+	filename := tmpfile.Name()
+	size, err := io.Copy(tmpfile, reader)
+	if closeErr := tmpfile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(filename)
+		return "", 0, err
+	}
+
+	return filename, size, nil
+}
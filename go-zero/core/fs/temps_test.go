@@ -0,0 +1,43 @@
+package fs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTempFilenameWithText(t *testing.T) {
+	filename, err := TempFilenameWithText("foo")
+	assert.Nil(t, err)
+	defer os.Remove(filename)
+
+	content, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, "foo", string(content))
+}
+
+func TestTempFilenameWithReader(t *testing.T) {
+	filename, size, err := TempFilenameWithReader(strings.NewReader("foobar"))
+	assert.Nil(t, err)
+	defer os.Remove(filename)
+
+	assert.Equal(t, int64(6), size)
+	content, err := ioutil.ReadFile(filename)
+	assert.Nil(t, err)
+	assert.Equal(t, "foobar", string(content))
+}
+
+func TestTempFilenameWithReader_Error(t *testing.T) {
+	_, _, err := TempFilenameWithReader(badReader{})
+	assert.NotNil(t, err)
+}
+
+type badReader struct{}
+
+func (badReader) Read([]byte) (int, error) {
+	return 0, errors.New("bad")
+}
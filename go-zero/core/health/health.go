@@ -0,0 +1,247 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"github.com/zeromicro/go-zero/core/threading"
+)
+
+const (
+	// StatusUp means the probes are all passed.
+	StatusUp = "UP"
+	// StatusDown means any probe is failed.
+	StatusDown = "DOWN"
+
+	shutdownProbe = "shutdown"
+)
+
+var (
+	// ErrShuttingDown is an error that indicates the process is shutting down.
+	ErrShuttingDown = errors.New("shutting down")
+
+	errProbePanic   = errors.New("probe panicked")
+	errProbeTimeout = errors.New("probe timed out")
+
+	livenessProbes  = newProbeManager()
+	readinessProbes = newProbeManager()
+	shuttingDown    = syncx.NewAtomicBool()
+	drainDelay      = syncx.NewAtomicDuration()
+	drainOnce       sync.Once
+	drained         = make(chan struct{})
+)
+
+type (
+	// A Probe checks the health of a component, returns nil if it's healthy.
+	// The ctx is cancelled on timeout, the probes should return as soon as possible then.
+	Probe func(ctx context.Context) error
+
+	// A Report is the result of the probes.
+	Report struct {
+		Status string `json:"status"`
+		// Probes are the results of the probes, the failed ones are with the error messages.
+		Probes map[string]string `json:"probes,omitempty"`
+	}
+
+	probeManager struct {
+		lock   sync.RWMutex
+		probes map[string]Probe
+	}
+)
+
+func init() {
+	// flip the readiness as soon as SIGTERM received,
+	// to let the load balancers drain the traffic before shutting down.
+	proc.AddWrapUpListener(func() {
+		shuttingDown.Set(true)
+	})
+}
+
+// AddLivenessProbe adds the probe with given name, which is checked on liveness checking.
+// The liveness probes are used to detect the process is alive but unable to make progress,
+// like deadlocks, and the process is expected to be restarted if they fail.
+// The probe with the same name is replaced.
+func AddLivenessProbe(name string, probe Probe) {
+	livenessProbes.add(name, probe)
+}
+
+// AddReadinessProbe adds the probe with given name, which is checked on readiness checking.
+// The readiness probes are used to check the dependencies, like databases, redis and etcd,
+// and the process is expected to be removed from the load balancers if they fail.
+// The probe with the same name is replaced.
+func AddReadinessProbe(name string, probe Probe) {
+	readinessProbes.add(name, probe)
+}
+
+// Check checks both the liveness probes and the readiness probes.
+func Check(ctx context.Context) Report {
+	return merge(CheckLiveness(ctx), CheckReadiness(ctx))
+}
+
+// CheckLiveness checks the liveness probes.
+func CheckLiveness(ctx context.Context) Report {
+	return livenessProbes.check(ctx)
+}
+
+// CheckReadiness checks the readiness probes, the report is down if the process is shutting down.
+func CheckReadiness(ctx context.Context) Report {
+	if IsShuttingDown() {
+		return Report{
+			Status: StatusDown,
+			Probes: map[string]string{
+				shutdownProbe: ErrShuttingDown.Error(),
+			},
+		}
+	}
+
+	return readinessProbes.check(ctx)
+}
+
+// Drain marks the process as shutting down, and waits for the drain delay,
+// to let the load balancers remove the process before the servers stop accepting requests.
+// The delay is counted from the first call, so the concurrent callers return together.
+func Drain() {
+	shuttingDown.Set(true)
+	drainOnce.Do(func() {
+		threading.GoSafe(func() {
+			time.Sleep(drainDelay.Load())
+			close(drained)
+		})
+	})
+	<-drained
+}
+
+// IsShuttingDown checks if the process is shutting down.
+func IsShuttingDown() bool {
+	return shuttingDown.True()
+}
+
+// RemoveProbe removes the liveness and readiness probes with given name.
+func RemoveProbe(name string) {
+	livenessProbes.remove(name)
+	readinessProbes.remove(name)
+}
+
+// SetDrainDelay sets the delay that Drain waits for, the longest one is kept
+// if it's set by multiple servers.
+func SetDrainDelay(delay time.Duration) {
+	for {
+		current := drainDelay.Load()
+		if delay <= current || drainDelay.CompareAndSwap(current, delay) {
+			return
+		}
+	}
+}
+
+// Healthy checks if the report is up.
+func (r Report) Healthy() bool {
+	return r.Status == StatusUp
+}
+
+func newProbeManager() *probeManager {
+	return &probeManager{
+		probes: make(map[string]Probe),
+	}
+}
+
+func (m *probeManager) add(name string, probe Probe) {
+	m.lock.Lock()
+	m.probes[name] = probe
+	m.lock.Unlock()
+}
+
+func (m *probeManager) check(ctx context.Context) Report {
+	m.lock.RLock()
+	probes := make(map[string]Probe, len(m.probes))
+	for name, probe := range m.probes {
+		probes[name] = probe
+	}
+	m.lock.RUnlock()
+
+	if len(probes) == 0 {
+		return Report{Status: StatusUp}
+	}
+
+	var lock sync.Mutex
+	// the probes not finished in time are taken as timed out,
+	// and the panicked ones are overwritten on panicking.
+	results := make(map[string]error, len(probes))
+	for name := range probes {
+		results[name] = errProbeTimeout
+	}
+
+	group := threading.NewRoutineGroup()
+	for name, probe := range probes {
+		name, probe := name, probe
+		group.RunSafe(func() {
+			err := errProbePanic
+			defer func() {
+				lock.Lock()
+				results[name] = err
+				lock.Unlock()
+			}()
+
+			err = probe(ctx)
+		})
+	}
+
+	done := make(chan struct{})
+	go func() {
+		group.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	return newReport(results)
+}
+
+func (m *probeManager) remove(name string) {
+	m.lock.Lock()
+	delete(m.probes, name)
+	m.lock.Unlock()
+}
+
+func merge(reports ...Report) Report {
+	result := Report{Status: StatusUp}
+	for _, report := range reports {
+		if !report.Healthy() {
+			result.Status = StatusDown
+		}
+
+		for name, status := range report.Probes {
+			if result.Probes == nil {
+				result.Probes = make(map[string]string)
+			}
+			result.Probes[name] = status
+		}
+	}
+
+	return result
+}
+
+func newReport(results map[string]error) Report {
+	report := Report{
+		Status: StatusUp,
+		Probes: make(map[string]string, len(results)),
+	}
+	for name, err := range results {
+		if err != nil {
+			report.Status = StatusDown
+			report.Probes[name] = err.Error()
+		} else {
+			report.Probes[name] = StatusUp
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,114 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestCheck(t *testing.T) {
+	defer reset()
+
+	assert.Equal(t, Report{Status: StatusUp}, Check(context.Background()))
+
+	AddLivenessProbe("live", func(ctx context.Context) error {
+		return nil
+	})
+	AddReadinessProbe("db", func(ctx context.Context) error {
+		return nil
+	})
+	report := Check(context.Background())
+	assert.True(t, report.Healthy())
+	assert.Equal(t, map[string]string{
+		"live": StatusUp,
+		"db":   StatusUp,
+	}, report.Probes)
+
+	AddReadinessProbe("db", func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	report = CheckReadiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, "connection refused", report.Probes["db"])
+	assert.True(t, CheckLiveness(context.Background()).Healthy())
+	assert.False(t, Check(context.Background()).Healthy())
+
+	RemoveProbe("db")
+	assert.True(t, Check(context.Background()).Healthy())
+}
+
+func TestCheck_PanicAndTimeout(t *testing.T) {
+	defer reset()
+
+	AddLivenessProbe("panic", func(ctx context.Context) error {
+		panic("oops")
+	})
+	AddLivenessProbe("slow", func(ctx context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	report := CheckLiveness(ctx)
+	assert.Equal(t, StatusDown, report.Status)
+	assert.Equal(t, map[string]string{
+		"panic": errProbePanic.Error(),
+		"slow":  errProbeTimeout.Error(),
+	}, report.Probes)
+}
+
+func TestCheckReadiness_ShuttingDown(t *testing.T) {
+	defer reset()
+
+	AddReadinessProbe("db", func(ctx context.Context) error {
+		return nil
+	})
+	assert.False(t, IsShuttingDown())
+	assert.True(t, CheckReadiness(context.Background()).Healthy())
+
+	shuttingDown.Set(true)
+	assert.True(t, IsShuttingDown())
+	report := CheckReadiness(context.Background())
+	assert.False(t, report.Healthy())
+	assert.Equal(t, ErrShuttingDown.Error(), report.Probes[shutdownProbe])
+	assert.True(t, CheckLiveness(context.Background()).Healthy())
+}
+
+func TestDrain(t *testing.T) {
+	defer reset()
+
+	SetDrainDelay(time.Millisecond * 50)
+	SetDrainDelay(time.Millisecond * 10)
+	assert.Equal(t, time.Millisecond*50, drainDelay.Load())
+
+	start := time.Now()
+	done := make(chan struct{})
+	go func() {
+		Drain()
+		close(done)
+	}()
+	Drain()
+	<-done
+	assert.True(t, time.Since(start) >= time.Millisecond*50)
+	assert.True(t, IsShuttingDown())
+	assert.False(t, CheckReadiness(context.Background()).Healthy())
+}
+
+func reset() {
+	livenessProbes = newProbeManager()
+	readinessProbes = newProbeManager()
+	shuttingDown.Set(false)
+	drainDelay.Set(0)
+	drainOnce = sync.Once{}
+	drained = make(chan struct{})
+}
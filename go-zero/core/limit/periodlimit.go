@@ -9,19 +9,13 @@ import (
 )
 
 // to be compatible with aliyun redis, we cannot use `local key = KEYS[1]` to reuse the key
-const periodScript = `local limit = tonumber(ARGV[1])
-local window = tonumber(ARGV[2])
+// returns the count of the requests in the current period
+const periodScript = `local window = tonumber(ARGV[1])
 local current = redis.call("INCRBY", KEYS[1], 1)
 if current == 1 then
     redis.call("expire", KEYS[1], window)
-    return 1
-elseif current < limit then
-    return 1
-elseif current == limit then
-    return 2
-else
-    return 0
-end`
+end
+return current`
 
 const (
 	// Unknown means not initialized state.
@@ -32,10 +26,6 @@ const (
 	HitQuota
 	// OverQuota means passed the quota.
 	OverQuota
-
-	internalOverQuota = 0
-	internalAllowed   = 1
-	internalHitQuota  = 2
 )
 
 // ErrUnknownCode is an error that represents unknown status code.
@@ -74,28 +64,47 @@ func NewPeriodLimit(period, quota int, limitStore *redis.Redis, keyPrefix string
 
 // Take requests a permit, it returns the permit state.
 func (h *PeriodLimit) Take(key string) (int, error) {
+	state, _, err := h.TakeWithRemaining(key)
+	return state, err
+}
+
+// TakeWithRemaining requests a permit, it returns the permit state
+// and the remaining permits in the current period.
+func (h *PeriodLimit) TakeWithRemaining(key string) (int, int, error) {
 	resp, err := h.limitStore.Eval(periodScript, []string{h.keyPrefix + key}, []string{
-		strconv.Itoa(h.quota),
 		strconv.Itoa(h.calcExpireSeconds()),
 	})
 	if err != nil {
-		return Unknown, err
+		return Unknown, 0, err
 	}
 
-	code, ok := resp.(int64)
+	current, ok := resp.(int64)
 	if !ok {
-		return Unknown, ErrUnknownCode
+		return Unknown, 0, ErrUnknownCode
 	}
 
-	switch code {
-	case internalOverQuota:
-		return OverQuota, nil
-	case internalAllowed:
-		return Allowed, nil
-	case internalHitQuota:
-		return HitQuota, nil
+	return State(int(current), h.quota), Remaining(int(current), h.quota), nil
+}
+
+// Remaining returns the remaining permits after current requests in a period with quota.
+func Remaining(current, quota int) int {
+	if current >= quota {
+		return 0
+	}
+
+	return quota - current
+}
+
+// State returns the permit state of the current request in a period with quota.
+// The first request in a period is always allowed.
+func State(current, quota int) int {
+	switch {
+	case current == 1 || current < quota:
+		return Allowed
+	case current == quota:
+		return HitQuota
 	default:
-		return Unknown, ErrUnknownCode
+		return OverQuota
 	}
 }
 
@@ -32,6 +32,32 @@ func TestPeriodLimit_RedisUnavailable(t *testing.T) {
 	assert.Equal(t, 0, val)
 }
 
+func TestPeriodLimit_TakeWithRemaining(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	const quota = 3
+	l := NewPeriodLimit(3600, quota, store, "periodlimit")
+	states := []int{Allowed, Allowed, HitQuota, OverQuota, OverQuota}
+	for i, expect := range states {
+		state, remaining, err := l.TakeWithRemaining("first")
+		assert.Nil(t, err)
+		assert.Equal(t, expect, state)
+		assert.Equal(t, Remaining(i+1, quota), remaining)
+	}
+}
+
+func TestState(t *testing.T) {
+	assert.Equal(t, Allowed, State(1, 1))
+	assert.Equal(t, OverQuota, State(2, 1))
+	assert.Equal(t, Allowed, State(1, 3))
+	assert.Equal(t, HitQuota, State(3, 3))
+	assert.Equal(t, OverQuota, State(4, 3))
+	assert.Equal(t, 2, Remaining(1, 3))
+	assert.Equal(t, 0, Remaining(4, 3))
+}
+
 func testPeriodLimit(t *testing.T, opts ...PeriodOption) {
 	store, clean, err := redistest.CreateRedis()
 	assert.Nil(t, err)
@@ -7,15 +7,17 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/requestid"
 	"github.com/zeromicro/go-zero/core/timex"
 	"go.opentelemetry.io/otel/trace"
 )
 
 type traceLogger struct {
 	logEntry
-	Trace string `json:"trace,omitempty"`
-	Span  string `json:"span,omitempty"`
-	ctx   context.Context
+	Trace   string `json:"trace,omitempty"`
+	Span    string `json:"span,omitempty"`
+	Request string `json:"request,omitempty"`
+	ctx     context.Context
 }
 
 func (l *traceLogger) Error(v ...interface{}) {
@@ -80,10 +82,16 @@ func (l *traceLogger) WithDuration(duration time.Duration) Logger {
 func (l *traceLogger) write(writer io.Writer, level string, val interface{}) {
 	traceID := traceIdFromContext(l.ctx)
 	spanID := spanIdFromContext(l.ctx)
+	requestID := requestid.FromContext(l.ctx)
 
 	switch atomic.LoadUint32(&encoding) {
 	case plainEncodingType:
-		writePlainAny(writer, level, val, l.Duration, traceID, spanID)
+		fields := []string{l.Duration, traceID, spanID}
+		// keep the plain format unchanged if no request id.
+		if len(requestID) > 0 {
+			fields = append(fields, requestID)
+		}
+		writePlainAny(writer, level, val, fields...)
 	default:
 		outputJson(writer, &traceLogger{
 			logEntry: logEntry{
@@ -92,13 +100,14 @@ func (l *traceLogger) write(writer io.Writer, level string, val interface{}) {
 				Duration:  l.Duration,
 				Content:   val,
 			},
-			Trace: traceID,
-			Span:  spanID,
+			Trace:   traceID,
+			Span:    spanID,
+			Request: requestID,
 		})
 	}
 }
 
-// WithContext sets ctx to log, for keeping tracing information.
+// WithContext sets ctx to log, for keeping tracing information and the request id.
 func WithContext(ctx context.Context) Logger {
 	return &traceLogger{
 		ctx: ctx,
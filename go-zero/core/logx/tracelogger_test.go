@@ -9,13 +9,15 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/requestid"
 	"go.opentelemetry.io/otel"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 )
 
 const (
-	traceKey = "trace"
-	spanKey  = "span"
+	traceKey   = "trace"
+	spanKey    = "span"
+	requestKey = "request"
 )
 
 func TestTraceLog(t *testing.T) {
@@ -152,3 +154,35 @@ func TestTraceWithoutContext(t *testing.T) {
 	assert.False(t, strings.Contains(buf.String(), traceKey))
 	assert.False(t, strings.Contains(buf.String(), spanKey))
 }
+
+func TestTraceWithRequestId(t *testing.T) {
+	var buf mockWriter
+	atomic.StoreUint32(&initialized, 1)
+	infoLog = newLogWriter(log.New(&buf, "", flags))
+	ctx := requestid.NewContext(context.Background(), "req-id")
+	l := WithContext(ctx).(*traceLogger)
+	SetLevel(InfoLevel)
+	l.WithDuration(time.Second).Info(testlog)
+	assert.True(t, strings.Contains(buf.String(), requestKey))
+	assert.True(t, strings.Contains(buf.String(), "req-id"))
+	buf.Reset()
+	l.WithDuration(time.Second).Infov(testlog)
+	assert.True(t, strings.Contains(buf.String(), "req-id"))
+}
+
+func TestTraceWithRequestIdPlain(t *testing.T) {
+	old := atomic.LoadUint32(&encoding)
+	atomic.StoreUint32(&encoding, plainEncodingType)
+	defer func() {
+		atomic.StoreUint32(&encoding, old)
+	}()
+
+	var buf mockWriter
+	atomic.StoreUint32(&initialized, 1)
+	ctx := requestid.NewContext(context.Background(), "req-id")
+	WithContext(ctx).(*traceLogger).write(&buf, levelInfo, testlog)
+	assert.True(t, strings.Contains(buf.String(), "req-id"))
+	buf.Reset()
+	WithContext(context.Background()).(*traceLogger).write(&buf, levelInfo, testlog)
+	assert.False(t, strings.Contains(buf.String(), "req-id"))
+}
@@ -0,0 +1,498 @@
+package mapping
+
+import (
+	"fmt"
+	"net/mail"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	validateTagKey  = "validate"
+	requiredRule    = "required"
+	omitEmptyRule   = "omitempty"
+	minRule         = "min"
+	maxRule         = "max"
+	lenRule         = "len"
+	regexpRule      = "regexp"
+	emailRule       = "email"
+	eqFieldRule     = "eqfield"
+	neFieldRule     = "nefield"
+	gtFieldRule     = "gtfield"
+	ltFieldRule     = "ltfield"
+	ruleSeparator   = ','
+	validateMessage = "validation failed"
+	notComparable   = "cannot be compared with %s"
+	fieldNotExists  = "field %s referenced by %s in %s doesn't exist"
+)
+
+var (
+	// the tags to find the names of the fields in error paths, in order.
+	nameTags        = []string{jsonTagKey, "form", "path", "header"}
+	timeType        = reflect.TypeOf(time.Time{})
+	validatorsCache sync.Map
+)
+
+type (
+	// A FieldError is a validation failure of a field.
+	FieldError struct {
+		// Field is the full path of the field, like user.addresses[0].zipcode.
+		Field string `json:"field"`
+		// Rule is the rule that failed.
+		Rule string `json:"rule"`
+		// Message describes the failure.
+		Message string `json:"message"`
+	}
+
+	// A ValidationError is an error that contains all the failed fields.
+	ValidationError struct {
+		Message string       `json:"message"`
+		Fields  []FieldError `json:"fields"`
+	}
+
+	validateRule struct {
+		name  string
+		param string
+		num   float64
+		re    *regexp.Regexp
+		field int
+	}
+
+	fieldValidator struct {
+		index int
+		name  string
+		rules []validateRule
+	}
+
+	structValidator struct {
+		fields []fieldValidator
+		err    error
+	}
+)
+
+// Error returns the string representation of the failures.
+func (e *ValidationError) Error() string {
+	var builder strings.Builder
+	builder.WriteString(e.Message)
+	for i, field := range e.Fields {
+		if i == 0 {
+			builder.WriteString(": ")
+		} else {
+			builder.WriteString("; ")
+		}
+		builder.WriteString(field.Field)
+		builder.WriteByte(' ')
+		builder.WriteString(field.Message)
+	}
+
+	return builder.String()
+}
+
+// Validate validates v with the rules in the validate tags, like:
+//  Name     string   `json:"name" validate:"required,min=2,max=20"`
+//  Email    string   `json:"email" validate:"omitempty,email"`
+//  Code     string   `json:"code" validate:"regexp=^[0-9]{6}$"`
+//  Tags     []string `json:"tags" validate:"max=5"`
+//  Password string   `json:"password" validate:"min=8"`
+//  Confirm  string   `json:"confirm" validate:"eqfield=Password"`
+// The supported rules are required, omitempty, min, max, len, regexp, email,
+// eqfield, nefield, gtfield and ltfield. Commas in regexps need to be escaped as \,.
+// min, max and len check the lengths of strings, slices and maps, and the values of numbers.
+// All the failed fields are returned in a *ValidationError.
+func Validate(v interface{}) error {
+	val := reflect.ValueOf(v)
+	for val.Kind() == reflect.Ptr {
+		if val.IsNil() {
+			return nil
+		}
+		val = val.Elem()
+	}
+
+	if val.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var fields []FieldError
+	if err := validateStruct(val, "", &fields); err != nil {
+		return err
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return &ValidationError{
+		Message: validateMessage,
+		Fields:  fields,
+	}
+}
+
+func (r validateRule) check(val reflect.Value, parent reflect.Value) (string, bool) {
+	switch r.name {
+	case requiredRule:
+		if val.IsZero() {
+			return "is required", false
+		}
+	case minRule:
+		if size, ok := sizeOf(val); ok && size < r.num {
+			return fmt.Sprintf("%s must be at least %s", sizeName(val), r.param), false
+		}
+	case maxRule:
+		if size, ok := sizeOf(val); ok && size > r.num {
+			return fmt.Sprintf("%s must be at most %s", sizeName(val), r.param), false
+		}
+	case lenRule:
+		if size, ok := sizeOf(val); ok && size != r.num {
+			return fmt.Sprintf("%s must be %s", sizeName(val), r.param), false
+		}
+	case regexpRule:
+		if val.Kind() == reflect.String && !r.re.MatchString(val.String()) {
+			return fmt.Sprintf("must match %s", r.param), false
+		}
+	case emailRule:
+		if val.Kind() == reflect.String && !isEmail(val.String()) {
+			return "must be a valid email address", false
+		}
+	case eqFieldRule, neFieldRule, gtFieldRule, ltFieldRule:
+		return r.checkField(val, indirect(parent.Field(r.field)))
+	}
+
+	return "", true
+}
+
+func (r validateRule) checkField(val, other reflect.Value) (string, bool) {
+	if !val.IsValid() || !other.IsValid() {
+		return "", true
+	}
+
+	cmp, ok := compareValues(val, other)
+	if !ok {
+		return fmt.Sprintf(notComparable, r.param), false
+	}
+
+	switch r.name {
+	case eqFieldRule:
+		if cmp != 0 {
+			return fmt.Sprintf("must be equal to %s", r.param), false
+		}
+	case neFieldRule:
+		if cmp == 0 {
+			return fmt.Sprintf("must not be equal to %s", r.param), false
+		}
+	case gtFieldRule:
+		if cmp <= 0 {
+			return fmt.Sprintf("must be greater than %s", r.param), false
+		}
+	case ltFieldRule:
+		if cmp >= 0 {
+			return fmt.Sprintf("must be less than %s", r.param), false
+		}
+	}
+
+	return "", true
+}
+
+func buildStructValidator(tp reflect.Type) *structValidator {
+	var sv structValidator
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			continue
+		}
+
+		fv := fieldValidator{
+			index: i,
+			name:  fieldNameOf(field),
+		}
+		if tag, ok := field.Tag.Lookup(validateTagKey); ok {
+			rules, err := parseValidateRules(tp, field, tag)
+			if err != nil {
+				return &structValidator{err: err}
+			}
+			fv.rules = rules
+		}
+
+		sv.fields = append(sv.fields, fv)
+	}
+
+	return &sv
+}
+
+func compareValues(val, other reflect.Value) (int, bool) {
+	if val.Type() == timeType && other.Type() == timeType {
+		left := val.Interface().(time.Time)
+		right := other.Interface().(time.Time)
+		switch {
+		case left.Before(right):
+			return -1, true
+		case left.After(right):
+			return 1, true
+		default:
+			return 0, true
+		}
+	}
+
+	if val.Kind() == reflect.String && other.Kind() == reflect.String {
+		return strings.Compare(val.String(), other.String()), true
+	}
+
+	left, ok := numberOf(val)
+	if !ok {
+		return 0, false
+	}
+
+	right, ok := numberOf(other)
+	if !ok {
+		return 0, false
+	}
+
+	switch {
+	case left < right:
+		return -1, true
+	case left > right:
+		return 1, true
+	default:
+		return 0, true
+	}
+}
+
+func fieldNameOf(field reflect.StructField) string {
+	for _, tag := range nameTags {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+
+		name := strings.TrimSpace(strings.SplitN(value, ",", 2)[0])
+		if len(name) > 0 && name != "-" {
+			return name
+		}
+	}
+
+	return field.Name
+}
+
+func getStructValidator(tp reflect.Type) *structValidator {
+	if val, ok := validatorsCache.Load(tp); ok {
+		return val.(*structValidator)
+	}
+
+	sv := buildStructValidator(tp)
+	validatorsCache.Store(tp, sv)
+	return sv
+}
+
+func indirect(val reflect.Value) reflect.Value {
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return reflect.Value{}
+		}
+		val = val.Elem()
+	}
+
+	return val
+}
+
+func isEmail(s string) bool {
+	addr, err := mail.ParseAddress(s)
+	return err == nil && addr.Address == s
+}
+
+func joinPath(prefix, name string) string {
+	if len(prefix) == 0 {
+		return name
+	}
+
+	return prefix + string(delimiter) + name
+}
+
+func numberOf(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return val.Float(), true
+	default:
+		return 0, false
+	}
+}
+
+func parseValidateRules(tp reflect.Type, field reflect.StructField, tag string) ([]validateRule, error) {
+	var rules []validateRule
+	for _, segment := range splitRules(tag) {
+		segment = strings.TrimSpace(segment)
+		if len(segment) == 0 {
+			continue
+		}
+
+		var rule validateRule
+		kv := strings.SplitN(segment, equalToken, 2)
+		rule.name = strings.TrimSpace(kv[0])
+		if len(kv) == 2 {
+			rule.param = kv[1]
+		}
+
+		switch rule.name {
+		case requiredRule, omitEmptyRule, emailRule:
+		case minRule, maxRule, lenRule:
+			num, err := strconv.ParseFloat(strings.TrimSpace(rule.param), 64)
+			if err != nil {
+				return nil, fmt.Errorf("field %s has wrong %s rule in %s", field.Name, rule.name, tp)
+			}
+			rule.num = num
+		case regexpRule:
+			re, err := regexp.Compile(rule.param)
+			if err != nil {
+				return nil, fmt.Errorf("field %s has wrong regexp rule in %s: %v", field.Name, tp, err)
+			}
+			rule.re = re
+		case eqFieldRule, neFieldRule, gtFieldRule, ltFieldRule:
+			other, ok := tp.FieldByName(strings.TrimSpace(rule.param))
+			if !ok || len(other.Index) != 1 {
+				return nil, fmt.Errorf(fieldNotExists, rule.param, field.Name, tp)
+			}
+			rule.field = other.Index[0]
+		default:
+			return nil, fmt.Errorf("field %s has unknown validate rule %q in %s", field.Name, rule.name, tp)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func sizeName(val reflect.Value) string {
+	switch val.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		return "length"
+	default:
+		return "value"
+	}
+}
+
+func sizeOf(val reflect.Value) (float64, bool) {
+	switch val.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(val.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(val.Len()), true
+	default:
+		return numberOf(val)
+	}
+}
+
+// splitRules splits the rules by commas, except the escaped ones as \,.
+func splitRules(tag string) []string {
+	var segments []string
+	var buf strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == escapeChar && i+1 < len(tag) && tag[i+1] == ruleSeparator:
+			buf.WriteByte(ruleSeparator)
+			i++
+		case tag[i] == ruleSeparator:
+			segments = append(segments, buf.String())
+			buf.Reset()
+		default:
+			buf.WriteByte(tag[i])
+		}
+	}
+	segments = append(segments, buf.String())
+
+	return segments
+}
+
+func validateStruct(val reflect.Value, prefix string, failures *[]FieldError) error {
+	sv := getStructValidator(val.Type())
+	if sv.err != nil {
+		return sv.err
+	}
+
+	for _, fv := range sv.fields {
+		field := val.Field(fv.index)
+		path := prefix
+		if !val.Type().Field(fv.index).Anonymous {
+			path = joinPath(prefix, fv.name)
+		}
+
+		if !validateField(val, field, fv.rules, path, failures) {
+			continue
+		}
+
+		if err := validateNested(indirect(field), path, failures); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validateField validates field with rules, returns true if the nested values need to be validated.
+func validateField(parent, field reflect.Value, rules []validateRule, path string,
+	failures *[]FieldError) bool {
+	val := indirect(field)
+	for _, rule := range rules {
+		if rule.name == omitEmptyRule {
+			if !val.IsValid() || val.IsZero() {
+				return false
+			}
+			continue
+		}
+
+		if rule.name != requiredRule && !val.IsValid() {
+			continue
+		}
+
+		checked := val
+		if rule.name == requiredRule {
+			checked = field
+		}
+		if msg, ok := rule.check(checked, parent); !ok {
+			*failures = append(*failures, FieldError{
+				Field:   path,
+				Rule:    rule.name,
+				Message: msg,
+			})
+			return false
+		}
+	}
+
+	return val.IsValid()
+}
+
+func validateNested(val reflect.Value, path string, failures *[]FieldError) error {
+	switch val.Kind() {
+	case reflect.Struct:
+		if val.Type() == timeType {
+			return nil
+		}
+
+		return validateStruct(val, path, failures)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < val.Len(); i++ {
+			item := indirect(val.Index(i))
+			if err := validateNested(item, fmt.Sprintf("%s[%d]", path, i), failures); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := val.MapRange()
+		for iter.Next() {
+			item := indirect(iter.Value())
+			key := fmt.Sprintf("%s[%v]", path, iter.Key().Interface())
+			if err := validateNested(item, key, failures); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
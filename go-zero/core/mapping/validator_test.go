@@ -0,0 +1,151 @@
+package mapping
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	type (
+		address struct {
+			Zipcode string `json:"zipcode" validate:"len=6,regexp=^[0-9]+$"`
+		}
+
+		user struct {
+			Name      string             `json:"name" validate:"required,min=2,max=5"`
+			Email     string             `json:"email,optional" validate:"omitempty,email"`
+			Age       int                `form:"age" validate:"min=18"`
+			Tags      []string           `json:"tags" validate:"max=2"`
+			Password  string             `json:"password" validate:"min=4"`
+			Confirm   string             `json:"confirm" validate:"eqfield=Password"`
+			Start     time.Time          `json:"start"`
+			End       time.Time          `json:"end" validate:"gtfield=Start"`
+			Addresses []address          `json:"addresses"`
+			Primary   *address           `json:"primary"`
+			Extra     map[string]address `json:"extra"`
+			Nickname  *string            `json:"nickname" validate:"min=2"`
+			Ignored   string
+		}
+	)
+
+	now := time.Now()
+	t.Run("valid", func(t *testing.T) {
+		assert.Nil(t, Validate(&user{
+			Name:      "kevin",
+			Age:       18,
+			Password:  "1234",
+			Confirm:   "1234",
+			Start:     now,
+			End:       now.Add(time.Hour),
+			Addresses: []address{{Zipcode: "200000"}},
+		}))
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		err := Validate(&user{
+			Name:      "k",
+			Email:     "kevin",
+			Age:       17,
+			Tags:      []string{"a", "b", "c"},
+			Password:  "1234",
+			Confirm:   "4321",
+			Start:     now,
+			End:       now,
+			Addresses: []address{{Zipcode: "200000"}, {Zipcode: "20000a"}},
+			Primary:   &address{Zipcode: "1"},
+			Extra:     map[string]address{"home": {Zipcode: "1"}},
+		})
+		ve, ok := err.(*ValidationError)
+		assert.True(t, ok)
+		assert.EqualValues(t, []FieldError{
+			{Field: "name", Rule: "min", Message: "length must be at least 2"},
+			{Field: "email", Rule: "email", Message: "must be a valid email address"},
+			{Field: "age", Rule: "min", Message: "value must be at least 18"},
+			{Field: "tags", Rule: "max", Message: "length must be at most 2"},
+			{Field: "confirm", Rule: "eqfield", Message: "must be equal to Password"},
+			{Field: "end", Rule: "gtfield", Message: "must be greater than Start"},
+			{Field: "addresses[1].zipcode", Rule: "regexp", Message: "must match ^[0-9]+$"},
+			{Field: "primary.zipcode", Rule: "len", Message: "length must be 6"},
+			{Field: "extra[home].zipcode", Rule: "len", Message: "length must be 6"},
+		}, ve.Fields)
+		assert.Contains(t, ve.Error(), "validation failed: name length must be at least 2; email")
+	})
+
+	t.Run("required", func(t *testing.T) {
+		err := Validate(&user{})
+		ve, ok := err.(*ValidationError)
+		assert.True(t, ok)
+		assert.Equal(t, FieldError{
+			Field:   "name",
+			Rule:    "required",
+			Message: "is required",
+		}, ve.Fields[0])
+	})
+
+	t.Run("non struct", func(t *testing.T) {
+		var u *user
+		assert.Nil(t, Validate(u))
+		assert.Nil(t, Validate(1))
+	})
+}
+
+func TestValidate_EscapedRegexp(t *testing.T) {
+	var v struct {
+		Code string `validate:"regexp=^[a-z]{2\\,3}$"`
+	}
+
+	v.Code = "abc"
+	assert.Nil(t, Validate(&v))
+	v.Code = "abcd"
+	assert.NotNil(t, Validate(&v))
+}
+
+func TestValidate_Compare(t *testing.T) {
+	var v struct {
+		Min   float64 `json:"min"`
+		Max   int     `json:"max" validate:"gtfield=Min"`
+		Less  uint    `json:"less" validate:"ltfield=Max"`
+		Other string  `json:"other" validate:"nefield=Name"`
+		Name  string  `json:"name"`
+		Mixed string  `json:"mixed" validate:"eqfield=Max"`
+	}
+
+	v.Min = 1.5
+	v.Max = 2
+	v.Less = 1
+	v.Other = "a"
+	v.Name = "a"
+	v.Mixed = "2"
+	ve, ok := Validate(&v).(*ValidationError)
+	assert.True(t, ok)
+	assert.EqualValues(t, []FieldError{
+		{Field: "other", Rule: "nefield", Message: "must not be equal to Name"},
+		{Field: "mixed", Rule: "eqfield", Message: "cannot be compared with Max"},
+	}, ve.Fields)
+}
+
+func TestValidate_BadRules(t *testing.T) {
+	tests := []interface{}{
+		&struct {
+			Name string `validate:"min=a"`
+		}{},
+		&struct {
+			Name string `validate:"regexp=[a-"`
+		}{},
+		&struct {
+			Name string `validate:"eqfield=Missing"`
+		}{},
+		&struct {
+			Name string `validate:"unknown"`
+		}{},
+	}
+
+	for _, test := range tests {
+		err := Validate(test)
+		assert.NotNil(t, err)
+		_, ok := err.(*ValidationError)
+		assert.False(t, ok)
+	}
+}
@@ -0,0 +1,58 @@
+package requestid
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/utils"
+)
+
+const (
+	// HeaderKey is the http header key of the request id.
+	HeaderKey = "X-Request-Id"
+	// MetadataKey is the grpc metadata key of the request id.
+	MetadataKey = "x-request-id"
+
+	// maxLength is the max length of the request ids from the clients.
+	maxLength = 128
+)
+
+type requestIdKey struct{}
+
+// FromContext returns the request id in ctx, empty string if not set.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	if id, ok := ctx.Value(requestIdKey{}).(string); ok {
+		return id
+	}
+
+	return ""
+}
+
+// IsValid checks if id is acceptable from the clients, which is not empty, not too long,
+// and only with the printable ascii characters, to avoid log injections.
+func IsValid(id string) bool {
+	if len(id) == 0 || len(id) > maxLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] <= ' ' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// New generates a new request id.
+func New() string {
+	return utils.NewUuid()
+}
+
+// NewContext returns a new context with the request id id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIdKey{}, id)
+}
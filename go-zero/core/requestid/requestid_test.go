@@ -0,0 +1,47 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+	//nolint:staticcheck
+	assert.Empty(t, FromContext(nil))
+	assert.Empty(t, FromContext(context.WithValue(context.Background(), requestIdKey{}, 1)))
+
+	ctx := NewContext(context.Background(), "foo")
+	assert.Equal(t, "foo", FromContext(ctx))
+}
+
+func TestIsValid(t *testing.T) {
+	tests := []struct {
+		id    string
+		valid bool
+	}{
+		{id: "", valid: false},
+		{id: "abc-123", valid: true},
+		{id: "a b", valid: false},
+		{id: "a\nb", valid: false},
+		{id: "中文", valid: false},
+		{id: strings.Repeat("a", maxLength), valid: true},
+		{id: strings.Repeat("a", maxLength+1), valid: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.id, func(t *testing.T) {
+			assert.Equal(t, test.valid, IsValid(test.id))
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	id := New()
+	assert.True(t, IsValid(id))
+	assert.NotEqual(t, id, New())
+}
@@ -3,11 +3,16 @@ package search
 import (
 	"errors"
 	"fmt"
+	"regexp"
+	"strings"
 )
 
 const (
-	colon = ':'
-	slash = '/'
+	colon      = ':'
+	slash      = '/'
+	star       = '*'
+	leftBrace  = '{'
+	rightBrace = '}'
 )
 
 var (
@@ -21,6 +26,19 @@ var (
 	errInvalidState = errors.New("search tree is in an invalid state")
 	// errNotFromRoot means path is not starting with slash.
 	errNotFromRoot = errors.New("path should start with /")
+	// errCatchAllNotLast means the catch-all segment is not the last one.
+	errCatchAllNotLast = errors.New("catch-all segment must be the last one")
+	// errBadSegment means the segment is not well formed, like unclosed braces or bad regex.
+	errBadSegment = errors.New("bad segment")
+
+	// typeConstraints are the shortcuts of the commonly used constraints, like :id{int}.
+	typeConstraints = map[string]string{
+		"int":   `-?[0-9]+`,
+		"uint":  `[0-9]+`,
+		"float": `-?[0-9]+(\.[0-9]+)?`,
+		"alpha": `[a-zA-Z]+`,
+		"uuid":  `[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`,
+	}
 
 	// NotFound is used to hold the not found result.
 	NotFound Result
@@ -34,14 +52,35 @@ type (
 		found bool
 	}
 
+	// segment is the parsed pattern of a path segment.
+	segment struct {
+		name string
+		// the constraint of the parameter, nil means any value.
+		re *regexp.Regexp
+	}
+
 	node struct {
-		item     interface{}
+		item interface{}
+		// seg is the parsed pattern if the node is a parameter or a catch-all.
+		seg *segment
+		// the static children and the parameter children.
 		children [2]map[string]*node
+		// the keys of the parameter children in matching order,
+		// the constrained ones before the plain ones, then in adding order.
+		params   []string
+		catchAll *node
 	}
 
 	// A Tree is a search tree.
+	// The segments of routes are static segments like users, parameters like :id,
+	// constrained parameters like :id{[0-9]+} or :id{int}, and catch-all segments like *path,
+	// which match the rest of the path and must be the last ones.
+	// The segments are matched with the precedence of static segments, constrained parameters,
+	// plain parameters and catch-all segments.
 	Tree struct {
 		root *node
+		// the added routes, keyed by their shapes, to detect the ambiguous routes.
+		shapes map[string]string
 	}
 
 	// A Result is a search result from tree.
@@ -54,12 +93,14 @@ type (
 // NewTree returns a Tree.
 func NewTree() *Tree {
 	return &Tree{
-		root: newNode(nil),
+		root:   newNode(nil),
+		shapes: make(map[string]string),
 	}
 }
 
 // Add adds item to associate with route.
 // item 在路径搜索树中，是相应路径的处理方法
+// The routes with the same shape, like /users/:id and /users/:name, are reported as conflicts.
 func (t *Tree) Add(route string, item interface{}) error {
 	if len(route) == 0 || route[0] != slash {
 		return errNotFromRoot
@@ -69,12 +110,34 @@ func (t *Tree) Add(route string, item interface{}) error {
 		return errEmptyItem
 	}
 
-	err := add(t.root, route[1:], item)
+	segments, err := splitRoute(route[1:])
+	if err != nil {
+		return badSegment(route, err)
+	}
+
+	shape, err := shapeOf(segments)
+	if err != nil {
+		return badSegment(route, err)
+	}
+	if prev, ok := t.shapes[shape]; ok {
+		if strings.TrimSuffix(prev, "/") == strings.TrimSuffix(route, "/") {
+			return duplicatedItem(route)
+		}
+
+		return conflictedItem(route, prev)
+	}
+
+	err = add(t.root, segments, item)
 	switch err {
+	case nil:
+		t.shapes[shape] = route
+		return nil
 	case errDupItem:
 		return duplicatedItem(route)
 	case errDupSlash:
 		return duplicatedSlash(route)
+	case errCatchAllNotLast, errBadSegment:
+		return badSegment(route, err)
 	default:
 		return err
 	}
@@ -103,8 +166,8 @@ func (t *Tree) next(n *node, route string, result *Result) bool {
 		}
 
 		token := route[:i]
-		return n.forEach(func(k string, v *node) bool {
-			r := match(k, token)
+		if n.forEach(func(k string, v *node) bool {
+			r := match(k, v, token)
 			if !r.found || !t.next(v, route[i+1:], result) {
 				return false
 			}
@@ -113,11 +176,15 @@ func (t *Tree) next(n *node, route string, result *Result) bool {
 			}
 
 			return true
-		})
+		}) {
+			return true
+		}
+
+		return n.matchCatchAll(route, result)
 	}
 
-	return n.forEach(func(k string, v *node) bool {
-		if r := match(k, route); r.found && v.item != nil {
+	if n.forEach(func(k string, v *node) bool {
+		if r := match(k, v, route); r.found && v.item != nil {
 			result.Item = v.item
 			if r.named {
 				addParam(result, r.key, r.value)
@@ -127,15 +194,24 @@ func (t *Tree) next(n *node, route string, result *Result) bool {
 		}
 
 		return false
-	})
+	}) {
+		return true
+	}
+
+	return n.matchCatchAll(route, result)
 }
 
+// forEach calls fn on the children in matching order, until fn returns true.
 func (nd *node) forEach(fn func(string, *node) bool) bool {
-	for _, children := range nd.children {
-		for k, v := range children {
-			if fn(k, v) {
-				return true
-			}
+	for k, v := range nd.children[0] {
+		if fn(k, v) {
+			return true
+		}
+	}
+
+	for _, k := range nd.params {
+		if fn(k, nd.children[1][k]) {
+			return true
 		}
 	}
 
@@ -150,8 +226,23 @@ func (nd *node) getChildren(route string) map[string]*node {
 	return nd.children[0]
 }
 
-func add(nd *node, route string, item interface{}) error {
+func (nd *node) matchCatchAll(route string, result *Result) bool {
+	if nd.catchAll == nil {
+		return false
+	}
+
+	route = strings.TrimSuffix(route, string(slash))
 	if len(route) == 0 {
+		return false
+	}
+
+	result.Item = nd.catchAll.item
+	addParam(result, nd.catchAll.seg.name, route)
+	return true
+}
+
+func add(nd *node, segments []string, item interface{}) error {
+	if len(segments) == 0 {
 		if nd.item != nil {
 			return errDupItem
 		}
@@ -160,42 +251,70 @@ func add(nd *node, route string, item interface{}) error {
 		return nil
 	}
 
-	if route[0] == slash {
+	token := segments[0]
+	if len(token) == 0 {
+		// the trailing slash is ignored, like /a/b/ is the same as /a/b
+		if len(segments) == 1 {
+			return add(nd, nil, item)
+		}
+
 		return errDupSlash
 	}
 
-	for i := range route {
-		if route[i] != slash {
-			continue
+	if token[0] == star {
+		if len(segments) > 1 {
+			return errCatchAllNotLast
+		}
+		if len(token) == 1 {
+			return errBadSegment
 		}
 
-		token := route[:i]
-		children := nd.getChildren(token)
-		if child, ok := children[token]; ok {
-			if child != nil {
-				return add(child, route[i+1:], item)
-			}
+		if nd.catchAll != nil {
+			return errDupItem
+		}
+
+		nd.catchAll = newNode(item)
+		nd.catchAll.seg = &segment{name: token[1:]}
+		return nil
+	}
 
+	children := nd.getChildren(token)
+	child, ok := children[token]
+	if ok {
+		if child == nil {
 			return errInvalidState
 		}
+	} else {
+		child = newNode(nil)
+		if token[0] == colon {
+			seg, err := parseSegment(token)
+			if err != nil {
+				return err
+			}
 
-		child := newNode(nil)
+			child.seg = seg
+			nd.addParam(token, seg)
+		}
 		children[token] = child
-		return add(child, route[i+1:], item)
 	}
 
-	children := nd.getChildren(route)
-	if child, ok := children[route]; ok {
-		if child.item != nil {
-			return errDupItem
-		}
+	return add(child, segments[1:], item)
+}
 
-		child.item = item
-	} else {
-		children[route] = newNode(item)
+func (nd *node) addParam(key string, seg *segment) {
+	if seg.re == nil {
+		nd.params = append(nd.params, key)
+		return
 	}
 
-	return nil
+	// insert after the last constrained parameter
+	var i int
+	for i < len(nd.params) && nd.children[1][nd.params[i]].seg.re != nil {
+		i++
+	}
+	nd.params = append(nd.params, "")
+	copy(nd.params[i+1:], nd.params[i:])
+	nd.params[i] = key
 }
 
 func addParam(result *Result, k, v string) {
@@ -206,6 +325,14 @@ func addParam(result *Result, k, v string) {
 	result.Params[k] = v
 }
 
+func badSegment(route string, err error) error {
+	return fmt.Errorf("%s for %s", err.Error(), route)
+}
+
+func conflictedItem(item, prev string) error {
+	return fmt.Errorf("route %s conflicts with %s", item, prev)
+}
+
 func duplicatedItem(item string) error {
 	return fmt.Errorf("duplicated item for %s", item)
 }
@@ -214,10 +341,14 @@ func duplicatedSlash(item string) error {
 	return fmt.Errorf("duplicated slash for %s", item)
 }
 
-func match(pat, token string) innerResult {
+func match(pat string, nd *node, token string) innerResult {
 	if pat[0] == colon {
+		if nd.seg.re != nil && !nd.seg.re.MatchString(token) {
+			return innerResult{}
+		}
+
 		return innerResult{
-			key:   pat[1:],
+			key:   nd.seg.name,
 			value: token,
 			named: true,
 			found: true,
@@ -238,3 +369,92 @@ func newNode(item interface{}) *node {
 		},
 	}
 }
+
+// parseSegment parses the parameter segment like :id or :id{[0-9]+}.
+func parseSegment(token string) (*segment, error) {
+	start := strings.IndexByte(token, leftBrace)
+	if start < 0 {
+		return &segment{name: token[1:]}, nil
+	}
+
+	if token[len(token)-1] != rightBrace || start == 1 {
+		return nil, errBadSegment
+	}
+
+	expr := token[start+1 : len(token)-1]
+	if constraint, ok := typeConstraints[expr]; ok {
+		expr = constraint
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, errBadSegment
+	}
+
+	return &segment{
+		name: token[1:start],
+		re:   re,
+	}, nil
+}
+
+// shapeOf returns the shape of the route, which ignores the parameter names,
+// the routes with the same shape are ambiguous.
+func shapeOf(segments []string) (string, error) {
+	var builder strings.Builder
+	for i, token := range segments {
+		if len(token) == 0 && i == len(segments)-1 {
+			break
+		}
+
+		builder.WriteByte(slash)
+		switch {
+		case len(token) == 0:
+		case token[0] == colon:
+			seg, err := parseSegment(token)
+			if err != nil {
+				return "", err
+			}
+
+			builder.WriteByte(colon)
+			if seg.re != nil {
+				builder.WriteString(seg.re.String())
+			}
+		case token[0] == star:
+			builder.WriteByte(star)
+		default:
+			builder.WriteString(token)
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// splitRoute splits the route by slashes, the slashes in braces are kept, like :id{[^/]+}.
+func splitRoute(route string) ([]string, error) {
+	var segments []string
+	var depth, start int
+	for i := 0; i < len(route); i++ {
+		switch route[i] {
+		case leftBrace:
+			depth++
+		case rightBrace:
+			depth--
+			if depth < 0 {
+				return nil, errBadSegment
+			}
+		case slash:
+			if depth == 0 {
+				segments = append(segments, route[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, errBadSegment
+	}
+
+	if start < len(route) || len(segments) > 0 {
+		segments = append(segments, route[start:])
+	}
+
+	return segments, nil
+}
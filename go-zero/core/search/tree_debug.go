@@ -30,4 +30,8 @@ func printNode(n *node, depth int) {
 			printNode(v, depth+1)
 		}
 	}
+
+	if n.catchAll != nil {
+		fmt.Printf("%s%c%s:%#v\n", string(indent), star, n.catchAll.seg.name, n.catchAll.item)
+	}
 }
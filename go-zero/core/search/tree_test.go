@@ -187,6 +187,176 @@ func TestSearchInvalidItem(t *testing.T) {
 	assert.Equal(t, errEmptyItem, err)
 }
 
+func TestSearchWithConstraints(t *testing.T) {
+	routes := []mockedRoute{
+		{"/users/:name", 1},
+		{"/users/:id{int}", 2},
+		{"/users/me", 3},
+		{"/users/:code{[a-z]{2}-[0-9]+}/orders", 4},
+		{"/files/*path", 5},
+		{"/files/readme", 6},
+		{"/files/:dir{[^/]+}/index", 7},
+	}
+
+	tests := []struct {
+		query    string
+		expect   int
+		params   map[string]string
+		contains bool
+	}{
+		{
+			query:    "/users/me",
+			expect:   3,
+			contains: true,
+		},
+		{
+			query:    "/users/123",
+			expect:   2,
+			params:   map[string]string{"id": "123"},
+			contains: true,
+		},
+		{
+			query:    "/users/-1",
+			expect:   2,
+			params:   map[string]string{"id": "-1"},
+			contains: true,
+		},
+		{
+			query:    "/users/kevin",
+			expect:   1,
+			params:   map[string]string{"name": "kevin"},
+			contains: true,
+		},
+		{
+			query:    "/users/ab-12/orders",
+			expect:   4,
+			params:   map[string]string{"code": "ab-12"},
+			contains: true,
+		},
+		{
+			query:    "/users/abc/orders",
+			contains: false,
+		},
+		{
+			query:    "/files/readme",
+			expect:   6,
+			contains: true,
+		},
+		{
+			query:    "/files/docs/index",
+			expect:   7,
+			params:   map[string]string{"dir": "docs"},
+			contains: true,
+		},
+		{
+			query:    "/files/a/b/c",
+			expect:   5,
+			params:   map[string]string{"path": "a/b/c"},
+			contains: true,
+		},
+		{
+			query:    "/files/a/b/",
+			expect:   5,
+			params:   map[string]string{"path": "a/b"},
+			contains: true,
+		},
+		{
+			query:    "/files/",
+			contains: false,
+		},
+		{
+			query:    "/files",
+			contains: false,
+		},
+	}
+
+	tree := NewTree()
+	for _, r := range routes {
+		assert.Nil(t, tree.Add(r.route, r.value))
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.query, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				result, ok := tree.Search(test.query)
+				assert.Equal(t, test.contains, ok)
+				if ok {
+					assert.EqualValues(t, test.params, result.Params)
+					assert.Equal(t, test.expect, result.Item.(int))
+				}
+			}
+		})
+	}
+}
+
+func TestAddConflict(t *testing.T) {
+	tests := []struct {
+		routes []string
+		err    string
+	}{
+		{
+			routes: []string{"/users/:id", "/users/:name"},
+			err:    "route /users/:name conflicts with /users/:id",
+		},
+		{
+			routes: []string{"/users/:id/books", "/users/:uid/books/"},
+			err:    "route /users/:uid/books/ conflicts with /users/:id/books",
+		},
+		{
+			routes: []string{"/users/:id{int}", "/users/:uid{int}"},
+			err:    "route /users/:uid{int} conflicts with /users/:id{int}",
+		},
+		{
+			routes: []string{"/files/*path", "/files/*name"},
+			err:    "route /files/*name conflicts with /files/*path",
+		},
+		{
+			routes: []string{"/users/:id", "/users/:id/"},
+			err:    "duplicated item for /users/:id/",
+		},
+		{
+			routes: []string{"/users/:id{int}", "/users/:id"},
+		},
+		{
+			routes: []string{"/users/:id", "/users/*id"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(strings.Join(test.routes, ","), func(t *testing.T) {
+			tree := NewTree()
+			assert.Nil(t, tree.Add(test.routes[0], 1))
+			err := tree.Add(test.routes[1], 2)
+			if len(test.err) > 0 {
+				assert.EqualError(t, err, test.err)
+			} else {
+				assert.Nil(t, err)
+			}
+		})
+	}
+}
+
+func TestAddBadSegments(t *testing.T) {
+	routes := []string{
+		"/files/*path/more",
+		"/files/*",
+		"/users/:id{[0-9]+",
+		"/users/:id[0-9]+}",
+		"/users/:id{(}",
+		"/users/:{int}",
+	}
+
+	for _, route := range routes {
+		route := route
+		t.Run(route, func(t *testing.T) {
+			tree := NewTree()
+			assert.NotNil(t, tree.Add(route, 1))
+		})
+	}
+}
+
 func BenchmarkSearchTree(b *testing.B) {
 	const (
 		avgLen  = 1000
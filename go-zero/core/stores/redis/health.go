@@ -0,0 +1,22 @@
+package redis
+
+import (
+	"context"
+	"errors"
+
+	"github.com/zeromicro/go-zero/core/health"
+)
+
+// ErrPingFailed is an error that indicates failed to ping redis.
+var ErrPingFailed = errors.New("failed to ping redis")
+
+// NewHealthProbe returns a health.Probe that pings the redis.
+func NewHealthProbe(rds *Redis) health.Probe {
+	return func(ctx context.Context) error {
+		if !rds.PingCtx(ctx) {
+			return ErrPingFailed
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,19 @@
+package redis
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealthProbe(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	probe := NewHealthProbe(New(s.Addr()))
+	assert.Nil(t, probe(context.Background()))
+
+	s.Close()
+	assert.Equal(t, ErrPingFailed, probe(context.Background()))
+}
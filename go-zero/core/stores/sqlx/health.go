@@ -0,0 +1,19 @@
+package sqlx
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/health"
+)
+
+// NewHealthProbe returns a health.Probe that pings the database of conn.
+func NewHealthProbe(conn SqlConn) health.Probe {
+	return func(ctx context.Context) error {
+		db, err := conn.RawDB()
+		if err != nil {
+			return err
+		}
+
+		return db.PingContext(ctx)
+	}
+}
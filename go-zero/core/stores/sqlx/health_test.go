@@ -0,0 +1,30 @@
+package sqlx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewHealthProbe(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.Nil(t, err)
+	defer db.Close()
+
+	probe := NewHealthProbe(NewSqlConnFromDB(db))
+	mock.ExpectPing()
+	assert.Nil(t, probe(context.Background()))
+
+	errPing := errors.New("ping failed")
+	mock.ExpectPing().WillReturnError(errPing)
+	assert.Equal(t, errPing, probe(context.Background()))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestNewHealthProbe_BadConn(t *testing.T) {
+	probe := NewHealthProbe(NewMysql("badsql"))
+	assert.NotNil(t, probe(context.Background()))
+}
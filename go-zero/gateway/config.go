@@ -0,0 +1,39 @@
+package gateway
+
+import (
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/zrpc"
+)
+
+type (
+	// GatewayConf is the configuration of a gateway, which exposes the grpc methods
+	// of the upstreams as http routes.
+	GatewayConf struct {
+		rest.RestConf
+		Upstreams []Upstream
+	}
+
+	// RouteMapping is a mapping from an http route to a grpc method.
+	RouteMapping struct {
+		// Method is the http method, like GET.
+		Method string
+		// Path is the http path, like /orders/:id, the path variables and the query
+		// parameters are set into the fields of the grpc request with the same names.
+		Path string
+		// RpcPath is the full name of the grpc method, like order.Order/GetOrder.
+		RpcPath string
+	}
+
+	// Upstream is a zrpc service to be exposed by the gateway.
+	// The routes are added for the methods with google.api.http annotations,
+	// and the methods mapped in Mappings.
+	Upstream struct {
+		// Name is the name of the upstream, used in the logs.
+		Name string `json:",optional"`
+		Grpc zrpc.RpcClientConf
+		// ProtoSets are the files generated by protoc with --descriptor_set_out and --include_imports,
+		// the descriptors are loaded by grpc reflection from the upstream if empty.
+		ProtoSets []string       `json:",optional"`
+		Mappings  []RouteMapping `json:",optional"`
+	}
+)
@@ -0,0 +1,203 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+
+	"google.golang.org/grpc"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const reflectionServiceName = "grpc.reflection.v1alpha.ServerReflection"
+
+// buildFiles builds the files from fdps, the dependencies not in fdps,
+// like google/protobuf/empty.proto, are looked up in protoregistry.GlobalFiles.
+func buildFiles(fdps map[string]*descriptorpb.FileDescriptorProto) (*protoregistry.Files, error) {
+	files := new(protoregistry.Files)
+
+	var register func(name string) error
+	register = func(name string) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+
+		fdp, ok := fdps[name]
+		if !ok {
+			fd, err := protoregistry.GlobalFiles.FindFileByPath(name)
+			if err != nil {
+				return fmt.Errorf("proto file %q not found", name)
+			}
+
+			imports := fd.Imports()
+			for i := 0; i < imports.Len(); i++ {
+				if err := register(imports.Get(i).Path()); err != nil {
+					return err
+				}
+			}
+
+			return files.RegisterFile(fd)
+		}
+
+		for _, dep := range fdp.GetDependency() {
+			if err := register(dep); err != nil {
+				return err
+			}
+		}
+
+		fd, err := protodesc.NewFile(fdp, files)
+		if err != nil {
+			return err
+		}
+
+		return files.RegisterFile(fd)
+	}
+
+	for name := range fdps {
+		if err := register(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return files, nil
+}
+
+// loadProtoSets loads the files from the descriptor sets generated by protoc.
+func loadProtoSets(protoSets []string) (*protoregistry.Files, error) {
+	fdps := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, protoSet := range protoSets {
+		content, err := ioutil.ReadFile(protoSet)
+		if err != nil {
+			return nil, err
+		}
+
+		var set descriptorpb.FileDescriptorSet
+		if err := proto.Unmarshal(content, &set); err != nil {
+			return nil, fmt.Errorf("bad proto set %q: %w", protoSet, err)
+		}
+
+		for _, fdp := range set.GetFile() {
+			fdps[fdp.GetName()] = fdp
+		}
+	}
+
+	return buildFiles(fdps)
+}
+
+// loadReflection loads the files of the services from the upstream by grpc reflection.
+func loadReflection(ctx context.Context, conn grpc.ClientConnInterface) (*protoregistry.Files, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := reflect(stream, &rpb.ServerReflectionRequest{
+		MessageRequest: &rpb.ServerReflectionRequest_ListServices{},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fdps := make(map[string]*descriptorpb.FileDescriptorProto)
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		if service.GetName() == reflectionServiceName {
+			continue
+		}
+
+		resp, err := reflect(stream, &rpb.ServerReflectionRequest{
+			MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{
+				FileContainingSymbol: service.GetName(),
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if err := addFileDescriptors(fdps, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	// the servers may skip the dependencies that already sent, fetch the missing ones
+	for missing := missingDependencies(fdps); len(missing) > 0; missing = missingDependencies(fdps) {
+		for _, name := range missing {
+			resp, err := reflect(stream, &rpb.ServerReflectionRequest{
+				MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{
+					FileByFilename: name,
+				},
+			})
+			if err != nil {
+				return nil, err
+			}
+			if err := addFileDescriptors(fdps, resp); err != nil {
+				return nil, err
+			}
+			if _, ok := fdps[name]; !ok {
+				return nil, fmt.Errorf("proto file %q not found by reflection", name)
+			}
+		}
+	}
+
+	return buildFiles(fdps)
+}
+
+func addFileDescriptors(fdps map[string]*descriptorpb.FileDescriptorProto,
+	resp *rpb.ServerReflectionResponse) error {
+	for _, content := range resp.GetFileDescriptorResponse().GetFileDescriptorProto() {
+		var fdp descriptorpb.FileDescriptorProto
+		if err := proto.Unmarshal(content, &fdp); err != nil {
+			return err
+		}
+
+		fdps[fdp.GetName()] = &fdp
+	}
+
+	return nil
+}
+
+func missingDependencies(fdps map[string]*descriptorpb.FileDescriptorProto) []string {
+	var missing []string
+	seen := make(map[string]struct{})
+	for _, fdp := range fdps {
+		for _, dep := range fdp.GetDependency() {
+			if _, ok := fdps[dep]; ok {
+				continue
+			}
+			if _, ok := seen[dep]; ok {
+				continue
+			}
+			if _, err := protoregistry.GlobalFiles.FindFileByPath(dep); err == nil {
+				continue
+			}
+
+			seen[dep] = struct{}{}
+			missing = append(missing, dep)
+		}
+	}
+
+	return missing
+}
+
+func reflect(stream rpb.ServerReflection_ServerReflectionInfoClient,
+	req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+	if err := stream.Send(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := stream.Recv()
+	if err != nil {
+		return nil, err
+	}
+
+	if errResp := resp.GetErrorResponse(); errResp != nil {
+		return nil, fmt.Errorf("reflection error: %s", errResp.GetErrorMessage())
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,182 @@
+package gateway
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+const testServiceName = "gateway.test.Health"
+
+func TestBuildFiles(t *testing.T) {
+	files, err := buildFiles(map[string]*descriptorpb.FileDescriptorProto{
+		"gateway_test.proto": testFileDescriptor(),
+	})
+	assert.NoError(t, err)
+	desc, err := files.FindDescriptorByName(testServiceName)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, desc.(protoreflect.ServiceDescriptor).Methods().Len())
+	// the dependencies are from the global files
+	_, err = files.FindFileByPath("grpc/health/v1/health.proto")
+	assert.NoError(t, err)
+
+	fdp := testFileDescriptor()
+	fdp.Dependency = append(fdp.Dependency, "not/exist.proto")
+	_, err = buildFiles(map[string]*descriptorpb.FileDescriptorProto{
+		"gateway_test.proto": fdp,
+	})
+	assert.Error(t, err)
+}
+
+func TestLoadProtoSets(t *testing.T) {
+	protoSet := writeProtoSet(t)
+	files, err := loadProtoSets([]string{protoSet})
+	assert.NoError(t, err)
+	_, err = files.FindDescriptorByName(testServiceName)
+	assert.NoError(t, err)
+
+	_, err = loadProtoSets([]string{protoSet + ".notexist"})
+	assert.Error(t, err)
+
+	bad := filepath.Join(t.TempDir(), "bad.pb")
+	assert.NoError(t, ioutil.WriteFile(bad, []byte("bad"), os.ModePerm))
+	_, err = loadProtoSets([]string{bad})
+	assert.Error(t, err)
+}
+
+func TestLoadReflection(t *testing.T) {
+	addr := startTestServer(t, true)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	files, err := loadReflection(context.Background(), conn)
+	assert.NoError(t, err)
+	_, err = files.FindDescriptorByName("grpc.health.v1.Health.Check")
+	assert.NoError(t, err)
+	_, err = files.FindDescriptorByName(reflectionServiceName)
+	assert.Error(t, err)
+}
+
+func TestLoadReflectionWithoutReflection(t *testing.T) {
+	addr := startTestServer(t, false)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	_, err = loadReflection(context.Background(), conn)
+	assert.Error(t, err)
+}
+
+func TestMissingDependencies(t *testing.T) {
+	fdp := testFileDescriptor()
+	fdp.Dependency = append(fdp.Dependency, "not/exist.proto")
+	assert.Equal(t, []string{"not/exist.proto"}, missingDependencies(map[string]*descriptorpb.FileDescriptorProto{
+		"gateway_test.proto": fdp,
+		"another.proto": {
+			Name:       proto.String("another.proto"),
+			Dependency: []string{"not/exist.proto", "gateway_test.proto"},
+		},
+	}))
+}
+
+// startTestServer starts a grpc server with the health service and the reflection service,
+// or with the same health service named gateway.test.Health that's described by testFileDescriptor,
+// which can't be described by the reflection service.
+func startTestServer(t *testing.T, withReflection bool) string {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	hs := health.NewServer()
+	hs.SetServingStatus("foo", grpc_health_v1.HealthCheckResponse_SERVING)
+	server := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, hs)
+	if withReflection {
+		reflection.Register(server)
+	} else {
+		desc := grpc_health_v1.Health_ServiceDesc
+		desc.ServiceName = testServiceName
+		server.RegisterService(&desc, hs)
+	}
+
+	go func() {
+		_ = server.Serve(lis)
+	}()
+	t.Cleanup(server.Stop)
+
+	return lis.Addr().String()
+}
+
+func testFileDescriptor() *descriptorpb.FileDescriptorProto {
+	checkOpts := new(descriptorpb.MethodOptions)
+	proto.SetExtension(checkOpts, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{
+			Get: "/v1/health/{service}",
+		},
+		AdditionalBindings: []*annotations.HttpRule{
+			{
+				Pattern: &annotations.HttpRule_Post{
+					Post: "/v1/health",
+				},
+				Body: "*",
+			},
+		},
+	})
+	watchOpts := new(descriptorpb.MethodOptions)
+	proto.SetExtension(watchOpts, annotations.E_Http, &annotations.HttpRule{
+		Pattern: &annotations.HttpRule_Get{
+			Get: "/v1/health/{service}/watch",
+		},
+	})
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("gateway_test.proto"),
+		Package:    proto.String("gateway.test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"grpc/health/v1/health.proto", "google/api/annotations.proto"},
+		Service: []*descriptorpb.ServiceDescriptorProto{
+			{
+				Name: proto.String("Health"),
+				Method: []*descriptorpb.MethodDescriptorProto{
+					{
+						Name:       proto.String("Check"),
+						InputType:  proto.String(".grpc.health.v1.HealthCheckRequest"),
+						OutputType: proto.String(".grpc.health.v1.HealthCheckResponse"),
+						Options:    checkOpts,
+					},
+					{
+						Name:            proto.String("Watch"),
+						InputType:       proto.String(".grpc.health.v1.HealthCheckRequest"),
+						OutputType:      proto.String(".grpc.health.v1.HealthCheckResponse"),
+						Options:         watchOpts,
+						ServerStreaming: proto.Bool(true),
+					},
+				},
+			},
+		},
+	}
+}
+
+func writeProtoSet(t *testing.T) string {
+	content, err := proto.Marshal(&descriptorpb.FileDescriptorSet{
+		File: []*descriptorpb.FileDescriptorProto{testFileDescriptor()},
+	})
+	assert.NoError(t, err)
+
+	protoSet := filepath.Join(t.TempDir(), "gateway_test.pb")
+	assert.NoError(t, ioutil.WriteFile(protoSet, content, os.ModePerm))
+	return protoSet
+}
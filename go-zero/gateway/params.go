@@ -0,0 +1,188 @@
+package gateway
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/zeromicro/go-zero/rest/pathvar"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const (
+	// bodyAll means the whole body is the request.
+	bodyAll      = "*"
+	fieldPathSep = "."
+)
+
+var (
+	errFieldNotFound = errors.New("field not found")
+	unmarshaler      = protojson.UnmarshalOptions{DiscardUnknown: true}
+)
+
+// populateField sets the values into the field of msg, the path is like user.name,
+// the names can be either the proto names or the json names.
+func populateField(msg protoreflect.Message, path string, values []string) error {
+	names := strings.Split(path, fieldPathSep)
+	for i, name := range names {
+		fd := findField(msg.Descriptor(), name)
+		if fd == nil {
+			return fmt.Errorf("%w: %s", errFieldNotFound, path)
+		}
+
+		if i == len(names)-1 {
+			return setField(msg, fd, values)
+		}
+
+		if fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+			return fmt.Errorf("field %q is not a message", path)
+		}
+		msg = msg.Mutable(fd).Message()
+	}
+
+	return nil
+}
+
+// populateRequest populates msg from the body, the query parameters and the path variables of r.
+// The body is not used if body is empty, the whole body is unmarshalled into msg if body is *,
+// otherwise into the field named body.
+func populateRequest(r *http.Request, msg protoreflect.Message, body string) error {
+	if len(body) > 0 {
+		content, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return err
+		}
+
+		if len(content) > 0 {
+			target := msg
+			if body != bodyAll {
+				fd := findField(msg.Descriptor(), body)
+				if fd == nil || fd.Kind() != protoreflect.MessageKind || fd.IsList() || fd.IsMap() {
+					return fmt.Errorf("body field %q is not a message", body)
+				}
+				target = msg.Mutable(fd).Message()
+			}
+
+			if err := unmarshaler.Unmarshal(content, target.Interface()); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key, values := range r.URL.Query() {
+		// ignore the unknown query parameters, like the ones for the proxies
+		if err := populateField(msg, key, values); err != nil && !errors.Is(err, errFieldNotFound) {
+			return err
+		}
+	}
+
+	for key, value := range pathvar.Vars(r) {
+		if err := populateField(msg, key, []string{value}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func findField(md protoreflect.MessageDescriptor, name string) protoreflect.FieldDescriptor {
+	fields := md.Fields()
+	if fd := fields.ByName(protoreflect.Name(name)); fd != nil {
+		return fd
+	}
+
+	return fields.ByJSONName(name)
+}
+
+func parseValue(fd protoreflect.FieldDescriptor, val string) (protoreflect.Value, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(val)
+		return protoreflect.ValueOfBool(v), err
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(val, 10, 32)
+		return protoreflect.ValueOfInt32(int32(v)), err
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(val, 10, 64)
+		return protoreflect.ValueOfInt64(v), err
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(val, 10, 32)
+		return protoreflect.ValueOfUint32(uint32(v)), err
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(val, 10, 64)
+		return protoreflect.ValueOfUint64(v), err
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(val, 32)
+		return protoreflect.ValueOfFloat32(float32(v)), err
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(val, 64)
+		return protoreflect.ValueOfFloat64(v), err
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(val), nil
+	case protoreflect.BytesKind:
+		v, err := base64.StdEncoding.DecodeString(val)
+		if err != nil {
+			v, err = base64.URLEncoding.DecodeString(val)
+		}
+		return protoreflect.ValueOfBytes(v), err
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByName(protoreflect.Name(val)); ev != nil {
+			return protoreflect.ValueOfEnum(ev.Number()), nil
+		}
+		v, err := strconv.ParseInt(val, 10, 32)
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), err
+	case protoreflect.MessageKind:
+		// the well-known types, like google.protobuf.Timestamp and the wrappers
+		return parseMessage(fd, val)
+	default:
+		return protoreflect.Value{}, fmt.Errorf("unsupported field kind %s", fd.Kind())
+	}
+}
+
+func parseMessage(fd protoreflect.FieldDescriptor, val string) (protoreflect.Value, error) {
+	msg := dynamicpb.NewMessage(fd.Message())
+	// the values like true and 1 are valid json, the others like timestamps need to be quoted
+	if err := protojson.Unmarshal([]byte(val), msg.Interface()); err != nil {
+		msg = dynamicpb.NewMessage(fd.Message())
+		if err := protojson.Unmarshal([]byte(strconv.Quote(val)), msg.Interface()); err != nil {
+			return protoreflect.Value{}, err
+		}
+	}
+
+	return protoreflect.ValueOfMessage(msg), nil
+}
+
+func setField(msg protoreflect.Message, fd protoreflect.FieldDescriptor, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	if fd.IsMap() {
+		return fmt.Errorf("map field %q is not supported in parameters", fd.Name())
+	}
+
+	if fd.IsList() {
+		list := msg.Mutable(fd).List()
+		for _, val := range values {
+			v, err := parseValue(fd, val)
+			if err != nil {
+				return fmt.Errorf("bad value of field %q: %w", fd.Name(), err)
+			}
+			list.Append(v)
+		}
+		return nil
+	}
+
+	v, err := parseValue(fd, values[len(values)-1])
+	if err != nil {
+		return fmt.Errorf("bad value of field %q: %w", fd.Name(), err)
+	}
+
+	msg.Set(fd, v)
+	return nil
+}
@@ -0,0 +1,250 @@
+package gateway
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/apipb"
+	"google.golang.org/protobuf/types/known/typepb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPopulateField(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   string
+		values []string
+		expect string
+		err    bool
+	}{
+		{
+			name:   "string",
+			path:   "name",
+			values: []string{"foo"},
+			expect: `{"name":"foo"}`,
+		},
+		{
+			name:   "last value",
+			path:   "version",
+			values: []string{"v1", "v2"},
+			expect: `{"version":"v2"}`,
+		},
+		{
+			name:   "enum by name",
+			path:   "syntax",
+			values: []string{"SYNTAX_PROTO3"},
+			expect: `{"syntax":"SYNTAX_PROTO3"}`,
+		},
+		{
+			name:   "enum by number",
+			path:   "syntax",
+			values: []string{"1"},
+			expect: `{"syntax":"SYNTAX_PROTO3"}`,
+		},
+		{
+			name:   "bad enum",
+			path:   "syntax",
+			values: []string{"foo"},
+			err:    true,
+		},
+		{
+			name:   "nested json name",
+			path:   "sourceContext.fileName",
+			values: []string{"foo.proto"},
+			expect: `{"sourceContext":{"fileName":"foo.proto"}}`,
+		},
+		{
+			name:   "nested proto name",
+			path:   "source_context.file_name",
+			values: []string{"foo.proto"},
+			expect: `{"sourceContext":{"fileName":"foo.proto"}}`,
+		},
+		{
+			name:   "not message",
+			path:   "name.foo",
+			values: []string{"foo"},
+			err:    true,
+		},
+		{
+			name:   "not found",
+			path:   "foo",
+			values: []string{"foo"},
+			err:    true,
+		},
+		{
+			name:   "list of messages",
+			path:   "methods",
+			values: []string{"foo"},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msg := new(apipb.Api)
+			err := populateField(msg.ProtoReflect(), test.path, test.values)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expect, formatMessage(msg))
+		})
+	}
+}
+
+func TestParseValue(t *testing.T) {
+	fields := (&typepb.Field{}).ProtoReflect().Descriptor().Fields()
+	v, err := parseValue(fields.ByName("number"), "12")
+	assert.NoError(t, err)
+	assert.Equal(t, int32(12), int32(v.Int()))
+
+	v, err = parseValue(fields.ByName("packed"), "true")
+	assert.NoError(t, err)
+	assert.True(t, v.Bool())
+
+	_, err = parseValue(fields.ByName("packed"), "foo")
+	assert.Error(t, err)
+
+	wrappers := (&wrapperspb.BoolValue{}).ProtoReflect().Descriptor().Fields()
+	v, err = parseValue(wrappers.ByName("value"), "1")
+	assert.NoError(t, err)
+	assert.True(t, v.Bool())
+}
+
+func TestParseValueKinds(t *testing.T) {
+	tests := []struct {
+		msg    proto.Message
+		val    string
+		expect interface{}
+	}{
+		{msg: wrapperspb.Int64(0), val: "-12", expect: int64(-12)},
+		{msg: wrapperspb.UInt32(0), val: "12", expect: uint32(12)},
+		{msg: wrapperspb.UInt64(0), val: "12", expect: uint64(12)},
+		{msg: wrapperspb.Float(0), val: "1.5", expect: float32(1.5)},
+		{msg: wrapperspb.Double(0), val: "1.5", expect: 1.5},
+		{msg: wrapperspb.Bytes(nil), val: "Zm9v", expect: []byte("foo")},
+		{msg: wrapperspb.Bytes(nil), val: "_-8=", expect: []byte{0xff, 0xef}},
+	}
+
+	for _, test := range tests {
+		fd := test.msg.ProtoReflect().Descriptor().Fields().ByName("value")
+		v, err := parseValue(fd, test.val)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expect, v.Interface())
+	}
+}
+
+func TestParseMessage(t *testing.T) {
+	md := (&typepb.Option{}).ProtoReflect().Descriptor()
+	msg := dynamicpb.NewMessage(md)
+	// google.protobuf.Any needs a resolvable type
+	assert.Error(t, populateField(msg, "value", []string{"foo"}))
+
+	fd := (&apipb.Api{}).ProtoReflect().Descriptor().Fields().ByName("source_context")
+	v, err := parseValue(fd, `{"fileName":"foo.proto"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, "foo.proto", v.Message().Get(fd.Message().Fields().ByName("file_name")).String())
+}
+
+func TestPopulateRequest(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		body   string
+		field  string
+		vars   map[string]string
+		expect string
+		err    bool
+	}{
+		{
+			name:   "body",
+			target: "/",
+			body:   `{"name":"foo","version":"v1","unknown":1}`,
+			field:  bodyAll,
+			expect: `{"name":"foo","version":"v1"}`,
+		},
+		{
+			name:   "body ignored",
+			target: "/",
+			body:   `{"name":"foo"}`,
+			expect: `{}`,
+		},
+		{
+			name:   "body field",
+			target: "/",
+			body:   `{"fileName":"foo.proto"}`,
+			field:  "source_context",
+			expect: `{"sourceContext":{"fileName":"foo.proto"}}`,
+		},
+		{
+			name:   "bad body field",
+			target: "/",
+			body:   `{"fileName":"foo.proto"}`,
+			field:  "name",
+			err:    true,
+		},
+		{
+			name:   "bad body",
+			target: "/",
+			body:   `{"name":`,
+			field:  bodyAll,
+			err:    true,
+		},
+		{
+			name:   "query and path",
+			target: "/?version=v1&name=bar&foo=bar",
+			body:   `{"name":"foo"}`,
+			field:  bodyAll,
+			vars:   map[string]string{"name": "baz"},
+			expect: `{"name":"baz","version":"v1"}`,
+		},
+		{
+			name:   "bad query",
+			target: "/?syntax=foo",
+			err:    true,
+		},
+		{
+			name:   "bad path",
+			target: "/",
+			vars:   map[string]string{"foo": "bar"},
+			err:    true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, test.target, strings.NewReader(test.body))
+			if len(test.vars) > 0 {
+				r = pathvar.WithVars(r, test.vars)
+			}
+
+			msg := dynamicpb.NewMessage((&apipb.Api{}).ProtoReflect().Descriptor())
+			err := populateRequest(r, msg, test.field)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expect, formatMessage(msg))
+		})
+	}
+}
+
+func formatMessage(msg proto.Message) string {
+	content, _ := protojson.Marshal(msg)
+	var buf bytes.Buffer
+	_ = json.Compact(&buf, content)
+	return buf.String()
+}
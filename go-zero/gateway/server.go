@@ -0,0 +1,305 @@
+package gateway
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"strings"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/genproto/googleapis/api/annotations"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// metadataHeaderPrefix is the prefix of the headers forwarded to the upstreams as metadata,
+// like Grpc-Metadata-Tenant is forwarded as tenant.
+const metadataHeaderPrefix = "Grpc-Metadata-"
+
+var marshaler = protojson.MarshalOptions{EmitUnpopulated: true}
+
+type (
+	// A Server is a gateway server that transcodes the http/json requests into
+	// the grpc requests of the upstreams, the grpc status errors are written
+	// as problem+json with the mapped http statuses, see httpx.ToProblem.
+	Server struct {
+		*rest.Server
+		clients []zrpc.Client
+	}
+
+	gatewayRoute struct {
+		method string
+		path   string
+		rpc    protoreflect.MethodDescriptor
+		body   string
+	}
+)
+
+// MustNewServer returns a Server, exits on any error.
+func MustNewServer(c GatewayConf, opts ...rest.RunOption) *Server {
+	svr, err := NewServer(c, opts...)
+	logx.Must(err)
+	return svr
+}
+
+// NewServer returns a Server, the descriptors of the upstreams are loaded on creating.
+func NewServer(c GatewayConf, opts ...rest.RunOption) (*Server, error) {
+	restServer, err := rest.NewServer(c.RestConf, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	svr := &Server{
+		Server: restServer,
+	}
+	for _, up := range c.Upstreams {
+		if err := svr.addUpstream(up); err != nil {
+			svr.closeClients()
+			return nil, fmt.Errorf("upstream %q: %w", up.Name, err)
+		}
+	}
+
+	return svr, nil
+}
+
+// Stop stops the Server, and closes the connections to the upstreams.
+func (s *Server) Stop() {
+	s.Server.Stop()
+	s.closeClients()
+}
+
+func (s *Server) addUpstream(up Upstream) error {
+	cli, err := zrpc.NewClient(up.Grpc)
+	if err != nil {
+		return err
+	}
+	s.clients = append(s.clients, cli)
+
+	var files *protoregistry.Files
+	if len(up.ProtoSets) > 0 {
+		files, err = loadProtoSets(up.ProtoSets)
+	} else {
+		files, err = loadReflection(context.Background(), cli.Conn())
+	}
+	if err != nil {
+		return err
+	}
+
+	routes, err := buildRoutes(files, up.Mappings)
+	if err != nil {
+		return err
+	}
+
+	conn := cli.Conn()
+	for _, route := range routes {
+		logx.Infof("gateway route %s %s => %s", route.method, route.path, route.rpc.FullName())
+		s.AddRoute(rest.Route{
+			Method:  route.method,
+			Path:    route.path,
+			Handler: newHandler(conn, route),
+		})
+	}
+
+	return nil
+}
+
+func (s *Server) closeClients() {
+	for _, cli := range s.clients {
+		if err := cli.Conn().Close(); err != nil {
+			logx.Error(err)
+		}
+	}
+	s.clients = nil
+}
+
+// buildRoutes builds the routes of the methods with google.api.http annotations in files,
+// and the routes in mappings.
+func buildRoutes(files *protoregistry.Files, mappings []RouteMapping) ([]gatewayRoute, error) {
+	var routes []gatewayRoute
+	var err error
+	files.RangeFiles(func(fd protoreflect.FileDescriptor) bool {
+		services := fd.Services()
+		for i := 0; i < services.Len(); i++ {
+			methods := services.Get(i).Methods()
+			for j := 0; j < methods.Len(); j++ {
+				var rs []gatewayRoute
+				if rs, err = annotatedRoutes(methods.Get(j)); err != nil {
+					return false
+				}
+				routes = append(routes, rs...)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, mapping := range mappings {
+		name := strings.Replace(strings.TrimPrefix(mapping.RpcPath, "/"), "/", ".", 1)
+		desc, err := files.FindDescriptorByName(protoreflect.FullName(name))
+		if err != nil {
+			return nil, fmt.Errorf("rpc method %q not found", mapping.RpcPath)
+		}
+
+		md, ok := desc.(protoreflect.MethodDescriptor)
+		if !ok {
+			return nil, fmt.Errorf("%q is not a rpc method", mapping.RpcPath)
+		}
+		if md.IsStreamingClient() || md.IsStreamingServer() {
+			return nil, fmt.Errorf("streaming rpc method %q is not supported", mapping.RpcPath)
+		}
+
+		routes = append(routes, gatewayRoute{
+			method: strings.ToUpper(mapping.Method),
+			path:   mapping.Path,
+			rpc:    md,
+			body:   bodyAll,
+		})
+	}
+
+	return routes, nil
+}
+
+// annotatedRoutes returns the routes of the google.api.http annotations on md.
+func annotatedRoutes(md protoreflect.MethodDescriptor) ([]gatewayRoute, error) {
+	opts := md.Options()
+	if opts == nil || !proto.HasExtension(opts, annotations.E_Http) {
+		return nil, nil
+	}
+	if md.IsStreamingClient() || md.IsStreamingServer() {
+		logx.Infof("gateway skipped streaming rpc method %s", md.FullName())
+		return nil, nil
+	}
+
+	rule, ok := proto.GetExtension(opts, annotations.E_Http).(*annotations.HttpRule)
+	if !ok || rule == nil {
+		return nil, nil
+	}
+
+	var routes []gatewayRoute
+	for _, r := range append([]*annotations.HttpRule{rule}, rule.GetAdditionalBindings()...) {
+		method, tpl := httpRulePattern(r)
+		if len(method) == 0 {
+			continue
+		}
+
+		path, err := convertPathTemplate(tpl)
+		if err != nil {
+			return nil, fmt.Errorf("rpc method %s: %w", md.FullName(), err)
+		}
+
+		routes = append(routes, gatewayRoute{
+			method: method,
+			path:   path,
+			rpc:    md,
+			body:   r.GetBody(),
+		})
+	}
+
+	return routes, nil
+}
+
+// convertPathTemplate converts the path template like /v1/users/{id} into /v1/users/:id,
+// the templates with patterns like {name=shelves/*} are not supported.
+func convertPathTemplate(tpl string) (string, error) {
+	segments := strings.Split(tpl, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, "{") {
+			continue
+		}
+
+		if !strings.HasSuffix(segment, "}") || strings.ContainsAny(segment, "=*") {
+			return "", fmt.Errorf("unsupported path template %q", tpl)
+		}
+
+		segments[i] = ":" + segment[1:len(segment)-1]
+	}
+
+	return strings.Join(segments, "/"), nil
+}
+
+func forwardedMetadata(header http.Header) metadata.MD {
+	md := metadata.MD{}
+	for key, values := range header {
+		key = textproto.CanonicalMIMEHeaderKey(key)
+		if strings.HasPrefix(key, metadataHeaderPrefix) {
+			md.Append(strings.TrimPrefix(key, metadataHeaderPrefix), values...)
+		}
+	}
+
+	return md
+}
+
+// withForwardedMetadata returns a context with the forwarded metadata of header
+// merged into the outgoing metadata that ctx already carries.
+func withForwardedMetadata(ctx context.Context, header http.Header) context.Context {
+	md := forwardedMetadata(header)
+	if outgoing, ok := metadata.FromOutgoingContext(ctx); ok {
+		md = metadata.Join(outgoing, md)
+	}
+
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func httpRulePattern(rule *annotations.HttpRule) (string, string) {
+	switch pattern := rule.GetPattern().(type) {
+	case *annotations.HttpRule_Get:
+		return http.MethodGet, pattern.Get
+	case *annotations.HttpRule_Put:
+		return http.MethodPut, pattern.Put
+	case *annotations.HttpRule_Post:
+		return http.MethodPost, pattern.Post
+	case *annotations.HttpRule_Delete:
+		return http.MethodDelete, pattern.Delete
+	case *annotations.HttpRule_Patch:
+		return http.MethodPatch, pattern.Patch
+	case *annotations.HttpRule_Custom:
+		return strings.ToUpper(pattern.Custom.GetKind()), pattern.Custom.GetPath()
+	default:
+		return "", ""
+	}
+}
+
+func newHandler(conn grpc.ClientConnInterface, route gatewayRoute) http.HandlerFunc {
+	method := fmt.Sprintf("/%s/%s", route.rpc.Parent().FullName(), route.rpc.Name())
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		req := dynamicpb.NewMessage(route.rpc.Input())
+		if err := populateRequest(r, req, route.body); err != nil {
+			httpx.ErrorCtx(ctx, w, status.Error(codes.InvalidArgument, err.Error()))
+			return
+		}
+
+		resp := dynamicpb.NewMessage(route.rpc.Output())
+		outgoing := withForwardedMetadata(ctx, r.Header)
+		if err := conn.Invoke(outgoing, method, req, resp); err != nil {
+			httpx.ErrorCtx(ctx, w, err)
+			return
+		}
+
+		content, err := marshaler.Marshal(resp)
+		if err != nil {
+			httpx.ErrorCtx(ctx, w, status.Error(codes.Internal, err.Error()))
+			return
+		}
+
+		w.Header().Set(httpx.ContentType, httpx.ApplicationJson)
+		w.WriteHeader(http.StatusOK)
+		if _, err := w.Write(content); err != nil {
+			logx.WithContext(ctx).Errorf("write response failed, error: %s", err)
+		}
+	}
+}
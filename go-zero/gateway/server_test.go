@@ -0,0 +1,308 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/pathvar"
+	"github.com/zeromicro/go-zero/zrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/descriptorpb"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestNewServer(t *testing.T) {
+	reflectAddr := startTestServer(t, true)
+	protoSetAddr := startTestServer(t, false)
+
+	tests := []struct {
+		name     string
+		upstream Upstream
+		err      bool
+	}{
+		{
+			name: "reflection",
+			upstream: Upstream{
+				Grpc: zrpc.RpcClientConf{Endpoints: []string{reflectAddr}},
+				Mappings: []RouteMapping{
+					{
+						Method:  http.MethodGet,
+						Path:    "/health/:service",
+						RpcPath: "grpc.health.v1.Health/Check",
+					},
+				},
+			},
+		},
+		{
+			name: "proto sets",
+			upstream: Upstream{
+				Grpc:      zrpc.RpcClientConf{Endpoints: []string{protoSetAddr}},
+				ProtoSets: []string{writeProtoSet(t)},
+			},
+		},
+		{
+			name: "bad proto sets",
+			upstream: Upstream{
+				Grpc:      zrpc.RpcClientConf{Endpoints: []string{protoSetAddr}},
+				ProtoSets: []string{"not/exist.pb"},
+			},
+			err: true,
+		},
+		{
+			name: "bad mapping",
+			upstream: Upstream{
+				Grpc: zrpc.RpcClientConf{Endpoints: []string{reflectAddr}},
+				Mappings: []RouteMapping{
+					{
+						Method:  http.MethodGet,
+						Path:    "/health",
+						RpcPath: "grpc.health.v1.Health/NotExist",
+					},
+				},
+			},
+			err: true,
+		},
+		{
+			name: "bad upstream",
+			upstream: Upstream{
+				Grpc: zrpc.RpcClientConf{},
+			},
+			err: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := GatewayConf{
+				RestConf:  newRestConf(t),
+				Upstreams: []Upstream{test.upstream},
+			}
+			svr, err := NewServer(c)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, svr.clients, 1)
+			svr.Stop()
+			assert.Empty(t, svr.clients)
+		})
+	}
+}
+
+func TestMustNewServer(t *testing.T) {
+	svr := MustNewServer(GatewayConf{RestConf: newRestConf(t)}, rest.WithNotFoundHandler(nil))
+	assert.NotNil(t, svr)
+	svr.Stop()
+}
+
+func TestBuildRoutes(t *testing.T) {
+	files, err := buildFiles(map[string]*descriptorpb.FileDescriptorProto{
+		"gateway_test.proto": testFileDescriptor(),
+	})
+	assert.NoError(t, err)
+
+	routes, err := buildRoutes(files, []RouteMapping{
+		{
+			Method:  "post",
+			Path:    "/check",
+			RpcPath: "/gateway.test.Health/Check",
+		},
+	})
+	assert.NoError(t, err)
+	var actual []string
+	for _, route := range routes {
+		actual = append(actual, route.method+" "+route.path+" "+route.body+" "+string(route.rpc.FullName()))
+	}
+	// the streaming Watch is skipped
+	assert.ElementsMatch(t, []string{
+		"GET /v1/health/:service  gateway.test.Health.Check",
+		"POST /v1/health * gateway.test.Health.Check",
+		"POST /check * gateway.test.Health.Check",
+	}, actual)
+
+	for _, rpcPath := range []string{"gateway.test.Health/NotExist", "gateway.test.Health", "gateway.test.Health/Watch"} {
+		_, err = buildRoutes(files, []RouteMapping{
+			{
+				Method:  http.MethodGet,
+				Path:    "/foo",
+				RpcPath: rpcPath,
+			},
+		})
+		assert.Error(t, err, rpcPath)
+	}
+}
+
+func TestConvertPathTemplate(t *testing.T) {
+	tests := []struct {
+		tpl    string
+		expect string
+		err    bool
+	}{
+		{tpl: "/v1/users", expect: "/v1/users"},
+		{tpl: "/v1/users/{id}", expect: "/v1/users/:id"},
+		{tpl: "/v1/users/{user.id}/orders/{order_id}", expect: "/v1/users/:user.id/orders/:order_id"},
+		{tpl: "/v1/{name=shelves/*}", err: true},
+		{tpl: "/v1/{name", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tpl, func(t *testing.T) {
+			path, err := convertPathTemplate(test.tpl)
+			if test.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, test.expect, path)
+		})
+	}
+}
+
+func TestForwardedMetadata(t *testing.T) {
+	header := http.Header{}
+	header.Set("Grpc-Metadata-Tenant", "foo")
+	header.Add("grpc-metadata-trace", "bar")
+	header.Set("Authorization", "baz")
+	md := forwardedMetadata(header)
+	assert.Equal(t, metadata.Pairs("tenant", "foo", "trace", "bar"), md)
+}
+
+func TestWithForwardedMetadata(t *testing.T) {
+	header := http.Header{}
+	header.Set("Grpc-Metadata-Tenant", "foo")
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "tenant", "bar", "user", "kevin")
+	md, ok := metadata.FromOutgoingContext(withForwardedMetadata(ctx, header))
+	assert.True(t, ok)
+	assert.Equal(t, metadata.Pairs("tenant", "bar", "tenant", "foo", "user", "kevin"), md)
+
+	md, ok = metadata.FromOutgoingContext(withForwardedMetadata(context.Background(), header))
+	assert.True(t, ok)
+	assert.Equal(t, metadata.Pairs("tenant", "foo"), md)
+}
+
+func TestHandler(t *testing.T) {
+	addr := startTestServer(t, false)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	files, err := buildFiles(map[string]*descriptorpb.FileDescriptorProto{
+		"gateway_test.proto": testFileDescriptor(),
+	})
+	assert.NoError(t, err)
+	routes, err := buildRoutes(files, nil)
+	assert.NoError(t, err)
+	var get, post gatewayRoute
+	for _, route := range routes {
+		if route.method == http.MethodGet {
+			get = route
+		} else {
+			post = route
+		}
+	}
+
+	tests := []struct {
+		name   string
+		route  gatewayRoute
+		body   string
+		vars   map[string]string
+		code   int
+		expect string
+	}{
+		{
+			name:   "path",
+			route:  get,
+			vars:   map[string]string{"service": "foo"},
+			code:   http.StatusOK,
+			expect: `{"status":"SERVING"}`,
+		},
+		{
+			name:   "body",
+			route:  post,
+			body:   `{"service":"foo"}`,
+			code:   http.StatusOK,
+			expect: `{"status":"SERVING"}`,
+		},
+		{
+			name:   "not found",
+			route:  get,
+			vars:   map[string]string{"service": "bar"},
+			code:   http.StatusNotFound,
+			expect: `{"title":"Not Found","status":404,"detail":"unknown service","code":5}`,
+		},
+		{
+			name:   "bad request",
+			route:  post,
+			body:   `{"service":`,
+			code:   http.StatusBadRequest,
+			expect: `"status":400`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.route.method, "/", strings.NewReader(test.body))
+			r = pathvar.WithVars(r, test.vars)
+			w := httptest.NewRecorder()
+			newHandler(conn, test.route).ServeHTTP(w, r)
+			assert.Equal(t, test.code, w.Code)
+			if test.code == http.StatusOK {
+				assert.Equal(t, httpx.ApplicationJson, w.Header().Get(httpx.ContentType))
+				assert.JSONEq(t, test.expect, w.Body.String())
+			} else {
+				assert.Equal(t, httpx.ApplicationProblemJson, w.Header().Get(httpx.ContentType))
+				assert.Contains(t, w.Body.String(), test.expect)
+			}
+		})
+	}
+}
+
+func TestHandlerWithErrorHandler(t *testing.T) {
+	addr := startTestServer(t, false)
+	conn, err := grpc.Dial(addr, grpc.WithInsecure())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	files, err := buildFiles(map[string]*descriptorpb.FileDescriptorProto{
+		"gateway_test.proto": testFileDescriptor(),
+	})
+	assert.NoError(t, err)
+	routes, err := buildRoutes(files, []RouteMapping{
+		{
+			Method:  http.MethodGet,
+			Path:    "/check/:service",
+			RpcPath: "gateway.test.Health/Check",
+		},
+	})
+	assert.NoError(t, err)
+
+	r := httptest.NewRequest(http.MethodGet, "/check/bar", nil)
+	r = pathvar.WithVars(r, map[string]string{"service": "bar"})
+	r = r.WithContext(httpx.ContextWithErrorHandler(r.Context(), func(ctx context.Context, err error) (int, interface{}) {
+		return http.StatusTeapot, nil
+	}))
+	w := httptest.NewRecorder()
+	newHandler(conn, routes[len(routes)-1]).ServeHTTP(w, r)
+	assert.Equal(t, http.StatusTeapot, w.Code)
+}
+
+func newRestConf(t *testing.T) rest.RestConf {
+	var c rest.RestConf
+	assert.NoError(t, conf.LoadConfigFromYamlBytes([]byte("Name: gateway\nPort: 54321"), &c))
+	return c
+}
@@ -1,6 +1,6 @@
 module github.com/zeromicro/go-zero
 
-go 1.15
+go 1.16
 
 require (
 	github.com/ClickHouse/clickhouse-go v1.5.1
@@ -39,13 +39,15 @@ require (
 )
 
 require (
+	github.com/andybalholm/brotli v1.0.4
 	github.com/fatih/color v1.10.0 // indirect
 	github.com/go-logr/stdr v1.2.2 // indirect
 	github.com/go-redis/redis/v8 v8.11.4
 	github.com/mattn/go-runewidth v0.0.13 // indirect
 	github.com/openzipkin/zipkin-go v0.4.0 // indirect
-	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd // indirect
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
 	golang.org/x/sys v0.0.0-20220209214540-3681064d5158 // indirect
-	google.golang.org/genproto v0.0.0-20220211171837-173942840c17 // indirect
+	google.golang.org/genproto v0.0.0-20220211171837-173942840c17
 	k8s.io/klog/v2 v2.40.1 // indirect
 )
@@ -4,6 +4,8 @@ import (
 	"time"
 
 	"github.com/zeromicro/go-zero/core/service"
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/redis"
 )
 
 type (
@@ -20,6 +22,112 @@ type (
 		PrivateKeys []PrivateKeyConf
 	}
 
+	// A PublicKeyConf is a public key config to verify the jwt tokens, the key file is in PEM format.
+	// The key is selected by the kid header of the token, the only key is used if the token has no kid.
+	PublicKeyConf struct {
+		Kid     string `json:",optional"`
+		KeyFile string
+	}
+
+	// A JwtConf is a jwt config, which verifies the hmac signed tokens with Secret and PrevSecret,
+	// and the tokens signed with RSA, ECDSA or EdDSA with PublicKeys and the JWKS from JwksFile
+	// or JwksUrl, which is refreshed every JwksRefresh. Algorithms restrict the accepted algorithms,
+	// like RS256 and ES256, the tokens are accepted only if their aud claims contain any of Audiences
+	// and their iss claims equal to Issuer if set. Leeway allows the clock skew on exp, iat and nbf.
+	JwtConf struct {
+		Secret      string          `json:",optional"`
+		PrevSecret  string          `json:",optional"`
+		PublicKeys  []PublicKeyConf `json:",optional"`
+		JwksFile    string          `json:",optional"`
+		JwksUrl     string          `json:",optional"`
+		JwksRefresh time.Duration   `json:",default=10m"`
+		Algorithms  []string        `json:",optional"`
+		Audiences   []string        `json:",optional"`
+		Issuer      string          `json:",optional"`
+		Leeway      time.Duration   `json:",optional"`
+	}
+
+	// A RateLimitConf is a rate limit config, which allows Quota requests in every Period seconds per key.
+	RateLimitConf struct {
+		Redis          redis.RedisConf `json:",optional"`
+		Period         int             `json:",default=1"`
+		Quota          int             `json:",optional"`
+		KeyBy          string          `json:",default=ip,options=ip|jwt|header|path"`
+		Key            string          `json:",optional"`
+		TrustedProxies []string        `json:",optional"`
+	}
+
+	// An IdempotencyConf is an idempotency config to process the requests with the same Idempotency-Key once.
+	IdempotencyConf struct {
+		Redis      redis.RedisConf
+		Expire     time.Duration `json:",default=24h"`
+		LockExpire time.Duration `json:",default=1m"`
+		UserBy     string        `json:",default=jwt,options=jwt|header"`
+		UserKey    string
+	}
+
+	// A CorsConf is a CORS config.
+	CorsConf struct {
+		Enabled       bool          `json:",optional"`
+		Origins       []string      `json:",optional"`
+		Methods       []string      `json:",optional"`
+		Headers       []string      `json:",optional"`
+		ExposeHeaders []string      `json:",optional"`
+		Credentials   bool          `json:",optional"`
+		MaxAge        time.Duration `json:",default=24h"`
+	}
+
+	// A ResponseCacheConf is a response cache config.
+	ResponseCacheConf struct {
+		Cache       cache.CacheConf `json:",optional"`
+		Expire      time.Duration   `json:",default=1m"`
+		Limit       int             `json:",default=10000"`
+		VaryHeaders []string        `json:",optional"`
+		VaryClaims  []string        `json:",optional"`
+	}
+
+	// A RouteConf overrides the settings of the routes matched by Method and Path.
+	RouteConf struct {
+		Method     string `json:",optional"`
+		Path       string
+		Timeout    time.Duration `json:",optional"`
+		MaxBytes   int64         `json:",optional"`
+		Priority   bool          `json:",optional"`
+		DisableLog bool          `json:",optional"`
+	}
+
+	// A CompressConf is a response compression config.
+	// Types are the content types to compress, like application/json or text/*,
+	// Encodings are the encodings in preference order, from br, gzip and deflate.
+	// The defaults are used if not set.
+	CompressConf struct {
+		Enabled   bool     `json:",optional"`
+		MinSize   int      `json:",default=1024"`
+		Types     []string `json:",optional"`
+		Encodings []string `json:",optional"`
+	}
+
+	// A HealthConf is a config to expose the health, liveness and readiness endpoints,
+	// which report the probes added by health.AddLivenessProbe and health.AddReadinessProbe.
+	// DrainDelay is the time to report not ready before the server stops accepting requests
+	// on shutting down, it should be less than the time to force quit, which is 5.5s.
+	HealthConf struct {
+		Enabled    bool          `json:",optional"`
+		HealthPath string        `json:",default=/healthz"`
+		LivePath   string        `json:",default=/livez"`
+		ReadyPath  string        `json:",default=/readyz"`
+		Timeout    time.Duration `json:",default=1s"`
+		DrainDelay time.Duration `json:",optional"`
+	}
+
+	// An IntrospectionConf is a config to expose the registered routes,
+	// both as a plain list and as an OpenAPI document.
+	IntrospectionConf struct {
+		Enabled     bool   `json:",optional"`
+		RoutesPath  string `json:",default=/debug/routes"`
+		OpenApiPath string `json:",default=/openapi.json"`
+	}
+
 	// A RestConf is a http service config.
 	// Why not name it as Conf, because we need to consider usage like:
 	//  type Config struct {
@@ -36,9 +144,22 @@ type (
 		Verbose  bool   `json:",optional"`
 		MaxConns int    `json:",default=10000"` // 最大连接数
 		MaxBytes int64  `json:",default=1048576"`
+		// the max concurrent websocket connections
+		MaxWebSockets int `json:",default=10000"`
+		// the allowed origins of websocket connections besides the same origin, like https://foo.com,
+		// all origins are allowed with *, be careful because the cookies are sent cross-site
+		WebSocketOrigins []string `json:",optional"`
 		// milliseconds
 		Timeout      int64         `json:",default=3000"`
 		CpuThreshold int64         `json:",default=900,range=[0:1000]"` // cpu 线程数 用于 自适应降载保护
 		Signature    SignatureConf `json:",optional"`
+		RateLimit    RateLimitConf `json:",optional"`
+		Compress     CompressConf  `json:",optional"`
+		Cors         CorsConf      `json:",optional"`
+		Health       HealthConf    `json:",optional"`
+		// expose the registered routes, be careful to enable it on public services
+		Introspection IntrospectionConf `json:",optional"`
+		// the route overrides, the first matched one is used for each route
+		Routes []RouteConf `json:",optional"`
 	}
 )
@@ -4,21 +4,38 @@ import (
 	"crypto/tls"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
+	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/justinas/alice"
 	"github.com/zeromicro/go-zero/core/codec"
+	"github.com/zeromicro/go-zero/core/errorx"
 	"github.com/zeromicro/go-zero/core/load"
+	"github.com/zeromicro/go-zero/core/proc"
+	"github.com/zeromicro/go-zero/core/search"
 	"github.com/zeromicro/go-zero/core/stat"
+	"github.com/zeromicro/go-zero/core/stores/redis"
 	"github.com/zeromicro/go-zero/rest/handler"
 	"github.com/zeromicro/go-zero/rest/httpx"
 	"github.com/zeromicro/go-zero/rest/internal"
+	"github.com/zeromicro/go-zero/rest/internal/cors"
 	"github.com/zeromicro/go-zero/rest/internal/response"
+	"github.com/zeromicro/go-zero/rest/token"
 )
 
-// use 1000m to represent 100%
-const topCpuUsage = 1000
+const (
+	// use 1000m to represent 100%
+	topCpuUsage = 1000
+	// the interval to send heartbeats on SSE streams
+	sseHeartbeatInterval = 15 * time.Second
+	// the defaults for the idempotency configs not loaded from files
+	defaultIdempotencyExpire     = 24 * time.Hour
+	defaultIdempotencyLockExpire = time.Minute
+)
 
 // ErrSignatureConfig is an error that indicates bad config for signature.
 var ErrSignatureConfig = errors.New("bad config for Signature")
@@ -32,11 +49,20 @@ type engine struct {
 	shedder              load.Shedder // 降载
 	priorityShedder      load.Shedder // 优先降载
 	tlsConfig            *tls.Config
+	errorHandler         httpx.ErrorHandler
+	webSockets           *webSocketManager
+	webSocketsOnce       sync.Once
+	// the added paths by methods, to detect the conflicted routes on adding
+	paths map[string]*search.Tree
+	// the key sets by the key sources of JwtConf, shared by the featured routes
+	keySets map[string]*token.KeySet
 }
 
 func newEngine(c RestConf) *engine {
 	srv := &engine{
-		conf: c,
+		conf:    c,
+		paths:   make(map[string]*search.Tree),
+		keySets: make(map[string]*token.KeySet),
 	}
 	if c.CpuThreshold > 0 { // 自适应降载配置
 		srv.shedder = load.NewAdaptiveShedder(load.WithCpuThreshold(c.CpuThreshold))
@@ -47,79 +73,282 @@ func newEngine(c RestConf) *engine {
 	return srv
 }
 
-func (ng *engine) addRoutes(r featuredRoutes) {
+// addRoutes adds the featured routes, returns the error that reports all the routes
+// conflicted with the added ones, like /users/:name conflicts with /users/:id.
+func (ng *engine) addRoutes(r featuredRoutes) error {
+	var be errorx.BatchError
+	for _, route := range r.routes {
+		tree, ok := ng.paths[route.Method]
+		if !ok {
+			tree = search.NewTree()
+			ng.paths[route.Method] = tree
+		}
+
+		if err := tree.Add(path.Clean(route.Path), route.Path); err != nil {
+			be.Add(fmt.Errorf("%s: %w", route.Method, err))
+		}
+	}
+	if be.NotNil() {
+		return be.Err()
+	}
+
 	ng.routes = append(ng.routes, r)
+	return nil
 }
 
 func (ng *engine) appendAuthHandler(fr featuredRoutes, chain alice.Chain,
-	verifier func(alice.Chain) alice.Chain) alice.Chain {
+	verifier func(alice.Chain) alice.Chain, parseOpts []token.ParseOption) alice.Chain {
 	if fr.jwt.enabled {
-		if len(fr.jwt.prevSecret) == 0 {
-			chain = chain.Append(handler.Authorize(fr.jwt.secret,
-				handler.WithUnauthorizedCallback(ng.unauthorizedCallback)))
-		} else {
-			chain = chain.Append(handler.Authorize(fr.jwt.secret,
-				handler.WithPrevSecret(fr.jwt.prevSecret),
-				handler.WithUnauthorizedCallback(ng.unauthorizedCallback)))
+		opts := []handler.AuthorizeOption{handler.WithUnauthorizedCallback(ng.unauthorizedCallback)}
+		if len(fr.jwt.prevSecret) > 0 {
+			opts = append(opts, handler.WithPrevSecret(fr.jwt.prevSecret))
 		}
+		if len(parseOpts) > 0 {
+			opts = append(opts, handler.WithParseOptions(parseOpts...))
+		}
+		chain = chain.Append(handler.Authorize(fr.jwt.secret, opts...))
 	}
 
 	return verifier(chain)
 }
 
-func (ng *engine) bindFeaturedRoutes(router httpx.Router, fr featuredRoutes, metrics *stat.Metrics) error {
+// appendRateLimitHandler appends the rate limit handler after the auth handlers,
+// to be able to limit the requests by jwt claims.
+func (ng *engine) appendRateLimitHandler(fr featuredRoutes, route Route, chain alice.Chain) (alice.Chain, error) {
+	c := ng.conf.RateLimit
+	if fr.rateLimit.enabled {
+		c = fr.rateLimit.RateLimitConf
+	}
+	if c.Quota <= 0 {
+		return chain, nil
+	}
+
+	keyFunc, err := rateLimitKeyFunc(c)
+	if err != nil {
+		return chain, err
+	}
+
+	var store *redis.Redis
+	if len(c.Redis.Host) > 0 {
+		store = c.Redis.NewRedis()
+	}
+
+	name := route.Method + route.Path
+	return chain.Append(handler.RateLimitHandler(name, c.Period, c.Quota, store, keyFunc)), nil
+}
+
+// appendIdempotencyHandler appends the idempotency handler after the auth and rate limit handlers,
+// to be able to identify the users by jwt claims, and not to store the rejected requests.
+func (ng *engine) appendIdempotencyHandler(fr featuredRoutes, route Route, chain alice.Chain) (alice.Chain, error) {
+	if !fr.idempotency.enabled {
+		return chain, nil
+	}
+
+	c := fr.idempotency.IdempotencyConf
+	if err := c.Redis.Validate(); err != nil {
+		return chain, err
+	}
+
+	expire := c.Expire
+	if expire <= 0 {
+		expire = defaultIdempotencyExpire
+	}
+	lockExpire := c.LockExpire
+	if lockExpire <= 0 {
+		lockExpire = defaultIdempotencyLockExpire
+	}
+	userFunc, err := idempotencyUserFunc(c)
+	if err != nil {
+		return chain, err
+	}
+
+	name := route.Method + route.Path
+	return chain.Append(handler.IdempotencyHandler(name, c.Redis.NewRedis(), expire, lockExpire, userFunc)), nil
+}
+
+// wrapCompressHandler wraps the chain with the compress handler as the outermost one,
+// to let the log, timeout and user handlers work on the uncompressed responses.
+func (ng *engine) wrapCompressHandler(chain alice.Chain) alice.Chain {
+	c := ng.conf.Compress
+	if !c.Enabled {
+		return chain
+	}
+
+	return alice.New(handler.CompressHandler(c.MinSize, c.Types, c.Encodings)).Extend(chain)
+}
+
+func (ng *engine) bindFeaturedRoutes(router httpx.Router, fr featuredRoutes, metrics *stat.Metrics,
+	preflights map[string]bool) error {
 	verifier, err := ng.signatureVerifier(fr.signature) // 签名校验
 	if err != nil {
 		return err
 	}
 
+	// the keys are shared by the routes, to avoid loading the JWKS for each route.
+	parseOpts, err := ng.jwtParseOptions(fr.jwt)
+	if err != nil {
+		return err
+	}
+
+	policy, err := ng.corsPolicy(fr)
+	if err != nil {
+		return err
+	}
+
 	for _, route := range fr.routes {
-		if err := ng.bindRoute(fr, router, metrics, route, verifier); err != nil {
+		if err := ng.bindRoute(ng.overrideRoute(fr, route), router, metrics, route, verifier,
+			parseOpts, policy); err != nil {
 			return err
 		}
 	}
 
+	if policy == nil {
+		return nil
+	}
+
+	return ng.bindPreflightRoutes(router, fr, policy, preflights)
+}
+
+// bindPreflightRoutes binds the OPTIONS routes to respond the preflight requests with the CORS policy
+// of the routes, the paths in preflights are skipped, which are bound by the users or other routes.
+func (ng *engine) bindPreflightRoutes(router httpx.Router, fr featuredRoutes, policy *cors.Policy,
+	preflights map[string]bool) error {
+	for _, route := range fr.routes {
+		if fr.websocket || preflights[path.Clean(route.Path)] {
+			continue
+		}
+
+		chain := alice.New(
+			handler.RequestIdHandler,
+			handler.TracingHandler(ng.conf.Name, route.Path),
+			ng.getLogHandler(),
+			handler.RecoverHandler,
+		)
+		if err := router.Handle(http.MethodOptions, route.Path, chain.Then(policy)); err != nil {
+			return err
+		}
+
+		preflights[path.Clean(route.Path)] = true
+	}
+
 	return nil
 }
 
 // bindRoute 绑定路由
 // 增加 go-zero 预配置中间件
 func (ng *engine) bindRoute(fr featuredRoutes, router httpx.Router, metrics *stat.Metrics,
-	route Route, verifier func(chain alice.Chain) alice.Chain) error {
-	chain := alice.New( // 为路由增加 中间件
-		handler.TracingHandler(ng.conf.Name, route.Path), // 链路跟踪
-		ng.getLogHandler(), // 日志处理
-		handler.PrometheusHandler(route.Path), // Prometheus
-		handler.MaxConns(ng.conf.MaxConns), // 最大连接数限制
-		handler.BreakerHandler(route.Method, route.Path, metrics), // 中断连接
-		handler.SheddingHandler(ng.getShedder(fr.priority), metrics), // 降载
-		handler.TimeoutHandler(ng.checkedTimeout(fr.timeout)), // 超时
-		handler.RecoverHandler, // 恢复
-		handler.MetricHandler(metrics), // 统计指标
-		handler.MaxBytesHandler(ng.conf.MaxBytes), // 最大数据包
-		handler.GunzipHandler, // 解压
-	)
-	chain = ng.appendAuthHandler(fr, chain, verifier) // 权限校验中间件
+	route Route, verifier func(chain alice.Chain) alice.Chain, parseOpts []token.ParseOption,
+	policy *cors.Policy) error {
+	var chain alice.Chain
+	if fr.websocket {
+		chain = ng.buildWebSocketChain(fr, route, metrics)
+	} else {
+		chain = alice.New( // 为路由增加 中间件
+			handler.RequestIdHandler, // 请求 ID
+			handler.CanaryHandler, // 灰度标签
+			handler.TracingHandler(ng.conf.Name, route.Path), // 链路跟踪
+			ng.getRouteLogHandler(fr), // 日志处理
+			handler.PrometheusHandler(route.Path), // Prometheus
+			handler.MaxConns(ng.conf.MaxConns), // 最大连接数限制
+			handler.BreakerHandler(route.Method, route.Path, metrics), // 中断连接
+			handler.SheddingHandler(ng.getShedder(fr.priority), metrics), // 降载
+			ng.getStreamHandler(fr), // 超时 or SSE 流
+			handler.RecoverHandler, // 恢复
+			handler.MetricHandler(metrics), // 统计指标
+			handler.MaxBytesHandler(ng.checkedMaxBytes(fr.maxBytes)), // 最大数据包
+			handler.GunzipHandler, // 解压
+			handler.UploadsHandler, // 清理上传的临时文件
+		)
+		if policy != nil {
+			// before the auth handlers, to let the browsers read the rejected responses
+			chain = chain.Append(policy.Handler)
+		}
+		chain = ng.wrapCompressHandler(chain)
+	}
+	chain = ng.appendAuthHandler(fr, chain, verifier, parseOpts) // 权限校验中间件
+	chain, err := ng.appendRateLimitHandler(fr, route, chain)
+	if err != nil {
+		return err
+	}
+	chain, err = ng.appendIdempotencyHandler(fr, route, chain)
+	if err != nil {
+		return err
+	}
+	// the responses of the jwt routes are per user, not cached unless varied by claims
+	if fr.cache != nil && !fr.sse && !fr.websocket && (!fr.jwt.enabled || fr.cache.VariesByClaims()) {
+		chain = chain.Append(fr.cache.Handler)
+	}
+
+	if ng.errorHandler != nil {
+		chain = chain.Append(ng.errorHandlerMiddleware)
+	}
 
 	for _, middleware := range ng.middlewares {
 		chain = chain.Append(convertMiddleware(middleware)) // 用户中间件插入
 	}
+	// innermost, to not be hidden by the writers wrapped in the middlewares
+	if ng.errorHandler != nil {
+		chain = chain.Append(errorWriterMiddleware)
+	}
 	handle := chain.ThenFunc(route.Handler)
 
 	// router.Handle 在 go-zero/rest/router/patrouter.go 中定义
 	return router.Handle(route.Method, route.Path, handle)
 }
 
+// buildWebSocketChain builds the chain for websocket routes.
+// The connections are hijacked and long-lived, so the timeout, body limit
+// and duration metrics don't apply, and the concurrent sockets are limited on upgrading.
+func (ng *engine) buildWebSocketChain(fr featuredRoutes, route Route, metrics *stat.Metrics) alice.Chain {
+	return alice.New(
+		handler.RequestIdHandler,
+		handler.CanaryHandler,
+		handler.TracingHandler(ng.conf.Name, route.Path),
+		ng.getRouteLogHandler(fr),
+		handler.BreakerHandler(route.Method, route.Path, metrics),
+		handler.SheddingHandler(ng.getShedder(fr.priority), metrics),
+		handler.RecoverHandler,
+	)
+}
+
 func (ng *engine) bindRoutes(router httpx.Router) error {
+	for _, c := range ng.conf.Routes {
+		if _, err := path.Match(c.Path, ""); err != nil {
+			return fmt.Errorf("bad path pattern %q in Routes: %w", c.Path, err)
+		}
+	}
+
 	metrics := ng.createMetrics()
 
+	// the paths with OPTIONS routes, which are not bound for preflight requests
+	preflights := make(map[string]bool)
+	for _, fr := range ng.routes {
+		for _, route := range fr.routes {
+			if route.Method == http.MethodOptions {
+				preflights[path.Clean(route.Path)] = true
+			}
+		}
+	}
+
 	for _, fr := range ng.routes {
-		if err := ng.bindFeaturedRoutes(router, fr, metrics); err != nil {
+		if err := ng.bindFeaturedRoutes(router, fr, metrics, preflights); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	if err := ng.bindHealth(router); err != nil {
+		return err
+	}
+
+	return ng.bindIntrospection(router)
+}
+
+func (ng *engine) checkedMaxBytes(maxBytes int64) int64 {
+	if maxBytes > 0 {
+		return maxBytes
+	}
+
+	return ng.conf.MaxBytes
 }
 
 func (ng *engine) checkedTimeout(timeout time.Duration) time.Duration {
@@ -130,6 +359,26 @@ func (ng *engine) checkedTimeout(timeout time.Duration) time.Duration {
 	return time.Duration(ng.conf.Timeout) * time.Millisecond
 }
 
+// corsPolicy returns the CORS policy of fr, which overrides the Cors in RestConf, nil if disabled.
+func (ng *engine) corsPolicy(fr featuredRoutes) (*cors.Policy, error) {
+	c := ng.conf.Cors
+	if fr.cors.enabled {
+		c = fr.cors.CorsConf
+	}
+	if !c.Enabled {
+		return nil, nil
+	}
+
+	return cors.NewPolicy(cors.Options{
+		Origins:       c.Origins,
+		Methods:       c.Methods,
+		Headers:       c.Headers,
+		ExposeHeaders: c.ExposeHeaders,
+		Credentials:   c.Credentials,
+		MaxAge:        c.MaxAge,
+	})
+}
+
 func (ng *engine) createMetrics() *stat.Metrics {
 	var metrics *stat.Metrics
 
@@ -150,6 +399,44 @@ func (ng *engine) getLogHandler() func(http.Handler) http.Handler {
 	return handler.LogHandler
 }
 
+// errorHandlerMiddleware puts the error handler of the server into the request context,
+// to be used by httpx.ErrorCtx.
+func (ng *engine) errorHandlerMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := httpx.ContextWithErrorHandler(r.Context(), ng.errorHandler)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// errorWriterMiddleware wraps the response writer with the request context,
+// to let httpx.Error find the error handler of the server without the context.
+func errorWriterMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(httpx.ResponseWriterWithContext(w, r.Context()), r)
+	})
+}
+
+// getRouteLogHandler returns the log handler of fr, which passes through if the logs are disabled.
+func (ng *engine) getRouteLogHandler(fr featuredRoutes) func(http.Handler) http.Handler {
+	if fr.disableLog {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	return ng.getLogHandler()
+}
+
+// getStreamHandler returns the timeout handler for regular routes,
+// and the SSE handler for streaming routes, which must not be timed out.
+func (ng *engine) getStreamHandler(fr featuredRoutes) func(http.Handler) http.Handler {
+	if fr.sse {
+		return handler.SSEHandler(sseHeartbeatInterval)
+	}
+
+	return handler.TimeoutHandler(ng.checkedTimeout(fr.timeout))
+}
+
 func (ng *engine) getShedder(priority bool) load.Shedder {
 	if priority && ng.priorityShedder != nil {
 		return ng.priorityShedder
@@ -162,6 +449,7 @@ func (ng *engine) getShedder(priority bool) load.Shedder {
 func (ng *engine) notFoundHandler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		chain := alice.New(
+			handler.RequestIdHandler,
 			handler.TracingHandler(ng.conf.Name, ""),
 			ng.getLogHandler(),
 		)
@@ -179,6 +467,35 @@ func (ng *engine) notFoundHandler(next http.Handler) http.Handler {
 	})
 }
 
+// overrideRoute returns fr with the settings of the first RouteConf in Routes that matches route.
+func (ng *engine) overrideRoute(fr featuredRoutes, route Route) featuredRoutes {
+	for _, c := range ng.conf.Routes {
+		if !matchRoute(c, route) {
+			continue
+		}
+
+		if c.Timeout > 0 {
+			fr.timeout = c.Timeout
+		}
+		if c.MaxBytes > 0 {
+			fr.maxBytes = c.MaxBytes
+		}
+		if c.Priority {
+			fr.priority = true
+		}
+		if c.DisableLog {
+			fr.disableLog = true
+		}
+		break
+	}
+
+	return fr
+}
+
+func (ng *engine) setErrorHandler(handler httpx.ErrorHandler) {
+	ng.errorHandler = handler
+}
+
 func (ng *engine) setTlsConfig(cfg *tls.Config) {
 	ng.tlsConfig = cfg
 }
@@ -235,6 +552,81 @@ func (ng *engine) signatureVerifier(signature signatureSetting) (func(chain alic
 	}, nil
 }
 
+// jwtParseOptions returns the token parse options of the public keys, the JWKS and the claims checks.
+func (ng *engine) jwtParseOptions(jwt jwtSetting) ([]token.ParseOption, error) {
+	if !jwt.enabled || jwt.conf == nil {
+		return nil, nil
+	}
+
+	c := jwt.conf
+	var opts []token.ParseOption
+	ks, err := ng.jwtKeySet(c)
+	if err != nil {
+		return nil, err
+	}
+	if ks != nil {
+		opts = append(opts, token.WithKeySet(ks))
+	}
+	if len(c.Algorithms) > 0 {
+		opts = append(opts, token.WithValidMethods(c.Algorithms...))
+	}
+	if len(c.Audiences) > 0 {
+		opts = append(opts, token.WithAudiences(c.Audiences...))
+	}
+	if len(c.Issuer) > 0 {
+		opts = append(opts, token.WithIssuer(c.Issuer))
+	}
+	if c.Leeway > 0 {
+		opts = append(opts, token.WithLeeway(c.Leeway))
+	}
+
+	return opts, nil
+}
+
+// jwtKeySet returns the key set of the public keys and the JWKS in c, or nil if none configured.
+// The key sets are cached by the key sources, to avoid loading and refreshing the same JWKS
+// for each featured routes.
+func (ng *engine) jwtKeySet(c *JwtConf) (*token.KeySet, error) {
+	if len(c.PublicKeys) == 0 && len(c.JwksFile) == 0 && len(c.JwksUrl) == 0 {
+		return nil, nil
+	}
+
+	cacheKey := fmt.Sprintf("%q|%q|%q|%s", c.PublicKeys, c.JwksFile, c.JwksUrl, c.JwksRefresh)
+	if ks, ok := ng.keySets[cacheKey]; ok {
+		return ks, nil
+	}
+
+	var keyOpts []token.KeySetOption
+	for _, pk := range c.PublicKeys {
+		content, err := ioutil.ReadFile(pk.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+
+		key, err := token.ParsePublicKeyFromPEM(content)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", pk.KeyFile, err)
+		}
+
+		keyOpts = append(keyOpts, token.WithPublicKey(pk.Kid, key))
+	}
+	if len(c.JwksFile) > 0 {
+		keyOpts = append(keyOpts, token.WithJwksFile(c.JwksFile))
+	}
+	if len(c.JwksUrl) > 0 {
+		keyOpts = append(keyOpts, token.WithJwksUrl(c.JwksUrl))
+	}
+	keyOpts = append(keyOpts, token.WithJwksRefresh(c.JwksRefresh))
+
+	ks, err := token.NewKeySet(keyOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	ng.keySets[cacheKey] = ks
+	return ks, nil
+}
+
 // start restful 引擎开启
 func (ng *engine) start(router httpx.Router) error {
 	// bindRoute 方法
@@ -258,6 +650,17 @@ func (ng *engine) start(router httpx.Router) error {
 		})
 }
 
+// webSocketHandler returns a handler that upgrades the requests to websocket connections,
+// which are closed on shutting down.
+func (ng *engine) webSocketHandler(handle WebSocketHandler) http.HandlerFunc {
+	ng.webSocketsOnce.Do(func() {
+		ng.webSockets = newWebSocketManager(ng.conf.MaxWebSockets, ng.conf.WebSocketOrigins)
+		proc.AddShutdownListener(ng.webSockets.closeAll)
+	})
+
+	return ng.webSockets.handler(handle)
+}
+
 // 添加中间件
 func (ng *engine) use(middleware Middleware) {
 	ng.middlewares = append(ng.middlewares, middleware)
@@ -268,3 +671,16 @@ func convertMiddleware(ware Middleware) func(http.Handler) http.Handler {
 		return ware(next.ServeHTTP)
 	}
 }
+
+func matchRoute(c RouteConf, route Route) bool {
+	if len(c.Method) > 0 && !strings.EqualFold(c.Method, route.Method) {
+		return false
+	}
+
+	if c.Path == route.Path {
+		return true
+	}
+
+	matched, err := path.Match(c.Path, route.Path)
+	return err == nil && matched
+}
@@ -1,17 +1,32 @@
 package rest
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
 	"errors"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/alicebob/miniredis/v2"
+	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
 	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/fs"
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/requestid"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/internal/cors"
+	"github.com/zeromicro/go-zero/rest/router"
 )
 
 func TestNewEngine(t *testing.T) {
@@ -277,3 +292,789 @@ func (m mockedRouter) SetNotFoundHandler(handler http.Handler) {
 
 func (m mockedRouter) SetNotAllowedHandler(handler http.Handler) {
 }
+
+func TestEngine_SSE(t *testing.T) {
+	logx.Disable()
+
+	ng := newEngine(RestConf{
+		Timeout: 10,
+	})
+	ng.addRoutes(featuredRoutes{
+		sse: true,
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/events",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				// longer than the timeout, the stream must not be cut off
+				time.Sleep(time.Millisecond * 50)
+				assert.Nil(t, httpx.WriteEvent(w, httpx.Event{Data: "done"}))
+			},
+		}},
+	})
+
+	rt := router.NewRouter()
+	assert.Nil(t, ng.bindRoutes(rt))
+	ts := httptest.NewServer(rt)
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/events")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, httpx.TextEventStream, resp.Header.Get(httpx.ContentType))
+	body, err := ioutil.ReadAll(resp.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, "data: done\n\n", string(body))
+}
+
+func TestEngine_SSEWithJwt(t *testing.T) {
+	logx.Disable()
+
+	ng := newEngine(RestConf{})
+	ng.addRoutes(featuredRoutes{
+		sse: true,
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/events",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Nil(t, httpx.WriteEvent(w, httpx.Event{Data: "done"}))
+			},
+		}},
+		jwt: jwtSetting{
+			enabled: true,
+			secret:  "any",
+		},
+	})
+	rt := newRouterForTest(t, ng)
+
+	r := httptest.NewRequest(http.MethodGet, "/events", nil)
+	r.Header.Set("Authorization", "Bearer bad-token")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.NotEqual(t, httpx.TextEventStream, w.Header().Get(httpx.ContentType))
+	assert.False(t, strings.Contains(w.Body.String(), "done"))
+}
+
+func TestEngine_Compress(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+Port: 54321
+Compress:
+  Enabled: true
+  MinSize: 10
+`), &cnf))
+	ng := newEngine(cnf)
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/products",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				httpx.OkJson(w, []string{"apple", "banana", "cherry"})
+			},
+		}},
+	})
+	rt := newRouterForTest(t, ng)
+
+	r := httptest.NewRequest(http.MethodGet, "/products", nil)
+	r.Header.Set("Accept-Encoding", "gzip")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "gzip", w.Header().Get(httpx.ContentEncoding))
+	assert.Equal(t, "Accept-Encoding", w.Header().Get("Vary"))
+	reader, err := gzip.NewReader(w.Body)
+	assert.Nil(t, err)
+	body, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	assert.Equal(t, `["apple","banana","cherry"]`, string(body))
+}
+
+func TestEngine_RequestId(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	ng := newEngine(cnf)
+	var id string
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				id = requestid.FromContext(r.Context())
+			},
+		}},
+	})
+	rt := newRouterForTest(t, ng)
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(requestid.HeaderKey, "foo")
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, "foo", id)
+	assert.Equal(t, "foo", w.Header().Get(requestid.HeaderKey))
+
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.NotEmpty(t, id)
+	assert.NotEqual(t, "foo", id)
+	assert.Equal(t, id, w.Header().Get(requestid.HeaderKey))
+}
+
+func TestEngine_MaxBytes(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321\nMaxBytes: 10"), &cnf))
+	ng := newEngine(cnf)
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method:  http.MethodPost,
+			Path:    "/",
+			Handler: handle,
+		}},
+	})
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method:  http.MethodPost,
+			Path:    "/upload",
+			Handler: handle,
+		}},
+		maxBytes: 100,
+	})
+	rt := newRouterForTest(t, ng)
+
+	for path, code := range map[string]int{
+		"/":       http.StatusRequestEntityTooLarge,
+		"/upload": http.StatusOK,
+	} {
+		r := httptest.NewRequest(http.MethodPost, path, strings.NewReader(strings.Repeat("a", 20)))
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		assert.Equal(t, code, w.Code, path)
+	}
+}
+
+func TestEngine_Cors(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+Port: 54321
+Cors:
+  Enabled: true
+  Origins:
+    - https://*.foo.com
+  ExposeHeaders:
+    - X-Total
+  Credentials: true`), &cnf))
+	assert.Equal(t, 24*time.Hour, cnf.Cors.MaxAge)
+
+	ng := newEngine(cnf)
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{
+			{Method: http.MethodGet, Path: "/users/:id", Handler: handle},
+			{Method: http.MethodPut, Path: "/users/:id", Handler: handle},
+			{Method: http.MethodGet, Path: "/options", Handler: handle},
+			{Method: http.MethodOptions, Path: "/options", Handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTeapot)
+			}},
+		},
+		jwt: jwtSetting{
+			enabled: true,
+			secret:  "any",
+		},
+	})
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{Method: http.MethodGet, Path: "/public", Handler: handle}},
+		cors: corsSetting{
+			CorsConf: CorsConf{Enabled: true},
+			enabled:  true,
+		},
+	})
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{Method: http.MethodGet, Path: "/internal", Handler: handle}},
+		cors:   corsSetting{enabled: true},
+	})
+	rt := newRouterForTest(t, ng)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		origin string
+		code   int
+		allow  string
+	}{
+		{
+			name:   "preflight",
+			method: http.MethodOptions,
+			path:   "/users/1",
+			origin: "https://a.foo.com",
+			code:   http.StatusNoContent,
+			allow:  "https://a.foo.com",
+		},
+		{
+			name:   "preflight not allowed",
+			method: http.MethodOptions,
+			path:   "/users/1",
+			origin: "https://bar.com",
+			code:   http.StatusNoContent,
+		},
+		{
+			name:   "unauthorized",
+			method: http.MethodGet,
+			path:   "/users/1",
+			origin: "https://a.foo.com",
+			code:   http.StatusUnauthorized,
+			allow:  "https://a.foo.com",
+		},
+		{
+			// short-circuited before the auth handlers
+			name:   "user options route",
+			method: http.MethodOptions,
+			path:   "/options",
+			origin: "https://a.foo.com",
+			code:   http.StatusNoContent,
+			allow:  "https://a.foo.com",
+		},
+		{
+			name:   "route group overridden",
+			method: http.MethodOptions,
+			path:   "/public",
+			origin: "https://bar.com",
+			code:   http.StatusNoContent,
+			allow:  "*",
+		},
+		{
+			name:   "route group disabled",
+			method: http.MethodOptions,
+			path:   "/internal",
+			origin: "https://a.foo.com",
+			code:   http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "route group disabled get",
+			method: http.MethodGet,
+			path:   "/internal",
+			origin: "https://a.foo.com",
+			code:   http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(test.method, test.path, nil)
+			r.Header.Set("Origin", test.origin)
+			if test.method == http.MethodOptions {
+				r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+			}
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, r)
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.allow, w.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
+
+func TestEngine_CorsCredentialsWithAllOrigins(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+Port: 54321
+Cors:
+  Enabled: true
+  Credentials: true`), &cnf))
+
+	ng := newEngine(cnf)
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method:  http.MethodGet,
+			Path:    "/users/:id",
+			Handler: func(w http.ResponseWriter, r *http.Request) {},
+		}},
+	})
+	assert.Equal(t, cors.ErrCredentialsWithAllOrigins, ng.bindRoutes(router.NewRouter()))
+}
+
+func TestEngine_ResponseCache(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	var cc ResponseCacheConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("VaryClaims:\n  - uid"), &cc))
+	assert.Equal(t, time.Minute, cc.Expire)
+	rc := MustNewResponseCache(cc)
+
+	var count int32
+	ng := newEngine(cnf)
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/products/:id",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&count, 1)
+				httpx.OkJson(w, r.Context().Value("uid"))
+			},
+		}},
+		cache: rc,
+	})
+	rt := newRouterForTest(t, ng)
+
+	serve := func(etag string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+		r.Header.Set(httpx.ContentType, httpx.ApplicationJson)
+		if len(etag) > 0 {
+			r.Header.Set("If-None-Match", etag)
+		}
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		return w
+	}
+
+	resp := serve("")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	resp = serve(resp.Header().Get("ETag"))
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	assert.Nil(t, rc.Purge(context.Background(), "/products/1"))
+	assert.Equal(t, http.StatusOK, serve("").Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestEngine_ResponseCacheWithJwt(t *testing.T) {
+	logx.Disable()
+
+	const secret = "any-secret-to-sign-the-tokens"
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+
+	tests := []struct {
+		name   string
+		claims []string
+		count  int32
+	}{
+		{
+			name:  "not varied by claims",
+			count: 2,
+		},
+		{
+			name:   "varied by claims",
+			claims: []string{"uid"},
+			count:  1,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var count int32
+			ng := newEngine(cnf)
+			ng.addRoutes(featuredRoutes{
+				routes: []Route{{
+					Method: http.MethodGet,
+					Path:   "/me",
+					Handler: func(w http.ResponseWriter, r *http.Request) {
+						atomic.AddInt32(&count, 1)
+						httpx.OkJson(w, r.Context().Value("uid"))
+					},
+				}},
+				jwt: jwtSetting{
+					enabled: true,
+					secret:  secret,
+				},
+				cache: MustNewResponseCache(ResponseCacheConf{
+					Expire:     time.Minute,
+					Limit:      10,
+					VaryClaims: test.claims,
+				}),
+			})
+			rt := newRouterForTest(t, ng)
+			tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+				"uid": "kevin",
+				"exp": time.Now().Add(time.Hour).Unix(),
+			}).SignedString([]byte(secret))
+			assert.Nil(t, err)
+
+			for i := 0; i < 2; i++ {
+				r := httptest.NewRequest(http.MethodGet, "/me", nil)
+				r.Header.Set("Authorization", "Bearer "+tok)
+				w := httptest.NewRecorder()
+				rt.ServeHTTP(w, r)
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, `"kevin"`, w.Body.String())
+			}
+			assert.Equal(t, test.count, atomic.LoadInt32(&count))
+		})
+	}
+}
+
+func TestEngine_ErrorHandler(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	ng := newEngine(cnf)
+	ng.setErrorHandler(func(ctx context.Context, err error) (int, interface{}) {
+		return http.StatusUnprocessableEntity, err
+	})
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/users/:id",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				httpx.ErrorCtx(r.Context(), w, httpx.NewCodeError(http.StatusNotFound, 1001, "user not found"))
+			},
+		}},
+	})
+	rt := newRouterForTest(t, ng)
+
+	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, httpx.ApplicationProblemJson, w.Header().Get(httpx.ContentType))
+	assert.Equal(t, `{"title":"Unprocessable Entity","status":422,"detail":"user not found","code":1001}`,
+		w.Body.String())
+}
+
+func TestEngine_ErrorHandlerWithError(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	ng := newEngine(cnf)
+	ng.setErrorHandler(func(ctx context.Context, err error) (int, interface{}) {
+		return http.StatusUnprocessableEntity, err
+	})
+	ng.use(func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			next(w, r)
+		}
+	})
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/users/:id",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				httpx.Error(w, httpx.NewCodeError(http.StatusNotFound, 1001, "user not found"))
+			},
+		}},
+	})
+	rt := newRouterForTest(t, ng)
+
+	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, `{"title":"Unprocessable Entity","status":422,"detail":"user not found","code":1001}`,
+		w.Body.String())
+}
+
+func TestEngine_Idempotency(t *testing.T) {
+	logx.Disable()
+
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer s.Close()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	var idem IdempotencyConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Redis:\n  Host: "+s.Addr()+
+		"\nUserBy: header\nUserKey: X-User"), &idem))
+	assert.Equal(t, 24*time.Hour, idem.Expire)
+
+	var count int32
+	ng := newEngine(cnf)
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodPost,
+			Path:   "/order",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&count, 1)
+			},
+		}},
+		idempotency: idempotencySetting{
+			IdempotencyConf: idem,
+			enabled:         true,
+		},
+	})
+	rt := newRouterForTest(t, ng)
+
+	for _, user := range []string{"kevin", "kevin", "anyone"} {
+		r := httptest.NewRequest(http.MethodPost, "/order", nil)
+		r.Header.Set("Idempotency-Key", "foo")
+		r.Header.Set("X-User", user)
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusOK, w.Code)
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestEngine_IdempotencyWithoutRedis(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	ng := newEngine(cnf)
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method:  http.MethodPost,
+			Path:    "/order",
+			Handler: func(w http.ResponseWriter, r *http.Request) {},
+		}},
+		idempotency: idempotencySetting{enabled: true},
+	})
+	assert.NotNil(t, ng.bindRoutes(router.NewRouter()))
+}
+
+func TestEngine_Routes(t *testing.T) {
+	logx.Disable()
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+Port: 54321
+MaxBytes: 10
+Timeout: 50
+Routes:
+  - Method: post
+    Path: /upload/*
+    MaxBytes: 100
+    Priority: true
+    DisableLog: true
+  - Path: /export
+    Timeout: 1s
+  - Path: /export
+    Timeout: 1ms`), &cnf))
+
+	ng := newEngine(cnf)
+	handle := func(w http.ResponseWriter, r *http.Request) {}
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{
+			{Method: http.MethodPost, Path: "/upload/:name", Handler: handle},
+			{Method: http.MethodPost, Path: "/upload", Handler: handle},
+			{Method: http.MethodGet, Path: "/upload/:name", Handler: handle},
+			{Method: http.MethodGet, Path: "/export", Handler: func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(time.Millisecond * 100)
+			}},
+		},
+		timeout: time.Millisecond * 10,
+	})
+	rt := newRouterForTest(t, ng)
+
+	tests := []struct {
+		method string
+		path   string
+		code   int
+	}{
+		{method: http.MethodPost, path: "/upload/foo", code: http.StatusOK},
+		{method: http.MethodPost, path: "/upload", code: http.StatusRequestEntityTooLarge},
+		{method: http.MethodGet, path: "/upload/foo", code: http.StatusRequestEntityTooLarge},
+		{method: http.MethodGet, path: "/export", code: http.StatusOK},
+	}
+	for _, test := range tests {
+		body := strings.Repeat("a", 20)
+		if test.path == "/export" {
+			body = ""
+		}
+		r := httptest.NewRequest(test.method, test.path, strings.NewReader(body))
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		assert.Equal(t, test.code, w.Code, test.method+test.path)
+	}
+
+	fr := ng.overrideRoute(ng.routes[0], ng.routes[0].routes[0])
+	assert.True(t, fr.priority)
+	assert.True(t, fr.disableLog)
+	assert.Equal(t, int64(100), fr.maxBytes)
+	fr = ng.overrideRoute(ng.routes[0], ng.routes[0].routes[3])
+	assert.Equal(t, time.Second, fr.timeout)
+	assert.False(t, fr.priority)
+}
+
+func TestEngine_RoutesBadPattern(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321\nRoutes:\n  - Path: /[a"), &cnf))
+	ng := newEngine(cnf)
+	assert.NotNil(t, ng.bindRoutes(router.NewRouter()))
+}
+
+func TestMatchRoute(t *testing.T) {
+	tests := []struct {
+		conf   RouteConf
+		route  Route
+		expect bool
+	}{
+		{
+			conf:   RouteConf{Path: "/users/:id"},
+			route:  Route{Method: http.MethodGet, Path: "/users/:id"},
+			expect: true,
+		},
+		{
+			conf:   RouteConf{Method: "get", Path: "/users/*"},
+			route:  Route{Method: http.MethodGet, Path: "/users/:id"},
+			expect: true,
+		},
+		{
+			conf:  RouteConf{Method: http.MethodPost, Path: "/users/:id"},
+			route: Route{Method: http.MethodGet, Path: "/users/:id"},
+		},
+		{
+			conf:  RouteConf{Path: "/users/*"},
+			route: Route{Method: http.MethodGet, Path: "/users/:id/orders"},
+		},
+		{
+			conf:  RouteConf{Path: "/[a"},
+			route: Route{Method: http.MethodGet, Path: "/a"},
+		},
+	}
+
+	for _, test := range tests {
+		assert.Equal(t, test.expect, matchRoute(test.conf, test.route), test.conf.Path)
+	}
+}
+
+func TestEngine_JwtConf(t *testing.T) {
+	logx.Disable()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	keyFile, err := fs.TempFilenameWithText(string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	})))
+	assert.Nil(t, err)
+	defer os.Remove(keyFile)
+
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	ng := newEngine(cnf)
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/",
+			Handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(r.Context().Value("user").(string)))
+			},
+		}},
+		jwt: jwtSetting{
+			enabled: true,
+			conf: &JwtConf{
+				PublicKeys: []PublicKeyConf{{KeyFile: keyFile}},
+				Algorithms: []string{"RS256"},
+				Issuer:     "idp",
+				Leeway:     time.Minute,
+			},
+		},
+	})
+	rt := newRouterForTest(t, ng)
+
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		code   int
+	}{
+		{
+			name: "valid",
+			claims: jwt.MapClaims{
+				"iss":  "idp",
+				"exp":  time.Now().Add(-time.Second * 10).Unix(),
+				"user": "foo",
+			},
+			code: http.StatusOK,
+		},
+		{
+			name: "bad issuer",
+			claims: jwt.MapClaims{
+				"iss":  "other",
+				"user": "foo",
+			},
+			code: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			tok, err := jwt.NewWithClaims(jwt.SigningMethodRS256, test.claims).SignedString(key)
+			assert.Nil(t, err)
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", "Bearer "+tok)
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, r)
+			assert.Equal(t, test.code, w.Code)
+			if test.code == http.StatusOK {
+				assert.Equal(t, "foo", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestEngine_JwtConfBadKeys(t *testing.T) {
+	keyFile, err := fs.TempFilenameWithText("bad")
+	assert.Nil(t, err)
+	defer os.Remove(keyFile)
+
+	tests := []JwtConf{
+		{PublicKeys: []PublicKeyConf{{KeyFile: "not-exist"}}},
+		{PublicKeys: []PublicKeyConf{{KeyFile: keyFile}}},
+		{JwksFile: keyFile},
+	}
+	for _, test := range tests {
+		test := test
+		_, err := newEngine(RestConf{}).jwtParseOptions(jwtSetting{
+			enabled: true,
+			conf:    &test,
+		})
+		assert.NotNil(t, err)
+	}
+}
+
+func TestEngine_JwtKeySetShared(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	der, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.Nil(t, err)
+	keyFile, err := fs.TempFilenameWithText(string(pem.EncodeToMemory(&pem.Block{
+		Type:  "PUBLIC KEY",
+		Bytes: der,
+	})))
+	assert.Nil(t, err)
+	defer os.Remove(keyFile)
+
+	ng := newEngine(RestConf{})
+	first, err := ng.jwtKeySet(&JwtConf{PublicKeys: []PublicKeyConf{{Kid: "foo", KeyFile: keyFile}}})
+	assert.Nil(t, err)
+	second, err := ng.jwtKeySet(&JwtConf{
+		PublicKeys: []PublicKeyConf{{Kid: "foo", KeyFile: keyFile}},
+		Audiences:  []string{"bar"},
+	})
+	assert.Nil(t, err)
+	assert.True(t, first == second)
+
+	third, err := ng.jwtKeySet(&JwtConf{PublicKeys: []PublicKeyConf{{Kid: "bar", KeyFile: keyFile}}})
+	assert.Nil(t, err)
+	assert.False(t, first == third)
+	assert.Len(t, ng.keySets, 2)
+
+	ks, err := ng.jwtKeySet(&JwtConf{Secret: "any"})
+	assert.Nil(t, err)
+	assert.Nil(t, ks)
+}
+
+func newRouterForTest(t *testing.T, ng *engine) http.Handler {
+	rt := router.NewRouter()
+	assert.Nil(t, ng.bindRoutes(rt))
+	return rt
+}
@@ -0,0 +1,89 @@
+package rest
+
+import (
+	"net/http"
+	"path"
+)
+
+// A Group is a group of routes that share the path prefix, the route options and the middlewares.
+// The sub groups inherit the settings of their parents, and override them with their own options,
+// like the jwt secret, the signature, the timeout and the priority.
+type Group struct {
+	server      *Server
+	parent      *Group
+	prefix      string
+	opts        []RouteOption
+	middlewares []Middleware
+}
+
+// Group returns a Group that registers routes with given path prefix and options.
+func (s *Server) Group(prefix string, opts ...RouteOption) *Group {
+	return &Group{
+		server: s,
+		prefix: path.Join("/", prefix),
+		opts:   opts,
+	}
+}
+
+// AddRoute adds given route into the Group.
+func (g *Group) AddRoute(r Route, opts ...RouteOption) {
+	g.AddRoutes([]Route{r}, opts...)
+}
+
+// AddRoutes adds given routes into the Group, the given options override the group options.
+func (g *Group) AddRoutes(rs []Route, opts ...RouteOption) {
+	routes := WithMiddlewares(g.allMiddlewares(), rs...)
+	for i := range routes {
+		routes[i].Path = path.Join(g.prefix, routes[i].Path)
+	}
+
+	g.server.AddRoutes(routes, append(g.allOptions(), opts...)...)
+}
+
+// AddWebSocketRoute adds given websocket route into the Group.
+func (g *Group) AddWebSocketRoute(r WebSocketRoute, opts ...RouteOption) {
+	route := Route{
+		Method:  http.MethodGet,
+		Path:    r.Path,
+		Handler: g.server.ngin.webSocketHandler(r.Handler),
+	}
+	g.AddRoutes([]Route{route}, append(opts, withWebSocket())...)
+}
+
+// Group returns a sub group with given path prefix appended to the group's,
+// and given options applied after the group's.
+func (g *Group) Group(prefix string, opts ...RouteOption) *Group {
+	return &Group{
+		server: g.server,
+		parent: g,
+		prefix: path.Join(g.prefix, prefix),
+		opts:   opts,
+	}
+}
+
+// Use adds given middlewares into the Group, which apply to the routes added afterwards,
+// including the ones added into the sub groups. The parent's middlewares run first.
+func (g *Group) Use(middlewares ...Middleware) {
+	g.middlewares = append(g.middlewares, middlewares...)
+}
+
+// allMiddlewares returns a copy of the middlewares from the root group to g.
+func (g *Group) allMiddlewares() []Middleware {
+	var middlewares []Middleware
+	if g.parent != nil {
+		middlewares = g.parent.allMiddlewares()
+	}
+
+	return append(middlewares, g.middlewares...)
+}
+
+// allOptions returns a copy of the options from the root group to g,
+// the later ones override the former ones.
+func (g *Group) allOptions() []RouteOption {
+	var opts []RouteOption
+	if g.parent != nil {
+		opts = g.parent.allOptions()
+	}
+
+	return append(opts, g.opts...)
+}
@@ -0,0 +1,119 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/conf"
+)
+
+func TestGroup(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	srv := MustNewServer(cnf)
+
+	tagger := func(tag string) Middleware {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Tags", tag)
+				next(w, r)
+			}
+		}
+	}
+	echo := func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.Path)
+	}
+
+	api := srv.Group("/api", WithTimeout(time.Second), WithPriority())
+	api.Use(tagger("api"))
+	api.AddRoute(Route{
+		Method:  http.MethodGet,
+		Path:    "/ping",
+		Handler: echo,
+	})
+
+	v1 := api.Group("v1", WithJwt("abcdefghijk"), WithTimeout(time.Minute))
+	v1.Use(tagger("v1"))
+	v1.AddRoutes([]Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/users/:id",
+			Handler: echo,
+		},
+	}, WithTimeout(time.Hour))
+	v1.Group("/admin").AddRoute(Route{
+		Method:  http.MethodGet,
+		Path:    "/",
+		Handler: echo,
+	})
+	api.Use(tagger("late"))
+	api.AddRoute(Route{
+		Method:  http.MethodGet,
+		Path:    "/late",
+		Handler: echo,
+	})
+
+	routes := srv.ngin.routes
+	assert.Equal(t, 4, len(routes))
+	assert.Equal(t, "/api/ping", routes[0].routes[0].Path)
+	assert.Equal(t, time.Second, routes[0].timeout)
+	assert.True(t, routes[0].priority)
+	assert.False(t, routes[0].jwt.enabled)
+
+	assert.Equal(t, "/api/v1/users/:id", routes[1].routes[0].Path)
+	assert.Equal(t, time.Hour, routes[1].timeout)
+	assert.True(t, routes[1].priority)
+	assert.True(t, routes[1].jwt.enabled)
+
+	assert.Equal(t, "/api/v1/admin", routes[2].routes[0].Path)
+	assert.Equal(t, time.Minute, routes[2].timeout)
+	assert.True(t, routes[2].jwt.enabled)
+
+	tests := []struct {
+		route featuredRoutes
+		tags  []string
+	}{
+		{
+			route: routes[0],
+			tags:  []string{"api"},
+		},
+		{
+			route: routes[1],
+			tags:  []string{"api", "v1"},
+		},
+		{
+			route: routes[2],
+			tags:  []string{"api", "v1"},
+		},
+		{
+			route: routes[3],
+			tags:  []string{"api", "late"},
+		},
+	}
+	for _, test := range tests {
+		route := test.route.routes[0]
+		r := httptest.NewRequest(http.MethodGet, route.Path, nil)
+		w := httptest.NewRecorder()
+		route.Handler(w, r)
+		assert.Equal(t, test.tags, w.Header()["X-Tags"])
+		assert.Equal(t, route.Path, w.Body.String())
+	}
+}
+
+func TestGroupWithWebSocket(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	srv := MustNewServer(cnf)
+	srv.Group("/ws", WithTimeout(time.Second)).AddWebSocketRoute(WebSocketRoute{
+		Path: "/echo",
+	})
+
+	assert.Equal(t, 1, len(srv.ngin.routes))
+	assert.True(t, srv.ngin.routes[0].websocket)
+	assert.Equal(t, http.MethodGet, srv.ngin.routes[0].routes[0].Method)
+	assert.Equal(t, "/ws/echo", srv.ngin.routes[0].routes[0].Path)
+}
@@ -31,8 +31,9 @@ var (
 type (
 	// A AuthorizeOptions is authorize options.
 	AuthorizeOptions struct {
-		PrevSecret string
-		Callback   UnauthorizedCallback
+		PrevSecret   string
+		Callback     UnauthorizedCallback
+		ParseOptions []token.ParseOption
 	}
 
 	// UnauthorizedCallback defines the method of unauthorized callback.
@@ -48,7 +49,7 @@ func Authorize(secret string, opts ...AuthorizeOption) func(http.Handler) http.H
 		opt(&authOpts)
 	}
 
-	parser := token.NewTokenParser()
+	parser := token.NewTokenParser(authOpts.ParseOptions...)
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			tok, err := parser.ParseToken(r, secret, authOpts.PrevSecret)
@@ -83,6 +84,14 @@ func Authorize(secret string, opts ...AuthorizeOption) func(http.Handler) http.H
 	}
 }
 
+// WithParseOptions returns an AuthorizeOption with setting the options of the token parser,
+// like the keys to verify the tokens signed with RSA, ECDSA or EdDSA, and the claims checks.
+func WithParseOptions(opts ...token.ParseOption) AuthorizeOption {
+	return func(authOpts *AuthorizeOptions) {
+		authOpts.ParseOptions = append(authOpts.ParseOptions, opts...)
+	}
+}
+
 // WithPrevSecret returns an AuthorizeOption with setting previous secret.
 func WithPrevSecret(secret string) AuthorizeOption {
 	return func(opts *AuthorizeOptions) {
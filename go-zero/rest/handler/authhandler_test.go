@@ -2,6 +2,8 @@ package handler
 
 import (
 	"bufio"
+	"crypto/rand"
+	"crypto/rsa"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/golang-jwt/jwt/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/token"
 )
 
 func TestAuthHandlerFailed(t *testing.T) {
@@ -82,6 +85,56 @@ func TestAuthHandlerWithPrevSecret(t *testing.T) {
 	assert.Equal(t, "content", resp.Body.String())
 }
 
+func TestAuthHandlerWithKeySet(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ks, err := token.NewKeySet(token.WithPublicKey("foo", &key.PublicKey))
+	assert.Nil(t, err)
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"aud": "api",
+		"key": "value",
+	})
+	tok.Header["kid"] = "foo"
+	signed, err := tok.SignedString(key)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name string
+		aud  string
+		code int
+	}{
+		{
+			name: "accepted",
+			aud:  "api",
+			code: http.StatusOK,
+		},
+		{
+			name: "bad audience",
+			aud:  "other",
+			code: http.StatusUnauthorized,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Set("Authorization", "Bearer "+signed)
+			handler := Authorize("", WithParseOptions(token.WithKeySet(ks),
+				token.WithAudiences(test.aud)))(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					assert.Equal(t, "value", r.Context().Value("key"))
+				}))
+
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+			assert.Equal(t, test.code, resp.Code)
+		})
+	}
+}
+
 func TestAuthHandler_NilError(t *testing.T) {
 	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
 	resp := httptest.NewRecorder()
@@ -0,0 +1,313 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/hash"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const (
+	authorizationHeader = "Authorization"
+	cacheControlHeader  = "Cache-Control"
+	setCookieHeader     = "Set-Cookie"
+	ifNoneMatchHeader   = "If-None-Match"
+	responseCacheKey    = "rest:cache:"
+	responseVersionKey  = "rest:cache:ver:"
+	versionExpireRatio  = 2
+	// the max response body to cache, the larger ones are not cached.
+	maxCachedBodySize = 1 << 20
+)
+
+type (
+	// A ResponseStore stores the cached responses.
+	ResponseStore interface {
+		// Get returns the value of key, false if not found.
+		Get(ctx context.Context, key string) (string, bool, error)
+		// Set sets the value of key, which expires after expire.
+		Set(ctx context.Context, key, val string, expire time.Duration) error
+	}
+
+	// A ResponseCache caches the successful responses of the GET requests, the requests are
+	// varied by their URIs, the headers in varyHeaders and the jwt claims in varyClaims.
+	// The responses are served with ETags, and 304 is responded if matching If-None-Match.
+	// The requests with Authorization are not cached unless varied by claims,
+	// to not serve the responses of a user to the others.
+	ResponseCache struct {
+		store       ResponseStore
+		expire      time.Duration
+		varyHeaders []string
+		varyClaims  []string
+	}
+
+	cachedResponse struct {
+		Code   int         `json:"code"`
+		Header http.Header `json:"header"`
+		Body   []byte      `json:"body"`
+	}
+
+	cacheResponseWriter struct {
+		http.ResponseWriter
+		code     int
+		header   http.Header
+		body     bytes.Buffer
+		overflow bool
+	}
+)
+
+// NewResponseCache returns a ResponseCache that caches the responses in store for expire.
+func NewResponseCache(store ResponseStore, expire time.Duration, varyHeaders, varyClaims []string) *ResponseCache {
+	return &ResponseCache{
+		store:       store,
+		expire:      expire,
+		varyHeaders: varyHeaders,
+		varyClaims:  varyClaims,
+	}
+}
+
+// Handler is a middleware that serves the GET requests from the cache, the requests with
+// Cache-Control no-cache, no-store or max-age=0 are not served from the cache, and the responses
+// with Cache-Control no-cache, no-store, private or max-age=0 are not cached.
+// The Set-Cookie headers are never cached.
+func (rc *ResponseCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet ||
+			(!rc.VariesByClaims() && len(r.Header.Get(authorizationHeader)) > 0) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		for _, header := range rc.varyHeaders {
+			w.Header().Add(varyHeader, header)
+		}
+
+		ctx := r.Context()
+		key, err := rc.key(r)
+		if err != nil {
+			logx.WithContext(ctx).Errorf("fail to use response cache: %s", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !isUncacheable(r.Header.Get(cacheControlHeader)) {
+			if resp, ok := rc.get(ctx, key); ok {
+				writeCachedResponse(w, r, resp)
+				return
+			}
+		}
+
+		cw := &cacheResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(cw, r)
+		resp, ok := cw.finish()
+		if !ok {
+			return
+		}
+
+		writeCachedResponse(w, r, resp)
+		if resp.Code != http.StatusOK || isUncacheable(resp.Header.Get(cacheControlHeader)) ||
+			isPrivate(resp.Header.Get(cacheControlHeader)) {
+			return
+		}
+
+		// the cookies are per user, never replay them to the others
+		header := resp.Header.Clone()
+		header.Del(setCookieHeader)
+		content, err := json.Marshal(cachedResponse{
+			Code:   resp.Code,
+			Header: header,
+			Body:   resp.Body,
+		})
+		if err != nil {
+			logx.WithContext(ctx).Errorf("fail to marshal cached response: %s", err)
+			return
+		}
+		if err := rc.store.Set(ctx, key, string(content), rc.expire); err != nil {
+			logx.WithContext(ctx).Errorf("fail to cache response: %s", err)
+		}
+	})
+}
+
+// Purge purges the cached responses of the paths, like /products/123,
+// including the ones with different queries, headers and claims.
+func (rc *ResponseCache) Purge(ctx context.Context, paths ...string) error {
+	version := strconv.FormatInt(time.Now().UnixNano(), 36)
+	for _, path := range paths {
+		// the versions outlive the responses, to not serve the purged responses again
+		// if the versions expired before them
+		if err := rc.store.Set(ctx, responseVersionKey+path, version, rc.expire*versionExpireRatio); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VariesByClaims checks if the responses are varied by the jwt claims,
+// the responses of the routes with jwt are not cached otherwise.
+func (rc *ResponseCache) VariesByClaims() bool {
+	return len(rc.varyClaims) > 0
+}
+
+func (rc *ResponseCache) get(ctx context.Context, key string) (*cachedResponse, bool) {
+	val, ok, err := rc.store.Get(ctx, key)
+	if err != nil {
+		logx.WithContext(ctx).Errorf("fail to get cached response: %s", err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+
+	var resp cachedResponse
+	if err := json.Unmarshal([]byte(val), &resp); err != nil {
+		logx.WithContext(ctx).Errorf("bad cached response: %s", err)
+		return nil, false
+	}
+
+	return &resp, true
+}
+
+// key returns the cache key of r, which contains the version of the path to be purged.
+func (rc *ResponseCache) key(r *http.Request) (string, error) {
+	version, ok, err := rc.store.Get(r.Context(), responseVersionKey+r.URL.Path)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		version = "0"
+	}
+
+	var buf strings.Builder
+	buf.WriteString(r.URL.RequestURI())
+	for _, header := range rc.varyHeaders {
+		buf.WriteString("\n")
+		buf.WriteString(r.Header.Get(header))
+	}
+	for _, claim := range rc.varyClaims {
+		buf.WriteString("\n")
+		if val := r.Context().Value(claim); val != nil {
+			buf.WriteString(fmt.Sprint(val))
+		}
+	}
+
+	return responseCacheKey + version + ":" + hash.Md5Hex([]byte(buf.String())), nil
+}
+
+// finish returns the buffered response, false if the response is too large and already written.
+func (w *cacheResponseWriter) finish() (*cachedResponse, bool) {
+	if w.overflow {
+		return nil, false
+	}
+
+	if w.code == 0 {
+		w.code = http.StatusOK
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+
+	return &cachedResponse{
+		Code:   w.code,
+		Header: w.header,
+		Body:   w.body.Bytes(),
+	}, true
+}
+
+func (w *cacheResponseWriter) Write(data []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.overflow {
+		return w.ResponseWriter.Write(data)
+	}
+
+	if w.body.Len()+len(data) <= maxCachedBodySize {
+		return w.body.Write(data)
+	}
+
+	// too large to cache, write the buffered content and pass through
+	w.overflow = true
+	w.ResponseWriter.WriteHeader(w.code)
+	if _, err := w.ResponseWriter.Write(w.body.Bytes()); err != nil {
+		return 0, err
+	}
+	w.body.Reset()
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *cacheResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+}
+
+// isPrivate checks if the response is for a single user, including private="field" directives.
+func isPrivate(cacheControl string) bool {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "private" || strings.HasPrefix(directive, "private=") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isUncacheable(cacheControl string) bool {
+	for _, directive := range strings.Split(cacheControl, ",") {
+		directive = strings.ToLower(strings.TrimSpace(directive))
+		if directive == "no-cache" || directive == "no-store" || directive == "max-age=0" {
+			return true
+		}
+	}
+
+	return false
+}
+
+func matchETag(ifNoneMatch, etag string) bool {
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		// the weak comparison, the etags are weakened on compressing
+		if tag == "*" || strings.TrimPrefix(tag, weakEtagPrefix) == strings.TrimPrefix(etag, weakEtagPrefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func writeCachedResponse(w http.ResponseWriter, r *http.Request, resp *cachedResponse) {
+	header := w.Header()
+	for k, v := range resp.Header {
+		// keep the headers set by the outer middlewares, like X-Request-Id
+		if _, ok := header[k]; !ok {
+			header[k] = v
+		}
+	}
+
+	if resp.Code == http.StatusOK {
+		etag := header.Get(etagHeader)
+		if len(etag) == 0 {
+			etag = `"` + hash.Md5Hex(resp.Body) + `"`
+			header.Set(etagHeader, etag)
+		}
+
+		if matchETag(r.Header.Get(ifNoneMatchHeader), etag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	w.WriteHeader(resp.Code)
+	if _, err := w.Write(resp.Body); err != nil {
+		logx.WithContext(r.Context()).Errorf("write response failed, error: %s", err)
+	}
+}
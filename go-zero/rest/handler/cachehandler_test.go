@@ -0,0 +1,263 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResponseCache(t *testing.T) {
+	var count int32
+	rc := NewResponseCache(newMockedResponseStore(), time.Minute, []string{"Accept-Language"}, []string{"uid"})
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		w.Header().Set("Content-Type", "text/plain")
+		_, _ = w.Write([]byte("product " + r.URL.Path + " " + strconv.Itoa(int(n))))
+	}))
+
+	resp := serveCached(handler, "/products/1", nil)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "product /products/1 1", resp.Body.String())
+	etag := resp.Header().Get(etagHeader)
+	assert.NotEmpty(t, etag)
+	assert.Equal(t, []string{"Accept-Language"}, resp.Header().Values(varyHeader))
+
+	resp = serveCached(handler, "/products/1", nil)
+	assert.Equal(t, "product /products/1 1", resp.Body.String())
+	assert.Equal(t, "text/plain", resp.Header().Get("Content-Type"))
+	assert.Equal(t, etag, resp.Header().Get(etagHeader))
+
+	resp = serveCached(handler, "/products/1", map[string]string{ifNoneMatchHeader: "W/" + etag})
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	assert.Empty(t, resp.Body.String())
+
+	// varied by queries, headers and claims
+	assert.Equal(t, "product /products/1 2", serveCached(handler, "/products/1?lang=en", nil).Body.String())
+	assert.Equal(t, "product /products/1 3", serveCached(handler, "/products/1",
+		map[string]string{"Accept-Language": "en"}).Body.String())
+	r := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), "uid", 1)))
+	assert.Equal(t, "product /products/1 4", w.Body.String())
+
+	// bypass the cache
+	assert.Equal(t, "product /products/1 5", serveCached(handler, "/products/1",
+		map[string]string{cacheControlHeader: "no-cache"}).Body.String())
+	assert.Equal(t, "product /products/1 5", serveCached(handler, "/products/1", nil).Body.String())
+
+	assert.Nil(t, rc.Purge(context.Background(), "/products/1"))
+	assert.Equal(t, "product /products/1 6", serveCached(handler, "/products/1", nil).Body.String())
+	assert.Equal(t, "product /products/1 7", serveCached(handler, "/products/1?lang=en", nil).Body.String())
+	assert.Equal(t, "product /products/1 6", serveCached(handler, "/products/1", nil).Body.String())
+
+	r = httptest.NewRequest(http.MethodPost, "/products/1", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, "product /products/1 8", w.Body.String())
+	assert.Empty(t, w.Header().Get(etagHeader))
+}
+
+func TestResponseCache_Uncacheable(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		control string
+		etag    string
+	}{
+		{
+			name: "not ok",
+			code: http.StatusNotFound,
+		},
+		{
+			name:    "no store",
+			code:    http.StatusOK,
+			control: "no-store",
+		},
+		{
+			name:    "private",
+			code:    http.StatusOK,
+			control: "private, max-age=60",
+		},
+		{
+			name:    "private field",
+			code:    http.StatusOK,
+			control: `Private="X-User", max-age=60`,
+		},
+		{
+			name:    "max age 0",
+			code:    http.StatusOK,
+			control: "public, max-age=0",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var count int32
+			rc := NewResponseCache(newMockedResponseStore(), time.Minute, nil, nil)
+			handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&count, 1)
+				if len(test.control) > 0 {
+					w.Header().Set(cacheControlHeader, test.control)
+				}
+				w.WriteHeader(test.code)
+			}))
+
+			assert.Equal(t, test.code, serveCached(handler, "/", nil).Code)
+			assert.Equal(t, test.code, serveCached(handler, "/", nil).Code)
+			assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+		})
+	}
+}
+
+func TestResponseCache_Authorization(t *testing.T) {
+	var count int32
+	handler := func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		_, _ = w.Write([]byte(strconv.Itoa(int(n))))
+	}
+	auth := map[string]string{authorizationHeader: "Bearer token"}
+
+	rc := NewResponseCache(newMockedResponseStore(), time.Minute, nil, nil)
+	assert.False(t, rc.VariesByClaims())
+	h := rc.Handler(http.HandlerFunc(handler))
+	assert.Equal(t, "1", serveCached(h, "/", auth).Body.String())
+	assert.Equal(t, "2", serveCached(h, "/", auth).Body.String())
+	assert.Equal(t, "3", serveCached(h, "/", nil).Body.String())
+	assert.Equal(t, "3", serveCached(h, "/", nil).Body.String())
+	// the responses cached without authorization are not served to the authorized ones either
+	assert.Equal(t, "4", serveCached(h, "/", auth).Body.String())
+
+	rc = NewResponseCache(newMockedResponseStore(), time.Minute, nil, []string{"uid"})
+	assert.True(t, rc.VariesByClaims())
+	h = rc.Handler(http.HandlerFunc(handler))
+	assert.Equal(t, "5", serveCached(h, "/", auth).Body.String())
+	assert.Equal(t, "5", serveCached(h, "/", auth).Body.String())
+}
+
+func TestResponseCache_SetCookie(t *testing.T) {
+	var count int32
+	rc := NewResponseCache(newMockedResponseStore(), time.Minute, nil, nil)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&count, 1)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: strconv.Itoa(int(n))})
+		_, _ = w.Write([]byte("foo"))
+	}))
+
+	resp := serveCached(handler, "/", nil)
+	assert.Equal(t, "session=1", resp.Header().Get(setCookieHeader))
+	resp = serveCached(handler, "/", nil)
+	assert.Equal(t, "foo", resp.Body.String())
+	assert.Empty(t, resp.Header().Get(setCookieHeader))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestResponseCache_CustomETag(t *testing.T) {
+	rc := NewResponseCache(newMockedResponseStore(), time.Minute, nil, nil)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(etagHeader, `"v1"`)
+		_, _ = w.Write([]byte("foo"))
+	}))
+
+	resp := serveCached(handler, "/", map[string]string{ifNoneMatchHeader: `"v0", "v1"`})
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	resp = serveCached(handler, "/", map[string]string{ifNoneMatchHeader: "*"})
+	assert.Equal(t, http.StatusNotModified, resp.Code)
+	resp = serveCached(handler, "/", map[string]string{ifNoneMatchHeader: `"v0"`})
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `"v1"`, resp.Header().Get(etagHeader))
+}
+
+func TestResponseCache_LargeBody(t *testing.T) {
+	var count int32
+	body := strings.Repeat("a", maxCachedBodySize+1)
+	rc := NewResponseCache(newMockedResponseStore(), time.Minute, nil, nil)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(body[:maxCachedBodySize]))
+		_, _ = w.Write([]byte(body[maxCachedBodySize:]))
+	}))
+
+	for i := 0; i < 2; i++ {
+		resp := serveCached(handler, "/", nil)
+		assert.Equal(t, http.StatusAccepted, resp.Code)
+		assert.Equal(t, body, resp.Body.String())
+		assert.Empty(t, resp.Header().Get(etagHeader))
+	}
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestResponseCache_StoreErrors(t *testing.T) {
+	var count int32
+	store := newMockedResponseStore()
+	rc := NewResponseCache(store, time.Minute, nil, nil)
+	handler := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+	}))
+
+	store.err = errors.New("any")
+	assert.Equal(t, http.StatusOK, serveCached(handler, "/", nil).Code)
+	assert.NotNil(t, rc.Purge(context.Background(), "/"))
+
+	store.err = nil
+	store.data[responseCacheKey+"0:"+"bad"] = "bad"
+	_, ok := rc.get(context.Background(), responseCacheKey+"0:"+"bad")
+	assert.False(t, ok)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+type mockedResponseStore struct {
+	lock sync.Mutex
+	data map[string]string
+	err  error
+}
+
+func newMockedResponseStore() *mockedResponseStore {
+	return &mockedResponseStore{
+		data: make(map[string]string),
+	}
+}
+
+func (s *mockedResponseStore) Get(_ context.Context, key string) (string, bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return "", false, s.err
+	}
+
+	val, ok := s.data[key]
+	return val, ok, nil
+}
+
+func (s *mockedResponseStore) Set(_ context.Context, key, val string, _ time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.err != nil {
+		return s.err
+	}
+
+	s.data[key] = val
+	return nil
+}
+
+func serveCached(handler http.Handler, path string, headers map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, path, nil)
+	for k, v := range headers {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
@@ -0,0 +1,22 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/canary"
+)
+
+// CanaryHandler returns a middleware that keeps the canary label from the X-Canary header
+// in the context, the label is forwarded to the rpc services by the zrpc clients,
+// to route the calls to the matching instances.
+func CanaryHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		label := r.Header.Get(canary.HeaderKey)
+		if !canary.IsValid(label) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(canary.NewContext(r.Context(), label)))
+	})
+}
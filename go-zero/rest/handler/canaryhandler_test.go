@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/canary"
+)
+
+func TestCanaryHandler(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		expect string
+	}{
+		{
+			name: "absent",
+		},
+		{
+			name:   "accepted",
+			header: "v2",
+			expect: "v2",
+		},
+		{
+			name:   "invalid",
+			header: "v 2",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var label string
+			h := CanaryHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				label = canary.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+			if len(test.header) > 0 {
+				req.Header.Set(canary.HeaderKey, test.header)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, test.expect, label)
+		})
+	}
+}
@@ -0,0 +1,357 @@
+package handler
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+const (
+	brotliEncoding    = "br"
+	deflateEncoding   = "deflate"
+	anyEncoding       = "*"
+	acceptEncoding    = "Accept-Encoding"
+	contentLength     = "Content-Length"
+	etagHeader        = "ETag"
+	varyHeader        = "Vary"
+	weakEtagPrefix    = "W/"
+	defaultMinSize    = 1024
+	qualityParam      = "q="
+	wildcardMediaType = "/*"
+)
+
+var (
+	// DefaultCompressTypes are the content types to compress if not specified.
+	DefaultCompressTypes = []string{
+		"text/html",
+		"text/css",
+		"text/plain",
+		"text/xml",
+		"text/javascript",
+		"application/javascript",
+		"application/json",
+		"application/xml",
+		"image/svg+xml",
+	}
+	// DefaultCompressEncodings are the encodings in preference order if not specified.
+	DefaultCompressEncodings = []string{brotliEncoding, gzipEncoding, deflateEncoding}
+
+	compressorPools = map[string]*sync.Pool{
+		brotliEncoding: {
+			New: func() interface{} {
+				return brotli.NewWriter(nil)
+			},
+		},
+		gzipEncoding: {
+			New: func() interface{} {
+				return gzip.NewWriter(nil)
+			},
+		},
+		deflateEncoding: {
+			New: func() interface{} {
+				return zlib.NewWriter(nil)
+			},
+		},
+	}
+)
+
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressHandler returns a middleware that compresses the responses with the encoding
+// negotiated by Accept-Encoding, from br, gzip and deflate in the order of encodings.
+// The responses are compressed only if their content types are in types, like text/html
+// or text/*, and their sizes are not less than minSize. The streaming responses that are
+// flushed before reaching minSize are compressed if there are data to flush.
+func CompressHandler(minSize int, types, encodings []string) func(http.Handler) http.Handler {
+	if minSize <= 0 {
+		minSize = defaultMinSize
+	}
+	if len(types) == 0 {
+		types = DefaultCompressTypes
+	}
+	if len(encodings) == 0 {
+		encodings = DefaultCompressEncodings
+	}
+
+	var supported []string
+	for _, encoding := range encodings {
+		encoding = strings.ToLower(strings.TrimSpace(encoding))
+		if _, ok := compressorPools[encoding]; ok {
+			supported = append(supported, encoding)
+		} else {
+			logx.Errorf("unsupported compress encoding: %s", encoding)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			cw := &compressResponseWriter{
+				writer:   w,
+				encoding: negotiateEncoding(r.Header.Get(acceptEncoding), supported),
+				minSize:  minSize,
+				types:    types,
+			}
+			defer cw.close()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressResponseWriter buffers the response until it's large enough or flushed,
+// then decides to compress it or not.
+type compressResponseWriter struct {
+	writer     http.ResponseWriter
+	encoding   string
+	minSize    int
+	types      []string
+	code       int
+	buf        bytes.Buffer
+	decided    bool
+	compressor compressor
+	hijacked   bool
+}
+
+func (w *compressResponseWriter) Flush() {
+	if !w.decided {
+		if err := w.decide(true); err != nil {
+			logx.Error(err)
+		}
+	}
+
+	if w.compressor != nil {
+		if err := w.compressor.Flush(); err != nil {
+			logx.Error(err)
+		}
+	}
+
+	if flusher, ok := w.writer.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *compressResponseWriter) Header() http.Header {
+	return w.writer.Header()
+}
+
+// Hijack implements the http.Hijacker interface.
+// This expands the Response to fulfill http.Hijacker if the underlying http.ResponseWriter supports it.
+func (w *compressResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacked, ok := w.writer.(http.Hijacker); ok {
+		w.hijacked = true
+		return hijacked.Hijack()
+	}
+
+	return nil, nil, errors.New("server doesn't support hijacking")
+}
+
+func (w *compressResponseWriter) Write(p []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.decided {
+		if w.compressor != nil {
+			return w.compressor.Write(p)
+		}
+
+		return w.writer.Write(p)
+	}
+
+	n, _ := w.buf.Write(p)
+	if w.buf.Len() >= w.minSize {
+		if err := w.decide(false); err != nil {
+			return 0, err
+		}
+	}
+
+	return n, nil
+}
+
+func (w *compressResponseWriter) WriteHeader(code int) {
+	if w.code != 0 {
+		return
+	}
+
+	w.code = code
+	if !bodyAllowed(code) {
+		w.decided = true
+		w.writer.WriteHeader(code)
+	}
+}
+
+func (w *compressResponseWriter) close() {
+	if w.hijacked {
+		return
+	}
+
+	if !w.decided {
+		if w.code == 0 {
+			return
+		}
+
+		if err := w.decide(false); err != nil {
+			logx.Error(err)
+			return
+		}
+	}
+
+	if w.compressor != nil {
+		if err := w.compressor.Close(); err != nil {
+			logx.Error(err)
+		}
+		w.compressor.Reset(nil)
+		compressorPools[w.encoding].Put(w.compressor)
+		w.compressor = nil
+	}
+}
+
+// decide decides to compress the response or not, writes the header and the buffered data.
+// flushing means the response is being flushed, and might be streamed in small pieces.
+func (w *compressResponseWriter) decide(flushing bool) error {
+	w.decided = true
+	if w.code == 0 {
+		w.code = http.StatusOK
+	}
+
+	header := w.writer.Header()
+	if len(header.Get(httpx.ContentType)) == 0 && w.buf.Len() > 0 {
+		// detect the content type before compressing, otherwise it's detected as gzipped data.
+		header.Set(httpx.ContentType, http.DetectContentType(w.buf.Bytes()))
+	}
+
+	if w.compressible() {
+		addVary(header)
+		size := w.buf.Len()
+		if len(w.encoding) > 0 && (size >= w.minSize || flushing && size > 0) {
+			w.startCompressing(header)
+		}
+	}
+
+	w.writer.WriteHeader(w.code)
+	if w.buf.Len() == 0 {
+		return nil
+	}
+
+	var err error
+	if w.compressor != nil {
+		_, err = w.compressor.Write(w.buf.Bytes())
+	} else {
+		_, err = w.writer.Write(w.buf.Bytes())
+	}
+	w.buf.Reset()
+	return err
+}
+
+func (w *compressResponseWriter) compressible() bool {
+	header := w.writer.Header()
+	if len(header.Get(httpx.ContentEncoding)) > 0 {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(header.Get(httpx.ContentType))
+	if err != nil {
+		return false
+	}
+
+	for _, tp := range w.types {
+		if tp == mediaType {
+			return true
+		}
+		if strings.HasSuffix(tp, wildcardMediaType) &&
+			strings.HasPrefix(mediaType, strings.TrimSuffix(tp, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (w *compressResponseWriter) startCompressing(header http.Header) {
+	header.Set(httpx.ContentEncoding, w.encoding)
+	header.Del(contentLength)
+	if etag := header.Get(etagHeader); len(etag) > 0 && !strings.HasPrefix(etag, weakEtagPrefix) {
+		header.Set(etagHeader, weakEtagPrefix+etag)
+	}
+
+	w.compressor = compressorPools[w.encoding].Get().(compressor)
+	w.compressor.Reset(w.writer)
+}
+
+func addVary(header http.Header) {
+	for _, vary := range header.Values(varyHeader) {
+		for _, val := range strings.Split(vary, ",") {
+			if strings.EqualFold(strings.TrimSpace(val), acceptEncoding) {
+				return
+			}
+		}
+	}
+
+	header.Add(varyHeader, acceptEncoding)
+}
+
+func bodyAllowed(code int) bool {
+	return code >= http.StatusOK && code != http.StatusNoContent && code != http.StatusNotModified
+}
+
+// negotiateEncoding returns the encoding in encodings with the highest quality in accept,
+// the former one is preferred if the qualities are the same.
+func negotiateEncoding(accept string, encodings []string) string {
+	if len(accept) == 0 {
+		return ""
+	}
+
+	qualities := make(map[string]float64)
+	for _, item := range strings.Split(accept, ",") {
+		parts := strings.Split(item, ";")
+		encoding := strings.ToLower(strings.TrimSpace(parts[0]))
+		if len(encoding) == 0 {
+			continue
+		}
+
+		quality := 1.0
+		for _, param := range parts[1:] {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, qualityParam) {
+				continue
+			}
+
+			if q, err := strconv.ParseFloat(param[len(qualityParam):], 64); err == nil {
+				quality = q
+			}
+		}
+		qualities[encoding] = quality
+	}
+
+	var best string
+	var bestQuality float64
+	for _, encoding := range encodings {
+		quality, ok := qualities[encoding]
+		if !ok {
+			quality, ok = qualities[anyEncoding]
+		}
+		if ok && quality > bestQuality {
+			best = encoding
+			bestQuality = quality
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,229 @@
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func TestCompressHandler(t *testing.T) {
+	body := strings.Repeat("hello world ", 200)
+	tests := []struct {
+		name     string
+		accept   string
+		tp       string
+		body     string
+		code     int
+		encoding string
+		vary     bool
+	}{
+		{
+			name:     "gzip",
+			accept:   "gzip",
+			tp:       "application/json",
+			body:     body,
+			encoding: gzipEncoding,
+			vary:     true,
+		},
+		{
+			name:     "br preferred",
+			accept:   "gzip, deflate, br",
+			tp:       "application/json; charset=utf-8",
+			body:     body,
+			encoding: brotliEncoding,
+			vary:     true,
+		},
+		{
+			name:     "quality",
+			accept:   "br;q=0.5, deflate",
+			tp:       "text/plain",
+			body:     body,
+			encoding: deflateEncoding,
+			vary:     true,
+		},
+		{
+			name:     "any",
+			accept:   "*",
+			tp:       "text/html",
+			body:     body,
+			encoding: brotliEncoding,
+			vary:     true,
+		},
+		{
+			name:   "not accepted",
+			accept: "br;q=0, gzip;q=0",
+			tp:     "text/html",
+			body:   body,
+			vary:   true,
+		},
+		{
+			name: "no accept encoding",
+			tp:   "text/html",
+			body: body,
+			vary: true,
+		},
+		{
+			name:   "too small",
+			accept: "gzip",
+			tp:     "application/json",
+			body:   "{}",
+			vary:   true,
+		},
+		{
+			name:   "not allowed type",
+			accept: "gzip",
+			tp:     "image/png",
+			body:   body,
+		},
+		{
+			name:     "detected type",
+			accept:   "gzip",
+			body:     "<html>" + body + "</html>",
+			vary:     true,
+			encoding: gzipEncoding,
+		},
+		{
+			name:   "no content",
+			accept: "gzip",
+			tp:     "application/json",
+			code:   http.StatusNoContent,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			handler := CompressHandler(0, nil, nil)(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if len(test.tp) > 0 {
+						w.Header().Set(httpx.ContentType, test.tp)
+					}
+					w.Header().Set(etagHeader, `"abc"`)
+					if test.code > 0 {
+						w.WriteHeader(test.code)
+					}
+					if len(test.body) > 0 {
+						// write in pieces to check the buffering
+						half := len(test.body) / 2
+						io.WriteString(w, test.body[:half])
+						io.WriteString(w, test.body[half:])
+					}
+				}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			if len(test.accept) > 0 {
+				req.Header.Set(acceptEncoding, test.accept)
+			}
+			resp := httptest.NewRecorder()
+			handler.ServeHTTP(resp, req)
+
+			if test.code > 0 {
+				assert.Equal(t, test.code, resp.Code)
+			} else {
+				assert.Equal(t, http.StatusOK, resp.Code)
+			}
+			assert.Equal(t, test.encoding, resp.Header().Get(httpx.ContentEncoding))
+			if test.vary {
+				assert.Equal(t, acceptEncoding, resp.Header().Get(varyHeader))
+			} else {
+				assert.Empty(t, resp.Header().Get(varyHeader))
+			}
+			if len(test.encoding) > 0 {
+				assert.Equal(t, `W/"abc"`, resp.Header().Get(etagHeader))
+			} else {
+				assert.Equal(t, `"abc"`, resp.Header().Get(etagHeader))
+			}
+			assert.Equal(t, test.body, decompress(t, test.encoding, resp.Body.Bytes()))
+		})
+	}
+}
+
+func TestCompressHandler_Flush(t *testing.T) {
+	handler := CompressHandler(1024, []string{"text/*"}, []string{"gzip"})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(httpx.ContentType, httpx.TextEventStream)
+			w.Header().Set(varyHeader, "Origin, accept-encoding")
+			io.WriteString(w, "data: first\n\n")
+			w.(http.Flusher).Flush()
+			io.WriteString(w, "data: second\n\n")
+			w.(http.Flusher).Flush()
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Set(acceptEncoding, "gzip")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.True(t, resp.Flushed)
+	assert.Equal(t, gzipEncoding, resp.Header().Get(httpx.ContentEncoding))
+	assert.Equal(t, []string{"Origin, accept-encoding"}, resp.Header().Values(varyHeader))
+	assert.Equal(t, "data: first\n\ndata: second\n\n", decompress(t, gzipEncoding, resp.Body.Bytes()))
+}
+
+func TestCompressHandler_EncodedAlready(t *testing.T) {
+	handler := CompressHandler(1, nil, []string{"gzip", "zstd"})(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set(httpx.ContentType, "application/json")
+			w.Header().Set(httpx.ContentEncoding, "custom")
+			io.WriteString(w, "{}")
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	req.Header.Set(acceptEncoding, "gzip")
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, "custom", resp.Header().Get(httpx.ContentEncoding))
+	assert.Equal(t, "{}", resp.Body.String())
+}
+
+func TestCompressHandler_Hijack(t *testing.T) {
+	handler := CompressHandler(0, nil, nil)(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			_, _, err := w.(http.Hijacker).Hijack()
+			assert.NotNil(t, err)
+		}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, 0, resp.Body.Len())
+}
+
+func TestNegotiateEncoding(t *testing.T) {
+	encodings := []string{brotliEncoding, gzipEncoding}
+	assert.Equal(t, "", negotiateEncoding("", encodings))
+	assert.Equal(t, "", negotiateEncoding("identity", encodings))
+	assert.Equal(t, gzipEncoding, negotiateEncoding("GZIP", encodings))
+	assert.Equal(t, gzipEncoding, negotiateEncoding("br;q=0.1, gzip;q=0.8", encodings))
+	assert.Equal(t, gzipEncoding, negotiateEncoding("br;q=0, *", encodings))
+	assert.Equal(t, brotliEncoding, negotiateEncoding("gzip, br", encodings))
+}
+
+func decompress(t *testing.T, encoding string, data []byte) string {
+	var reader io.Reader
+	var err error
+	switch encoding {
+	case gzipEncoding:
+		reader, err = gzip.NewReader(bytes.NewReader(data))
+	case deflateEncoding:
+		reader, err = zlib.NewReader(bytes.NewReader(data))
+	case brotliEncoding:
+		reader = brotli.NewReader(bytes.NewReader(data))
+	default:
+		return string(data)
+	}
+	assert.Nil(t, err)
+
+	content, err := ioutil.ReadAll(reader)
+	assert.Nil(t, err)
+	return string(content)
+}
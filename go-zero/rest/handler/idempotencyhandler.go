@@ -0,0 +1,253 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/rest/internal"
+)
+
+const (
+	// IdempotencyKeyHeader is the header that carries the idempotency key of a request.
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	idempotencyKeyPrefix     = "rest:idempotency:"
+	idempotencyProcessing    = "processing:"
+	maxIdempotencyKeyLen     = 255
+	// the max response body stored for replaying, the larger ones are replayed without body.
+	maxIdempotentBodySize = 1 << 20
+)
+
+type (
+	idempotentResponse struct {
+		// the fingerprint of the request, to reject the reused keys with different requests
+		Fingerprint string      `json:"fingerprint"`
+		Code        int         `json:"code"`
+		Header      http.Header `json:"header"`
+		Body        []byte      `json:"body"`
+	}
+
+	idempotentResponseWriter struct {
+		http.ResponseWriter
+		code     int
+		header   http.Header
+		body     bytes.Buffer
+		overflow bool
+	}
+)
+
+// IdempotencyHandler returns a middleware that processes the requests with the same
+// Idempotency-Key header from the same user only once, the users are returned by userFunc.
+// The concurrent duplicates are rejected with 409 while the first one is in process,
+// which holds the lock at most lockExpire. The completed responses are stored in store,
+// and replayed for the duplicates in expire. The responses with 5xx are not stored,
+// to let the clients retry, and the bodies larger than 1MB are not stored.
+// The reused keys with different methods, paths or bodies are rejected with 422.
+// The requests without Idempotency-Key, or from the users not identified, are not affected.
+func IdempotencyHandler(name string, store *redis.Redis, expire, lockExpire time.Duration,
+	userFunc func(r *http.Request) string) func(http.Handler) http.Handler {
+	expireSeconds := durationSeconds(expire)
+	lockSeconds := durationSeconds(lockExpire)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			idemKey := r.Header.Get(IdempotencyKeyHeader)
+			if len(idemKey) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(idemKey) > maxIdempotencyKeyLen {
+				internal.Errorf(r, "idempotency key is too long, rejected with code %d", http.StatusBadRequest)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			user := userFunc(r)
+			if len(user) == 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			fingerprint, err := requestFingerprint(r)
+			if err != nil {
+				internal.Errorf(r, "fail to read request body: %s, rejected with code %d",
+					err, http.StatusBadRequest)
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+
+			ctx := r.Context()
+			key := idempotencyKeyPrefix + name + ":" + user + ":" + idemKey
+			locked, err := store.SetnxExCtx(ctx, key, idempotencyProcessing+fingerprint, lockSeconds)
+			if err != nil {
+				logx.WithContext(ctx).Errorf("fail to use idempotency store: %s, process without it", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !locked {
+				replayIdempotentResponse(w, r, store, key, fingerprint)
+				return
+			}
+
+			cw := &idempotentResponseWriter{ResponseWriter: w}
+			completed := false
+			defer func() {
+				// release the lock if panicked or failed, to let the clients retry
+				if !completed {
+					if _, err := store.DelCtx(ctx, key); err != nil {
+						logx.WithContext(ctx).Errorf("fail to release idempotency key: %s", err)
+					}
+				}
+			}()
+
+			next.ServeHTTP(cw, r)
+			if cw.code == 0 {
+				cw.code = http.StatusOK
+			}
+			if cw.code >= http.StatusInternalServerError {
+				return
+			}
+
+			content, err := json.Marshal(idempotentResponse{
+				Fingerprint: fingerprint,
+				Code:        cw.code,
+				Header:      cw.header,
+				Body:        cw.body.Bytes(),
+			})
+			if err != nil {
+				logx.WithContext(ctx).Errorf("fail to marshal idempotent response: %s", err)
+				return
+			}
+
+			if err := store.SetexCtx(ctx, key, string(content), expireSeconds); err != nil {
+				logx.WithContext(ctx).Errorf("fail to store idempotent response: %s", err)
+				return
+			}
+
+			completed = true
+		})
+	}
+}
+
+func (w *idempotentResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *idempotentResponseWriter) Write(data []byte) (int, error) {
+	if w.code == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if !w.overflow {
+		if w.body.Len()+len(data) > maxIdempotentBodySize {
+			w.overflow = true
+			w.body.Reset()
+		} else {
+			w.body.Write(data)
+		}
+	}
+
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *idempotentResponseWriter) WriteHeader(code int) {
+	if w.code == 0 {
+		w.code = code
+		w.header = w.ResponseWriter.Header().Clone()
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func durationSeconds(duration time.Duration) int {
+	seconds := int(duration / time.Second)
+	if seconds <= 0 {
+		return 1
+	}
+
+	return seconds
+}
+
+func rejectMismatchedRequest(w http.ResponseWriter, r *http.Request) {
+	internal.Errorf(r, "idempotency key reused with a different request, rejected with code %d",
+		http.StatusUnprocessableEntity)
+	w.WriteHeader(http.StatusUnprocessableEntity)
+}
+
+func replayIdempotentResponse(w http.ResponseWriter, r *http.Request, store *redis.Redis,
+	key, fingerprint string) {
+	val, err := store.GetCtx(r.Context(), key)
+	if err != nil {
+		internal.Errorf(r, "fail to get idempotent response: %s", err)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+
+	// still in process, or just expired
+	if strings.HasPrefix(val, idempotencyProcessing) || len(val) == 0 {
+		if len(val) > 0 && val != idempotencyProcessing+fingerprint {
+			rejectMismatchedRequest(w, r)
+			return
+		}
+
+		internal.Errorf(r, "duplicate request in process, rejected with code %d", http.StatusConflict)
+		w.WriteHeader(http.StatusConflict)
+		return
+	}
+
+	var resp idempotentResponse
+	if err := json.Unmarshal([]byte(val), &resp); err != nil {
+		internal.Errorf(r, "bad idempotent response: %s", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+
+	if resp.Fingerprint != fingerprint {
+		rejectMismatchedRequest(w, r)
+		return
+	}
+
+	header := w.Header()
+	for k, v := range resp.Header {
+		// keep the headers set by the outer middlewares, like X-Request-Id
+		if _, ok := header[k]; !ok {
+			header[k] = v
+		}
+	}
+	header.Set(idempotentReplayedHeader, "true")
+	w.WriteHeader(resp.Code)
+	if _, err := w.Write(resp.Body); err != nil {
+		logx.WithContext(r.Context()).Errorf("write response failed, error: %s", err)
+	}
+}
+
+// requestFingerprint returns the hash of the method, the path and the body of r,
+// the body is read and restored to be read again.
+func requestFingerprint(r *http.Request) (string, error) {
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(r.Body); err != nil {
+			return "", err
+		}
+		r.Body = ioutil.NopCloser(bytes.NewReader(body))
+	}
+
+	hash := sha256.New()
+	hash.Write([]byte(r.Method))
+	hash.Write([]byte{'\n'})
+	hash.Write([]byte(r.URL.RequestURI()))
+	hash.Write([]byte{'\n'})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
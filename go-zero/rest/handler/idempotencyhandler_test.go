@@ -0,0 +1,244 @@
+package handler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/core/stores/redis/redistest"
+)
+
+func TestIdempotencyHandler(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	var count int32
+	handler := IdempotencyHandler("create", store, time.Minute, time.Second, userFromHeader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt32(&count, 1)
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("X-Request-Id", "first")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"order":` + strconv.Itoa(int(n)) + `}`))
+		}))
+
+	serve := func(user, key string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodPost, "/", nil)
+		r.Header.Set("X-User", user)
+		if len(key) > 0 {
+			r.Header.Set(IdempotencyKeyHeader, key)
+		}
+		w := httptest.NewRecorder()
+		w.Header().Set("X-Request-Id", "current")
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	resp := serve("kevin", "foo")
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, `{"order":1}`, resp.Body.String())
+	assert.Empty(t, resp.Header().Get(idempotentReplayedHeader))
+
+	resp = serve("kevin", "foo")
+	assert.Equal(t, http.StatusCreated, resp.Code)
+	assert.Equal(t, `{"order":1}`, resp.Body.String())
+	assert.Equal(t, "application/json", resp.Header().Get("Content-Type"))
+	assert.Equal(t, "current", resp.Header().Get("X-Request-Id"))
+	assert.Equal(t, "true", resp.Header().Get(idempotentReplayedHeader))
+
+	// keys are isolated by users
+	resp = serve("anyone", "foo")
+	assert.Equal(t, `{"order":2}`, resp.Body.String())
+
+	// requests without keys are not deduplicated
+	resp = serve("kevin", "")
+	assert.Equal(t, `{"order":3}`, resp.Body.String())
+	resp = serve("kevin", "")
+	assert.Equal(t, `{"order":4}`, resp.Body.String())
+
+	// requests from the users not identified are not deduplicated
+	resp = serve("", "foo")
+	assert.Equal(t, `{"order":5}`, resp.Body.String())
+	resp = serve("", "foo")
+	assert.Equal(t, `{"order":6}`, resp.Body.String())
+
+	resp = serve("kevin", strings.Repeat("a", maxIdempotencyKeyLen+1))
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&count))
+}
+
+func TestIdempotencyHandler_Mismatched(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	var count int32
+	handler := IdempotencyHandler("create", store, time.Minute, time.Minute, userFromHeader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if atomic.AddInt32(&count, 1) == 1 {
+				close(started)
+				<-done
+			}
+			body, err := ioutil.ReadAll(r.Body)
+			assert.Nil(t, err)
+			_, _ = w.Write(body)
+		}))
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, path, strings.NewReader(body))
+		r.Header.Set("X-User", "kevin")
+		r.Header.Set(IdempotencyKeyHeader, "foo")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- serve(http.MethodPost, "/orders", `{"amount":1}`)
+	}()
+
+	<-started
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(http.MethodPost, "/orders", `{"amount":2}`).Code)
+	assert.Equal(t, http.StatusConflict, serve(http.MethodPost, "/orders", `{"amount":1}`).Code)
+	close(done)
+	assert.Equal(t, `{"amount":1}`, (<-first).Body.String())
+
+	resp := serve(http.MethodPost, "/orders", `{"amount":1}`)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, `{"amount":1}`, resp.Body.String())
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(http.MethodPost, "/orders", `{"amount":2}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(http.MethodPut, "/orders", `{"amount":1}`).Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, serve(http.MethodPost, "/refunds", `{"amount":1}`).Code)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+}
+
+func TestIdempotencyHandler_InProcess(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	handler := IdempotencyHandler("create", store, time.Minute, time.Minute, userFromHeader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-done
+			_, _ = w.Write([]byte("ok"))
+		}))
+
+	first := make(chan *httptest.ResponseRecorder)
+	go func() {
+		first <- serveIdempotent(handler, "foo")
+	}()
+
+	<-started
+	assert.Equal(t, http.StatusConflict, serveIdempotent(handler, "foo").Code)
+	close(done)
+	resp := <-first
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "ok", resp.Body.String())
+
+	resp = serveIdempotent(handler, "foo")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "ok", resp.Body.String())
+}
+
+func TestIdempotencyHandler_Failed(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	var count int32
+	handler := IdempotencyHandler("create", store, time.Minute, time.Minute, userFromHeader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch atomic.AddInt32(&count, 1) {
+			case 1:
+				w.WriteHeader(http.StatusInternalServerError)
+			case 2:
+				panic("oops")
+			default:
+				_, _ = w.Write([]byte("ok"))
+			}
+		}))
+
+	// the failed requests are retryable
+	assert.Equal(t, http.StatusInternalServerError, serveIdempotent(handler, "foo").Code)
+	assert.Panics(t, func() {
+		serveIdempotent(handler, "foo")
+	})
+	assert.Equal(t, "ok", serveIdempotent(handler, "foo").Body.String())
+	assert.Equal(t, "ok", serveIdempotent(handler, "foo").Body.String())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&count))
+}
+
+func TestIdempotencyHandler_LargeBody(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	body := strings.Repeat("a", maxIdempotentBodySize+1)
+	handler := IdempotencyHandler("create", store, time.Minute, time.Minute, userFromHeader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			_, _ = w.Write([]byte(body[:maxIdempotentBodySize]))
+			_, _ = w.Write([]byte(body[maxIdempotentBodySize:]))
+		}))
+
+	assert.Equal(t, body, serveIdempotent(handler, "foo").Body.String())
+	resp := serveIdempotent(handler, "foo")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Empty(t, resp.Body.String())
+}
+
+func TestIdempotencyHandler_RedisUnavailable(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	store := redis.New(s.Addr())
+	s.Close()
+
+	var count int32
+	handler := IdempotencyHandler("create", store, time.Minute, 0, userFromHeader)(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			atomic.AddInt32(&count, 1)
+		}))
+
+	assert.Equal(t, http.StatusOK, serveIdempotent(handler, "foo").Code)
+	assert.Equal(t, http.StatusOK, serveIdempotent(handler, "foo").Code)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
+
+func TestReplayIdempotentResponse_Bad(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	assert.Nil(t, store.Set("key", "bad"))
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	w := httptest.NewRecorder()
+	replayIdempotentResponse(w, r, store, "key", "")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func serveIdempotent(handler http.Handler, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("X-User", "kevin")
+	r.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	return w
+}
+
+func userFromHeader(r *http.Request) string {
+	return r.Header.Get("X-User")
+}
@@ -7,6 +7,8 @@ import (
 )
 
 // MaxBytesHandler returns a middleware that limit reading of http request body.
+// The requests with larger Content-Length are rejected, and the bodies without
+// Content-Length, like the chunked uploads, fail on reading beyond the limit.
 func MaxBytesHandler(n int64) func(http.Handler) http.Handler {
 	if n <= 0 {
 		return func(next http.Handler) http.Handler {
@@ -21,6 +23,7 @@ func MaxBytesHandler(n int64) func(http.Handler) http.Handler {
 					n, r.ContentLength, http.StatusRequestEntityTooLarge)
 				w.WriteHeader(http.StatusRequestEntityTooLarge)
 			} else {
+				r.Body = http.MaxBytesReader(w, r.Body, n)
 				next.ServeHTTP(w, r)
 			}
 		})
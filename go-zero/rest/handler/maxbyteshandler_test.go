@@ -2,6 +2,7 @@ package handler
 
 import (
 	"bytes"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -25,6 +26,20 @@ func TestMaxBytesHandler(t *testing.T) {
 	assert.Equal(t, http.StatusOK, resp.Code)
 }
 
+func TestMaxBytesHandlerChunked(t *testing.T) {
+	maxb := MaxBytesHandler(10)
+	handler := maxb(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := ioutil.ReadAll(r.Body)
+		assert.NotNil(t, err)
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost",
+		bytes.NewBufferString("123456789012345"))
+	req.ContentLength = -1
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+}
+
 func TestMaxBytesHandlerNoLimit(t *testing.T) {
 	maxb := MaxBytesHandler(-1)
 	handler := maxb(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
@@ -0,0 +1,195 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/limit"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/rest/internal"
+)
+
+const (
+	rateLimitKeyPrefix     = "rest:ratelimit:"
+	rateLimitLimitHeader   = "X-RateLimit-Limit"
+	rateLimitRemainHeader  = "X-RateLimit-Remaining"
+	rateLimitResetHeader   = "X-RateLimit-Reset"
+	retryAfterHeader       = "Retry-After"
+	defaultRateLimitPeriod = 1
+	// the max keys counted in process, to avoid unbounded memory usage
+	maxLocalRateLimitKeys = 100000
+	rateLimitPingInterval = time.Millisecond * 100
+)
+
+// RateLimitHandler returns a middleware that allows quota requests in every period seconds
+// for each key returned by keyFunc, the periods are aligned to make the reset time predictable.
+// The requests are counted in store, or in process if store is nil or not available.
+// The rejected requests are responded with 429 and Retry-After header.
+func RateLimitHandler(name string, period, quota int, store *redis.Redis,
+	keyFunc func(r *http.Request) string) func(http.Handler) http.Handler {
+	if quota <= 0 {
+		return func(next http.Handler) http.Handler {
+			return next
+		}
+	}
+
+	if period <= 0 {
+		period = defaultRateLimitPeriod
+	}
+
+	limiter := newRateLimiter(rateLimitKeyPrefix+name+":", period, quota, store)
+	quotaValue := strconv.Itoa(quota)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := keyFunc(r)
+			state, remaining := limiter.take(r, key)
+
+			header := w.Header()
+			reset := strconv.Itoa(int(alignedReset(period) / time.Second))
+			header.Set(rateLimitLimitHeader, quotaValue)
+			header.Set(rateLimitResetHeader, reset)
+
+			if state == limit.OverQuota {
+				header.Set(rateLimitRemainHeader, "0")
+				header.Set(retryAfterHeader, reset)
+				internal.Errorf(r, "rate limit exceeded for key %q, rejected with code %d",
+					key, http.StatusTooManyRequests)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			header.Set(rateLimitRemainHeader, strconv.Itoa(remaining))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// alignedReset returns the duration before the current period ends,
+// aligned in the same way as limit.Align does.
+func alignedReset(period int) time.Duration {
+	now := time.Now()
+	_, offset := now.Zone()
+	unix := now.Unix() + int64(offset)
+	return time.Duration(int64(period)-unix%int64(period)) * time.Second
+}
+
+// rateLimiter counts the requests in store, and rescues with the in-process counts
+// if store is nil or not available.
+type rateLimiter struct {
+	store          *redis.Redis
+	remote         *limit.PeriodLimit
+	local          *localPeriodLimit
+	rescueLock     sync.Mutex
+	redisAlive     uint32
+	monitorStarted bool
+}
+
+func newRateLimiter(keyPrefix string, period, quota int, store *redis.Redis) *rateLimiter {
+	limiter := &rateLimiter{
+		store:      store,
+		local:      newLocalPeriodLimit(period, quota),
+		redisAlive: 1,
+	}
+	if store != nil {
+		limiter.remote = limit.NewPeriodLimit(period, quota, store, keyPrefix, limit.Align())
+	}
+
+	return limiter
+}
+
+// take counts the request of key, and returns the permit state and the remaining permits.
+func (l *rateLimiter) take(r *http.Request, key string) (int, int) {
+	state, remaining := l.local.take(key)
+	if l.remote == nil || atomic.LoadUint32(&l.redisAlive) == 0 {
+		return state, remaining
+	}
+
+	remoteState, remoteRemaining, err := l.remote.TakeWithRemaining(key)
+	if err != nil {
+		logx.WithContext(r.Context()).Errorf(
+			"fail to use rate limiter: %s, use in-process limiter for rescue", err)
+		l.startMonitor()
+		return state, remaining
+	}
+
+	return remoteState, remoteRemaining
+}
+
+func (l *rateLimiter) startMonitor() {
+	l.rescueLock.Lock()
+	defer l.rescueLock.Unlock()
+
+	if l.monitorStarted {
+		return
+	}
+
+	l.monitorStarted = true
+	atomic.StoreUint32(&l.redisAlive, 0)
+
+	go l.waitForRedis()
+}
+
+func (l *rateLimiter) waitForRedis() {
+	ticker := time.NewTicker(rateLimitPingInterval)
+	defer func() {
+		ticker.Stop()
+		l.rescueLock.Lock()
+		l.monitorStarted = false
+		l.rescueLock.Unlock()
+	}()
+
+	for range ticker.C {
+		if l.store.Ping() {
+			atomic.StoreUint32(&l.redisAlive, 1)
+			return
+		}
+	}
+}
+
+// localPeriodLimit counts the requests in the current aligned period in process.
+// It's always counted to be ready for rescuing when the store is not available.
+type localPeriodLimit struct {
+	period int64
+	quota  int
+	lock   sync.Mutex
+	window int64
+	counts map[string]int
+}
+
+func newLocalPeriodLimit(period, quota int) *localPeriodLimit {
+	return &localPeriodLimit{
+		period: int64(period),
+		quota:  quota,
+		counts: make(map[string]int),
+	}
+}
+
+// take counts the request of key, and returns the permit state and the remaining permits.
+// The new keys are rejected while maxLocalRateLimitKeys keys are counted in the current period.
+func (l *localPeriodLimit) take(key string) (int, int) {
+	now := time.Now()
+	_, offset := now.Zone()
+	window := (now.Unix() + int64(offset)) / l.period
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if window != l.window {
+		l.window = window
+		l.counts = make(map[string]int)
+	}
+
+	count, ok := l.counts[key]
+	if !ok && len(l.counts) >= maxLocalRateLimitKeys {
+		return limit.OverQuota, 0
+	}
+
+	count++
+	l.counts[key] = count
+	return limit.State(count, l.quota), limit.Remaining(count, l.quota)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/limit"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/core/stores/redis/redistest"
+)
+
+func TestRateLimitHandler(t *testing.T) {
+	store, clean, err := redistest.CreateRedis()
+	assert.Nil(t, err)
+	defer clean()
+
+	testRateLimitHandler(t, store)
+}
+
+func TestRateLimitHandler_Local(t *testing.T) {
+	testRateLimitHandler(t, nil)
+}
+
+func TestRateLimitHandler_RedisUnavailable(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	store := redis.New(s.Addr())
+	s.Close()
+
+	testRateLimitHandler(t, store)
+}
+
+func TestRateLimitHandler_RedisRescue(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer s.Close()
+
+	const quota = 3
+	limiter := newRateLimiter("rest:ratelimit:any:", 3600, quota, redis.New(s.Addr()))
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	state, remaining := limiter.take(req, "kevin")
+	assert.Equal(t, limit.Allowed, state)
+	assert.Equal(t, quota-1, remaining)
+
+	s.Close()
+	state, _ = limiter.take(req, "kevin")
+	assert.Equal(t, limit.Allowed, state)
+	assert.Equal(t, uint32(0), atomic.LoadUint32(&limiter.redisAlive))
+
+	// counted in process without calling redis while redis is down
+	state, remaining = limiter.take(req, "kevin")
+	assert.Equal(t, limit.HitQuota, state)
+	assert.Equal(t, 0, remaining)
+
+	assert.Nil(t, s.Restart())
+	assert.Eventually(t, func() bool {
+		return atomic.LoadUint32(&limiter.redisAlive) == 1
+	}, time.Second, rateLimitPingInterval)
+}
+
+func TestLocalPeriodLimit_MaxKeys(t *testing.T) {
+	const quota = 3
+	l := newLocalPeriodLimit(3600, quota)
+	state, remaining := l.take("kevin")
+	assert.Equal(t, limit.Allowed, state)
+	assert.Equal(t, quota-1, remaining)
+	for i := 1; i < maxLocalRateLimitKeys; i++ {
+		l.take(strconv.Itoa(i))
+	}
+
+	state, remaining = l.take("anyone")
+	assert.Equal(t, limit.OverQuota, state)
+	assert.Equal(t, 0, remaining)
+
+	// the counted keys are kept
+	state, remaining = l.take("kevin")
+	assert.Equal(t, limit.Allowed, state)
+	assert.Equal(t, quota-2, remaining)
+	state, remaining = l.take("kevin")
+	assert.Equal(t, limit.HitQuota, state)
+	assert.Equal(t, 0, remaining)
+}
+
+func TestRateLimitHandler_NoQuota(t *testing.T) {
+	rateLimit := RateLimitHandler("any", 1, 0, nil, func(r *http.Request) string {
+		return "key"
+	})
+	handler := rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	for i := 0; i < 10; i++ {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Empty(t, resp.Header().Get(rateLimitLimitHeader))
+	}
+}
+
+func testRateLimitHandler(t *testing.T, store *redis.Redis) {
+	const quota = 3
+	rateLimit := RateLimitHandler("GET/orders", 3600, quota, store, func(r *http.Request) string {
+		return r.Header.Get("X-User")
+	})
+	handler := rateLimit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(user string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+		req.Header.Set("X-User", user)
+		resp := httptest.NewRecorder()
+		handler.ServeHTTP(resp, req)
+		return resp
+	}
+
+	for i := 0; i < quota-1; i++ {
+		resp := serve("kevin")
+		assert.Equal(t, http.StatusOK, resp.Code)
+		assert.Equal(t, "3", resp.Header().Get(rateLimitLimitHeader))
+		assert.NotEmpty(t, resp.Header().Get(rateLimitResetHeader))
+		assert.Equal(t, strconv.Itoa(quota-i-1), resp.Header().Get(rateLimitRemainHeader))
+	}
+
+	resp := serve("kevin")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get(rateLimitRemainHeader))
+
+	resp = serve("kevin")
+	assert.Equal(t, http.StatusTooManyRequests, resp.Code)
+	assert.Equal(t, "0", resp.Header().Get(rateLimitRemainHeader))
+	assert.Equal(t, resp.Header().Get(rateLimitResetHeader), resp.Header().Get(retryAfterHeader))
+
+	resp = serve("anyone")
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, "2", resp.Header().Get(rateLimitRemainHeader))
+}
@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/requestid"
+)
+
+// RequestIdHandler returns a middleware that keeps the request id in the context.
+// The request id is taken from the X-Request-Id header if it's valid, otherwise a new one
+// is generated. The request id is echoed in the response header, forwarded to the rpc
+// services by the zrpc clients, and written in the logs by logx.WithContext.
+func RequestIdHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.HeaderKey)
+		if !requestid.IsValid(id) {
+			id = requestid.New()
+		}
+
+		w.Header().Set(requestid.HeaderKey, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/requestid"
+)
+
+func TestRequestIdHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		generate bool
+	}{
+		{
+			name:     "absent",
+			generate: true,
+		},
+		{
+			name:   "accepted",
+			header: "abc-123",
+		},
+		{
+			name:     "invalid",
+			header:   "abc\n123",
+			generate: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var id string
+			h := RequestIdHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				id = requestid.FromContext(r.Context())
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", http.NoBody)
+			if len(test.header) > 0 {
+				req.Header.Set(requestid.HeaderKey, test.header)
+			}
+			resp := httptest.NewRecorder()
+			h.ServeHTTP(resp, req)
+
+			assert.Equal(t, id, resp.Header().Get(requestid.HeaderKey))
+			if test.generate {
+				assert.True(t, requestid.IsValid(id))
+				assert.NotEqual(t, test.header, id)
+			} else {
+				assert.Equal(t, test.header, id)
+			}
+		})
+	}
+}
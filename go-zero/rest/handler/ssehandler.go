@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/internal"
+)
+
+const (
+	cacheControl      = "Cache-Control"
+	cacheControlValue = "no-cache"
+	connection        = "Connection"
+	connectionValue   = "keep-alive"
+	accelBuffering    = "X-Accel-Buffering"
+	heartbeatComment  = ": ping\n\n"
+)
+
+var errNotStreaming = errors.New("not streaming")
+
+// SSEHandler returns a middleware that serves Server-Sent Events.
+// Every write is flushed to the client immediately, and a comment line is sent
+// every heartbeat to keep the connection alive through proxies.
+// The event stream headers are sent on the first Write or Flush, so that the errors
+// written by the inner handlers, like 401 by the authorization, reach the clients as is.
+// The streaming stops when the client disconnects, which cancels the request context.
+func SSEHandler(heartbeat time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			flusher, ok := w.(http.Flusher)
+			if !ok {
+				internal.Error(r, "streaming unsupported by response writer")
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			sw := &sseResponseWriter{
+				w:       w,
+				flusher: flusher,
+				done:    r.Context().Done(),
+			}
+			stop := make(chan struct{})
+			var wg sync.WaitGroup
+			if heartbeat > 0 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					sw.keepAlive(heartbeat, stop)
+				}()
+			}
+			defer func() {
+				close(stop)
+				wg.Wait()
+			}()
+
+			next.ServeHTTP(sw, r)
+		})
+	}
+}
+
+type sseResponseWriter struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	done    <-chan struct{}
+	lock    sync.Mutex
+	// the status code written, 0 if not written yet
+	code int
+}
+
+// Flush sends the event stream headers if not sent, and flushes the response writer.
+func (w *sseResponseWriter) Flush() {
+	w.lock.Lock()
+	w.writeHeader(http.StatusOK)
+	w.flusher.Flush()
+	w.lock.Unlock()
+}
+
+// Header returns the http header.
+func (w *sseResponseWriter) Header() http.Header {
+	return w.w.Header()
+}
+
+// Hijack implements the http.Hijacker interface.
+// This expands the Response to fulfill http.Hijacker if the underlying http.ResponseWriter supports it.
+func (w *sseResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacked, ok := w.w.(http.Hijacker); ok {
+		return hijacked.Hijack()
+	}
+
+	return nil, nil, errors.New("server doesn't support hijacking")
+}
+
+// Write writes bytes into w and flushes them to the client.
+// An io.ErrClosedPipe is returned if the client has gone.
+func (w *sseResponseWriter) Write(bytes []byte) (int, error) {
+	select {
+	case <-w.done:
+		return 0, io.ErrClosedPipe
+	default:
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	w.writeHeader(http.StatusOK)
+	n, err := w.w.Write(bytes)
+	if err != nil {
+		return n, err
+	}
+
+	w.flusher.Flush()
+	return n, nil
+}
+
+// WriteHeader writes the header with code, the event stream headers are only set on 2xx,
+// the later calls are ignored.
+func (w *sseResponseWriter) WriteHeader(code int) {
+	w.lock.Lock()
+	w.writeHeader(code)
+	w.lock.Unlock()
+}
+
+// heartbeat writes a comment line to keep the stream alive, only after the stream is opened.
+func (w *sseResponseWriter) heartbeat() error {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if w.code == 0 {
+		return nil
+	}
+	if !isStreaming(w.code) {
+		return errNotStreaming
+	}
+
+	if _, err := io.WriteString(w.w, heartbeatComment); err != nil {
+		return err
+	}
+
+	w.flusher.Flush()
+	return nil
+}
+
+// writeHeader must be called with the lock held.
+func (w *sseResponseWriter) writeHeader(code int) {
+	if w.code != 0 {
+		return
+	}
+
+	w.code = code
+	if isStreaming(code) {
+		header := w.w.Header()
+		header.Set(httpx.ContentType, httpx.TextEventStream)
+		header.Set(cacheControl, cacheControlValue)
+		header.Set(connection, connectionValue)
+		header.Set(accelBuffering, "no")
+	}
+	w.w.WriteHeader(code)
+}
+
+func (w *sseResponseWriter) keepAlive(heartbeat time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(heartbeat)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			select {
+			case <-w.done:
+				return
+			default:
+			}
+
+			if err := w.heartbeat(); err != nil {
+				return
+			}
+		case <-w.done:
+			return
+		case <-stop:
+			return
+		}
+	}
+}
+
+func isStreaming(code int) bool {
+	return code >= http.StatusOK && code < http.StatusMultipleChoices
+}
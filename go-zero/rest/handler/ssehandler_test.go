@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func TestSSEHandler(t *testing.T) {
+	sse := SSEHandler(time.Millisecond)
+	handler := sse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusAccepted)
+		assert.Nil(t, httpx.WriteEvent(w, httpx.Event{
+			Event: "status",
+			Data:  "paid",
+		}))
+		time.Sleep(time.Millisecond * 20)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, httpx.TextEventStream, resp.Header().Get(httpx.ContentType))
+	assert.Equal(t, cacheControlValue, resp.Header().Get(cacheControl))
+	assert.True(t, resp.Flushed)
+	body := resp.Body.String()
+	assert.True(t, strings.HasPrefix(body, "event: status\ndata: paid\n\n"), body)
+	assert.True(t, strings.Contains(body, heartbeatComment), body)
+}
+
+func TestSSEHandler_Error(t *testing.T) {
+	sse := SSEHandler(time.Millisecond)
+	handler := sse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		time.Sleep(time.Millisecond * 20)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusUnauthorized, resp.Code)
+	assert.NotEqual(t, httpx.TextEventStream, resp.Header().Get(httpx.ContentType))
+	assert.False(t, strings.Contains(resp.Body.String(), heartbeatComment))
+}
+
+func TestSSEHandler_LazyHeader(t *testing.T) {
+	sse := SSEHandler(time.Millisecond)
+	handler := sse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// no heartbeats before the stream is opened
+		time.Sleep(time.Millisecond * 10)
+		assert.Equal(t, 0, w.(*sseResponseWriter).code)
+		w.(http.Flusher).Flush()
+		time.Sleep(time.Millisecond * 10)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, httpx.TextEventStream, resp.Header().Get(httpx.ContentType))
+	assert.True(t, strings.HasPrefix(resp.Body.String(), heartbeatComment))
+}
+
+func TestSSEHandler_ClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	sse := SSEHandler(time.Minute)
+	handler := sse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cancel()
+		<-r.Context().Done()
+		_, err := io.WriteString(w, "data: gone\n\n")
+		assert.Equal(t, io.ErrClosedPipe, err)
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil).WithContext(ctx)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.False(t, strings.Contains(resp.Body.String(), "gone"))
+}
+
+func TestSSEHandler_NotFlusher(t *testing.T) {
+	sse := SSEHandler(time.Minute)
+	handler := sse(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fail()
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(notFlushWriter{resp}, req)
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+}
+
+type notFlushWriter struct {
+	w http.ResponseWriter
+}
+
+func (w notFlushWriter) Header() http.Header {
+	return w.w.Header()
+}
+
+func (w notFlushWriter) Write(bs []byte) (int, error) {
+	return w.w.Write(bs)
+}
+
+func (w notFlushWriter) WriteHeader(code int) {
+	w.w.WriteHeader(code)
+}
@@ -0,0 +1,18 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// UploadsHandler returns a middleware that removes the temporary files of the files
+// uploaded in multipart forms and bound by httpx.Parse, after the request is handled.
+func UploadsHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r, cleanup := httpx.TrackUploads(r)
+		defer cleanup()
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+func TestUploadsHandler(t *testing.T) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", "foo.txt")
+	assert.Nil(t, err)
+	_, err = part.Write([]byte("foo"))
+	assert.Nil(t, err)
+	assert.Nil(t, writer.Close())
+
+	var file *os.File
+	handler := UploadsHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			File *httpx.File `file:"file"`
+		}
+		assert.Nil(t, httpx.Parse(r, &req))
+
+		file, err = req.File.Open()
+		assert.Nil(t, err)
+		assert.Nil(t, file.Close())
+	}))
+
+	req := httptest.NewRequest(http.MethodPost, "http://localhost", &body)
+	req.Header.Set(httpx.ContentType, writer.FormDataContentType())
+	resp := httptest.NewRecorder()
+	handler.ServeHTTP(resp, req)
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	_, err = os.Stat(file.Name())
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/health"
+	"github.com/zeromicro/go-zero/rest/handler"
+	"github.com/zeromicro/go-zero/rest/httpx"
+)
+
+// bindHealth binds the health, liveness and readiness endpoints.
+// The endpoints are not logged, limited or shed, to let the probes work under heavy load.
+func (ng *engine) bindHealth(router httpx.Router) error {
+	c := ng.conf.Health
+	if !c.Enabled {
+		return nil
+	}
+
+	health.SetDrainDelay(c.DrainDelay)
+	endpoints := map[string]func(context.Context) health.Report{
+		c.HealthPath: health.Check,
+		c.LivePath:   health.CheckLiveness,
+		c.ReadyPath:  health.CheckReadiness,
+	}
+	for path, check := range endpoints {
+		if len(path) == 0 {
+			continue
+		}
+
+		h := handler.RecoverHandler(healthHandler(check, c))
+		if err := router.Handle(http.MethodGet, path, h); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func healthHandler(check func(context.Context) health.Report, c HealthConf) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if c.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+			defer cancel()
+		}
+
+		report := check(ctx)
+		if report.Healthy() {
+			httpx.OkJson(w, report)
+		} else {
+			httpx.WriteJson(w, http.StatusServiceUnavailable, report)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/health"
+)
+
+func TestEngine_Health(t *testing.T) {
+	var c RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+Port: 54321
+Health:
+  Enabled: true
+`), &c))
+	rt := newRouterForTest(t, newEngine(c))
+
+	const probe = "rest-test-db"
+	health.AddReadinessProbe(probe, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	defer health.RemoveProbe(probe)
+
+	tests := []struct {
+		path string
+		code int
+	}{
+		{
+			path: "/healthz",
+			code: http.StatusServiceUnavailable,
+		},
+		{
+			path: "/livez",
+			code: http.StatusOK,
+		},
+		{
+			path: "/readyz",
+			code: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			w := httptest.NewRecorder()
+			rt.ServeHTTP(w, r)
+			assert.Equal(t, test.code, w.Code)
+
+			var report health.Report
+			assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &report))
+			assert.Equal(t, test.code == http.StatusOK, report.Healthy())
+			if !report.Healthy() {
+				assert.Equal(t, "connection refused", report.Probes[probe])
+			}
+		})
+	}
+}
+
+func TestEngine_HealthDisabled(t *testing.T) {
+	var c RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &c))
+	rt := newRouterForTest(t, newEngine(c))
+
+	r := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
@@ -0,0 +1,287 @@
+package httpx
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"mime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	jsonTagKey   = "json"
+	anyMediaType = "*/*"
+	qualityParam = "q"
+	wildcardSub  = "/*"
+)
+
+var (
+	// ErrUnsupportedValue is an error that indicates the codec can't marshal the type of the value,
+	// the codecs should return it or wrap it to let WriteNegotiated try the next acceptable codec.
+	ErrUnsupportedValue = errors.New("unsupported value")
+	// ErrNotProtoMessage is an error that indicates the value is not a proto.Message.
+	ErrNotProtoMessage = fmt.Errorf("%w: not a proto.Message", ErrUnsupportedValue)
+
+	codecLock sync.RWMutex
+	// the codecs in registering order, the former ones are preferred on wildcards.
+	codecs = []registeredCodec{
+		{contentType: ApplicationJson, codec: jsonCodec{}},
+		{contentType: ApplicationXml, codec: xmlCodec{}},
+		{contentType: TextXml, codec: xmlCodec{}},
+		{contentType: ApplicationProtobuf, codec: protobufCodec{}},
+		{contentType: ApplicationXProtobuf, codec: protobufCodec{}},
+		{contentType: ApplicationMsgpack, codec: msgpackCodec{}},
+		{contentType: ApplicationXMsgpack, codec: msgpackCodec{}},
+	}
+)
+
+type (
+	// A Codec marshals the response bodies and unmarshals the request bodies of a content type.
+	Codec interface {
+		Marshal(v interface{}) ([]byte, error)
+		Unmarshal(data []byte, v interface{}) error
+	}
+
+	registeredCodec struct {
+		contentType string
+		codec       Codec
+	}
+
+	candidateCodec struct {
+		registeredCodec
+		quality     float64
+		specificity int
+	}
+
+	acceptRange struct {
+		mediaType string
+		quality   float64
+	}
+
+	jsonCodec     struct{}
+	xmlCodec      struct{}
+	protobufCodec struct{}
+	msgpackCodec  struct{}
+)
+
+// GetCodec returns the codec registered for the content type, the parameters like charset are ignored.
+func GetCodec(contentType string) (Codec, bool) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, false
+	}
+
+	codecLock.RLock()
+	defer codecLock.RUnlock()
+
+	for _, c := range codecs {
+		if c.contentType == mediaType {
+			return c.codec, true
+		}
+	}
+
+	return nil, false
+}
+
+// Negotiate returns the content type and the codec to write the response by the Accept header.
+// The json codec is used if the Accept header is empty, false is returned if no codec is acceptable.
+func Negotiate(accept string) (string, Codec, bool) {
+	candidates := negotiate(accept)
+	if len(candidates) == 0 {
+		return "", nil, false
+	}
+
+	return candidates[0].contentType, candidates[0].codec, true
+}
+
+// RegisterCodec registers the codec for the content type, like application/x-protobuf,
+// the one registered for the same content type is replaced.
+func RegisterCodec(contentType string, codec Codec) {
+	codecLock.Lock()
+	defer codecLock.Unlock()
+
+	for i, c := range codecs {
+		if c.contentType == contentType {
+			codecs[i].codec = codec
+			return
+		}
+	}
+
+	codecs = append(codecs, registeredCodec{
+		contentType: contentType,
+		codec:       codec,
+	})
+}
+
+func (c jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (c msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := msgpack.NewEncoder(&buf)
+	// use the json tags to keep the same field names with json
+	enc.SetCustomStructTag(jsonTagKey)
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	dec := msgpack.NewDecoder(bytes.NewReader(data))
+	dec.SetCustomStructTag(jsonTagKey)
+	return dec.Decode(v)
+}
+
+func (c protobufCodec) Marshal(v interface{}) ([]byte, error) {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return nil, ErrNotProtoMessage
+	}
+
+	return proto.Marshal(msg)
+}
+
+func (c protobufCodec) Unmarshal(data []byte, v interface{}) error {
+	msg, ok := v.(proto.Message)
+	if !ok {
+		return ErrNotProtoMessage
+	}
+
+	return proto.Unmarshal(data, msg)
+}
+
+func (c xmlCodec) Marshal(v interface{}) ([]byte, error) {
+	return xml.Marshal(v)
+}
+
+func (c xmlCodec) Unmarshal(data []byte, v interface{}) error {
+	return xml.Unmarshal(data, v)
+}
+
+func isJson(contentType string) bool {
+	return contentType == ApplicationJson
+}
+
+func matchMediaRange(mediaRange, contentType string) bool {
+	switch {
+	case mediaRange == anyMediaType:
+		return true
+	case strings.HasSuffix(mediaRange, wildcardSub):
+		return strings.HasPrefix(contentType, strings.TrimSuffix(mediaRange, "*"))
+	default:
+		return mediaRange == contentType
+	}
+}
+
+// negotiate returns the acceptable codecs in preference order by the Accept header.
+// Each codec takes the quality of the most specific range it matches, the codecs are sorted
+// by the qualities and then the specificities, and the json codec is preferred on ties.
+func negotiate(accept string) []candidateCodec {
+	if len(strings.TrimSpace(accept)) == 0 {
+		accept = anyMediaType
+	}
+
+	ranges := parseAccept(accept)
+	codecLock.RLock()
+	var candidates []candidateCodec
+	for _, c := range codecs {
+		if quality, spec := qualityOf(ranges, c.contentType); quality > 0 {
+			candidates = append(candidates, candidateCodec{
+				registeredCodec: c,
+				quality:         quality,
+				specificity:     spec,
+			})
+		}
+	}
+	codecLock.RUnlock()
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].quality != candidates[j].quality {
+			return candidates[i].quality > candidates[j].quality
+		}
+		if candidates[i].specificity != candidates[j].specificity {
+			return candidates[i].specificity > candidates[j].specificity
+		}
+
+		return isJson(candidates[i].contentType) && !isJson(candidates[j].contentType)
+	})
+
+	return candidates
+}
+
+// parseAccept parses the Accept header, and sorts the ranges by the qualities and the specificities.
+func parseAccept(accept string) []acceptRange {
+	var ranges []acceptRange
+	for _, item := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(item))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, ok := params[qualityParam]; ok {
+			if val, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = val
+			}
+		}
+		ranges = append(ranges, acceptRange{
+			mediaType: mediaType,
+			quality:   quality,
+		})
+	}
+
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].quality != ranges[j].quality {
+			return ranges[i].quality > ranges[j].quality
+		}
+
+		return specificity(ranges[i].mediaType) > specificity(ranges[j].mediaType)
+	})
+
+	return ranges
+}
+
+// qualityOf returns the quality and the specificity of the most specific range
+// that matches the content type, 0 quality is returned if not matched.
+func qualityOf(ranges []acceptRange, contentType string) (float64, int) {
+	var quality float64
+	best := -1
+	for _, rg := range ranges {
+		if !matchMediaRange(rg.mediaType, contentType) {
+			continue
+		}
+
+		if spec := specificity(rg.mediaType); spec > best {
+			best = spec
+			quality = rg.quality
+		}
+	}
+
+	return quality, best
+}
+
+func specificity(mediaType string) int {
+	switch {
+	case mediaType == anyMediaType:
+		return 0
+	case strings.HasSuffix(mediaType, wildcardSub):
+		return 1
+	default:
+		return 2
+	}
+}
@@ -0,0 +1,209 @@
+package httpx
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+type upperCodec struct{}
+
+func (c upperCodec) Marshal(v interface{}) ([]byte, error) {
+	return []byte("UPPER"), nil
+}
+
+func (c upperCodec) Unmarshal(data []byte, v interface{}) error {
+	return nil
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		contentType string
+		ok          bool
+	}{
+		{
+			name:        "empty",
+			contentType: ApplicationJson,
+			ok:          true,
+		},
+		{
+			name:        "any",
+			accept:      "*/*",
+			contentType: ApplicationJson,
+			ok:          true,
+		},
+		{
+			name:        "exact",
+			accept:      "application/xml",
+			contentType: ApplicationXml,
+			ok:          true,
+		},
+		{
+			name:        "with parameters",
+			accept:      "application/x-protobuf; charset=utf-8",
+			contentType: ApplicationXProtobuf,
+			ok:          true,
+		},
+		{
+			name:        "quality",
+			accept:      "application/json;q=0.5, application/msgpack;q=0.8",
+			contentType: ApplicationMsgpack,
+			ok:          true,
+		},
+		{
+			name:        "specificity",
+			accept:      "application/*;q=0.5, application/xml",
+			contentType: ApplicationXml,
+			ok:          true,
+		},
+		{
+			name:        "more specific range overrides",
+			accept:      "application/msgpack;q=0.5, application/*",
+			contentType: ApplicationJson,
+			ok:          true,
+		},
+		{
+			name:        "json on ties",
+			accept:      "application/xml, application/json",
+			contentType: ApplicationJson,
+			ok:          true,
+		},
+		{
+			name:        "json on any",
+			accept:      "*/*",
+			contentType: ApplicationJson,
+			ok:          true,
+		},
+		{
+			name:        "explicit type over low quality any",
+			accept:      "application/xml, */*;q=0.1",
+			contentType: ApplicationXml,
+			ok:          true,
+		},
+		{
+			name:        "exact type over any on equal quality",
+			accept:      "*/*, application/msgpack",
+			contentType: ApplicationMsgpack,
+			ok:          true,
+		},
+		{
+			name:        "browser",
+			accept:      "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+			contentType: ApplicationXml,
+			ok:          true,
+		},
+		{
+			name:        "sub wildcard",
+			accept:      "text/*",
+			contentType: TextXml,
+			ok:          true,
+		},
+		{
+			name:        "excluded",
+			accept:      "application/json;q=0, */*;q=0.1",
+			contentType: ApplicationXml,
+			ok:          true,
+		},
+		{
+			name:   "not acceptable",
+			accept: "text/html, image/*",
+		},
+		{
+			name:   "all excluded",
+			accept: "*/*;q=0",
+		},
+		{
+			name:        "bad ranges ignored",
+			accept:      "bad;;, application/protobuf",
+			contentType: ApplicationProtobuf,
+			ok:          true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			contentType, codec, ok := Negotiate(test.accept)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.contentType, contentType)
+			assert.Equal(t, test.ok, codec != nil)
+		})
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	const contentType = "text/upper"
+	old := append([]registeredCodec(nil), codecs...)
+	defer func() {
+		codecs = old
+	}()
+
+	_, ok := GetCodec(contentType)
+	assert.False(t, ok)
+
+	RegisterCodec(contentType, upperCodec{})
+	codec, ok := GetCodec(contentType + "; charset=utf-8")
+	assert.True(t, ok)
+	assert.Equal(t, upperCodec{}, codec)
+	contentType1, _, ok := Negotiate("text/*;q=0.9, text/upper")
+	assert.True(t, ok)
+	assert.Equal(t, contentType, contentType1)
+
+	RegisterCodec(contentType, jsonCodec{})
+	codec, ok = GetCodec(contentType)
+	assert.True(t, ok)
+	assert.Equal(t, jsonCodec{}, codec)
+
+	_, ok = GetCodec("bad;;")
+	assert.False(t, ok)
+}
+
+func TestCodecs(t *testing.T) {
+	type person struct {
+		Name string `json:"name" xml:"name"`
+		Age  int    `json:"age" xml:"age"`
+	}
+
+	for _, contentType := range []string{ApplicationJson, ApplicationXml, ApplicationMsgpack} {
+		codec, ok := GetCodec(contentType)
+		assert.True(t, ok)
+
+		data, err := codec.Marshal(person{Name: "kevin", Age: 18})
+		assert.Nil(t, err)
+		var p person
+		assert.Nil(t, codec.Unmarshal(data, &p))
+		assert.Equal(t, person{Name: "kevin", Age: 18}, p)
+	}
+}
+
+func TestMsgpackCodecWithJsonTags(t *testing.T) {
+	var codec msgpackCodec
+	data, err := codec.Marshal(struct {
+		Name string `json:"name"`
+	}{Name: "kevin"})
+	assert.Nil(t, err)
+
+	var m map[string]interface{}
+	assert.Nil(t, codec.Unmarshal(data, &m))
+	assert.Equal(t, map[string]interface{}{"name": "kevin"}, m)
+}
+
+func TestProtobufCodec(t *testing.T) {
+	var codec protobufCodec
+	data, err := codec.Marshal(wrapperspb.String("kevin"))
+	assert.Nil(t, err)
+
+	var val wrapperspb.StringValue
+	assert.Nil(t, codec.Unmarshal(data, &val))
+	assert.True(t, proto.Equal(wrapperspb.String("kevin"), &val))
+
+	_, err = codec.Marshal(message{Name: "kevin"})
+	assert.Equal(t, ErrNotProtoMessage, err)
+	assert.True(t, errors.Is(err, ErrUnsupportedValue))
+	assert.Equal(t, ErrNotProtoMessage, codec.Unmarshal(data, &message{}))
+}
@@ -0,0 +1,344 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/fs"
+	"github.com/zeromicro/go-zero/core/logx"
+)
+
+const (
+	fileKey        = "file"
+	optionalOption = "optional"
+	typesOption    = "types="
+	typesSeparator = "|"
+	sniffLen       = 512
+)
+
+var (
+	fileType      = reflect.TypeOf(File{})
+	filePtrType   = reflect.PtrTo(fileType)
+	filesType     = reflect.SliceOf(filePtrType)
+	uploadsCtxKey = uploadsKey{}
+)
+
+type (
+	// A File is an uploaded file in a multipart form, which is streamed into a temporary file.
+	// Declare the fields in the request structs with file tags to bind the files, like:
+	//  Avatar *httpx.File   `file:"avatar,types=image/png|image/jpeg"`
+	//  Photos []*httpx.File `file:"photos,optional,types=image/*"`
+	// The types option restricts the content types of the files.
+	File struct {
+		Filename string
+		Header   textproto.MIMEHeader
+		// ContentType is the declared content type of the file, or sniffed from the content if not declared.
+		ContentType string
+		Size        int64
+		path        string
+	}
+
+	fileField struct {
+		index    []int
+		name     string
+		multiple bool
+		optional bool
+		types    []string
+	}
+
+	uploadsKey struct{}
+
+	uploads struct {
+		lock  sync.Mutex
+		files []*File
+	}
+)
+
+// Open opens the uploaded file for reading, the caller should close it after use.
+func (f *File) Open() (*os.File, error) {
+	return os.Open(f.path)
+}
+
+// Remove removes the temporary file of the uploaded file.
+func (f *File) Remove() error {
+	return os.Remove(f.path)
+}
+
+// TrackUploads returns a request with a context that tracks the files uploaded in r,
+// and a func to remove their temporary files, which should be called after r is handled.
+// The uploaded files are not tracked if r is not returned by TrackUploads,
+// and the caller should remove them by File.Remove.
+func TrackUploads(r *http.Request) (*http.Request, func()) {
+	tracked := new(uploads)
+	return r.WithContext(context.WithValue(r.Context(), uploadsCtxKey, tracked)), func() {
+		tracked.lock.Lock()
+		defer tracked.lock.Unlock()
+
+		for _, file := range tracked.files {
+			if err := file.Remove(); err != nil && !os.IsNotExist(err) {
+				logx.Error(err)
+			}
+		}
+		tracked.files = nil
+	}
+}
+
+func (u *uploads) add(file *File) {
+	if u == nil {
+		return
+	}
+
+	u.lock.Lock()
+	u.files = append(u.files, file)
+	u.lock.Unlock()
+}
+
+// parseMultipartForm streams the multipart form of r, the values are added into r.Form,
+// the files of the fields are saved into temporary files, and the others are discarded.
+func parseMultipartForm(r *http.Request, fields []fileField) (map[string][]*File, error) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	accepted := make(map[string]fileField, len(fields))
+	for _, field := range fields {
+		accepted[field.name] = field
+	}
+
+	tracked, _ := r.Context().Value(uploadsCtxKey).(*uploads)
+	files := make(map[string][]*File)
+	values := make(url.Values)
+	if err := readMultipartForm(reader, accepted, tracked, files, values); err != nil {
+		removeFiles(files)
+		return nil, err
+	}
+
+	if r.Form == nil {
+		r.Form = make(url.Values)
+	}
+	if r.PostForm == nil {
+		r.PostForm = make(url.Values)
+	}
+	for name, vals := range values {
+		r.Form[name] = append(r.Form[name], vals...)
+		r.PostForm[name] = append(r.PostForm[name], vals...)
+	}
+
+	return files, nil
+}
+
+func readMultipartForm(reader *multipart.Reader, accepted map[string]fileField, tracked *uploads,
+	files map[string][]*File, values url.Values) error {
+	remaining := int64(maxMemory)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := part.FormName()
+		if len(name) == 0 {
+			continue
+		}
+
+		if len(part.FileName()) == 0 {
+			// the values are kept in memory, limited by maxMemory like http.Request.ParseMultipartForm
+			content, err := ioutil.ReadAll(io.LimitReader(part, remaining+1))
+			if err != nil {
+				return err
+			}
+
+			remaining -= int64(len(content))
+			if remaining < 0 {
+				return fmt.Errorf("multipart values are too large, limit is %d", maxMemory)
+			}
+
+			values.Add(name, string(content))
+			continue
+		}
+
+		field, ok := accepted[name]
+		if !ok {
+			if _, err := io.Copy(ioutil.Discard, part); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if !field.multiple && len(files[name]) > 0 {
+			return fmt.Errorf("field %s expects only one file", name)
+		}
+
+		file, err := saveFile(part, field, tracked)
+		if err != nil {
+			return err
+		}
+
+		files[name] = append(files[name], file)
+	}
+
+	return nil
+}
+
+func bindFiles(v interface{}, fields []fileField, files map[string][]*File) error {
+	val := reflect.Indirect(reflect.ValueOf(v))
+	for _, field := range fields {
+		uploaded := files[field.name]
+		if len(uploaded) == 0 {
+			if field.optional {
+				continue
+			}
+
+			return fmt.Errorf("field %s is not set", field.name)
+		}
+
+		fieldVal := val.FieldByIndex(field.index)
+		if field.multiple {
+			fieldVal.Set(reflect.ValueOf(uploaded))
+		} else {
+			fieldVal.Set(reflect.ValueOf(uploaded[0]))
+		}
+	}
+
+	return nil
+}
+
+// fileFields returns the fields with file tags in v, including the ones in the embedded structs.
+func fileFields(v interface{}) []fileField {
+	tp := reflect.TypeOf(v)
+	for tp != nil && tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+	if tp == nil || tp.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return collectFileFields(tp, nil)
+}
+
+func collectFileFields(tp reflect.Type, index []int) []fileField {
+	var fields []fileField
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		fieldIndex := append(append([]int(nil), index...), i)
+		tag, ok := field.Tag.Lookup(fileKey)
+		if !ok {
+			if field.Anonymous && field.Type.Kind() == reflect.Struct {
+				fields = append(fields, collectFileFields(field.Type, fieldIndex)...)
+			}
+			continue
+		}
+
+		if field.Type != filePtrType && field.Type != filesType {
+			logx.Errorf("field %s with file tag should be *httpx.File or []*httpx.File", field.Name)
+			continue
+		}
+
+		ff := fileField{
+			index:    fieldIndex,
+			multiple: field.Type == filesType,
+		}
+		segments := strings.Split(tag, ",")
+		ff.name = strings.TrimSpace(segments[0])
+		if len(ff.name) == 0 {
+			ff.name = field.Name
+		}
+		for _, segment := range segments[1:] {
+			segment = strings.TrimSpace(segment)
+			switch {
+			case segment == optionalOption:
+				ff.optional = true
+			case strings.HasPrefix(segment, typesOption):
+				ff.types = strings.Split(segment[len(typesOption):], typesSeparator)
+			}
+		}
+		fields = append(fields, ff)
+	}
+
+	return fields
+}
+
+func isMultipartForm(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get(ContentType))
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+func matchType(contentType string, types []string) bool {
+	if len(types) == 0 {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	for _, tp := range types {
+		tp = strings.TrimSpace(tp)
+		if tp == mediaType {
+			return true
+		}
+		if strings.HasSuffix(tp, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(tp, "*")) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeFiles removes the saved files, which are useless on errors.
+func removeFiles(files map[string][]*File) {
+	for _, uploaded := range files {
+		for _, file := range uploaded {
+			if err := file.Remove(); err != nil {
+				logx.Error(err)
+			}
+		}
+	}
+}
+
+func saveFile(part *multipart.Part, field fileField, tracked *uploads) (*File, error) {
+	reader := bufio.NewReaderSize(part, sniffLen)
+	contentType := part.Header.Get(ContentType)
+	if len(contentType) == 0 {
+		// the error is ignored, the short files are sniffed with what they have
+		head, _ := reader.Peek(sniffLen)
+		contentType = http.DetectContentType(head)
+	}
+	if !matchType(contentType, field.types) {
+		return nil, fmt.Errorf("file %s of field %s with content type %s is not allowed",
+			part.FileName(), field.name, contentType)
+	}
+
+	path, size, err := fs.TempFilenameWithReader(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	file := &File{
+		Filename:    part.FileName(),
+		Header:      part.Header,
+		ContentType: contentType,
+		Size:        size,
+		path:        path,
+	}
+	tracked.add(file)
+
+	return file, nil
+}
@@ -0,0 +1,197 @@
+package httpx
+
+import (
+	"bytes"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type (
+	uploadedFile struct {
+		field       string
+		filename    string
+		contentType string
+		content     string
+	}
+
+	uploadRequest struct {
+		Name   string  `form:"name"`
+		Avatar *File   `file:"avatar,types=image/png|image/jpeg"`
+		Photos []*File `file:"photos,optional,types=image/*"`
+	}
+)
+
+var pngHeader = "\x89PNG\x0D\x0A\x1A\x0A"
+
+func TestParseFiles(t *testing.T) {
+	r, cleanup := TrackUploads(newMultipartRequest(t, map[string]string{"name": "foo"}, []uploadedFile{
+		{field: "avatar", filename: "avatar.png", content: pngHeader + "avatar"},
+		{field: "photos", filename: "a.jpg", contentType: "image/jpeg", content: "a"},
+		{field: "photos", filename: "b.png", contentType: "image/png", content: "bb"},
+		{field: "other", filename: "other.txt", content: "other"},
+	}))
+
+	var req uploadRequest
+	assert.Nil(t, Parse(r, &req))
+	assert.Equal(t, "foo", req.Name)
+	assert.Equal(t, "foo", r.PostForm.Get("name"))
+
+	assert.Equal(t, "avatar.png", req.Avatar.Filename)
+	assert.Equal(t, "image/png", req.Avatar.ContentType)
+	assert.Equal(t, int64(len(pngHeader)+6), req.Avatar.Size)
+	assert.Equal(t, pngHeader+"avatar", readFile(t, req.Avatar))
+
+	assert.Len(t, req.Photos, 2)
+	assert.Equal(t, "a.jpg", req.Photos[0].Filename)
+	assert.Equal(t, "a", readFile(t, req.Photos[0]))
+	assert.Equal(t, "b.png", req.Photos[1].Filename)
+	assert.Equal(t, "bb", readFile(t, req.Photos[1]))
+
+	cleanup()
+	for _, file := range append(req.Photos, req.Avatar) {
+		_, err := file.Open()
+		assert.True(t, os.IsNotExist(err))
+	}
+}
+
+func TestParseFiles_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		files []uploadedFile
+	}{
+		{
+			name: "missing",
+			files: []uploadedFile{
+				{field: "photos", filename: "a.jpg", contentType: "image/jpeg", content: "a"},
+			},
+		},
+		{
+			name: "bad type",
+			files: []uploadedFile{
+				{field: "avatar", filename: "avatar.txt", content: "avatar"},
+			},
+		},
+		{
+			name: "multiple",
+			files: []uploadedFile{
+				{field: "avatar", filename: "a.png", contentType: "image/png", content: "a"},
+				{field: "avatar", filename: "b.png", contentType: "image/png", content: "b"},
+			},
+		},
+		{
+			name: "bad type in multiple",
+			files: []uploadedFile{
+				{field: "avatar", filename: "a.png", contentType: "image/png", content: "a"},
+				{field: "photos", filename: "b.txt", contentType: "text/plain", content: "b"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := newMultipartRequest(t, map[string]string{"name": "foo"}, test.files)
+			var req uploadRequest
+			assert.NotNil(t, Parse(r, &req))
+		})
+	}
+}
+
+func TestParseFiles_NotMultipart(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/?name=foo", nil)
+	var req struct {
+		Name   string `form:"name"`
+		Avatar *File  `file:"avatar,optional"`
+	}
+	assert.Nil(t, Parse(r, &req))
+	assert.Equal(t, "foo", req.Name)
+	assert.Nil(t, req.Avatar)
+
+	var required uploadRequest
+	assert.NotNil(t, Parse(r, &required))
+}
+
+func TestParseFiles_Embedded(t *testing.T) {
+	type (
+		Base struct {
+			Avatar *File `file:"avatar"`
+		}
+		request struct {
+			Base
+			Bad *string `file:"bad"`
+		}
+	)
+
+	r := newMultipartRequest(t, nil, []uploadedFile{
+		{field: "avatar", filename: "a.png", contentType: "image/png", content: "a"},
+	})
+	var req request
+	assert.Nil(t, Parse(r, &req))
+	assert.Equal(t, "a", readFile(t, req.Avatar))
+	assert.Nil(t, req.Avatar.Remove())
+}
+
+func TestParseForm_MultipartWithoutFiles(t *testing.T) {
+	r := newMultipartRequest(t, map[string]string{"name": "foo"}, []uploadedFile{
+		{field: "avatar", filename: "a.png", contentType: "image/png", content: "a"},
+	})
+	var req struct {
+		Name string `form:"name"`
+	}
+	assert.Nil(t, Parse(r, &req))
+	assert.Equal(t, "foo", req.Name)
+
+	// keep the files parsed by http.Request.ParseMultipartForm for compatibility
+	_, header, err := r.FormFile("avatar")
+	assert.Nil(t, err)
+	assert.Equal(t, "a.png", header.Filename)
+}
+
+func TestMatchType(t *testing.T) {
+	assert.True(t, matchType("image/png", nil))
+	assert.True(t, matchType("image/png", []string{"image/png"}))
+	assert.True(t, matchType("image/png; charset=utf-8", []string{"image/*"}))
+	assert.False(t, matchType("text/plain", []string{"image/*"}))
+	assert.False(t, matchType("bad;;", []string{"image/*"}))
+}
+
+func newMultipartRequest(t *testing.T, values map[string]string, files []uploadedFile) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range values {
+		assert.Nil(t, writer.WriteField(k, v))
+	}
+	for _, file := range files {
+		header := make(textproto.MIMEHeader)
+		header.Set("Content-Disposition", `form-data; name="`+file.field+`"; filename="`+file.filename+`"`)
+		if len(file.contentType) > 0 {
+			header.Set(ContentType, file.contentType)
+		}
+		part, err := writer.CreatePart(header)
+		assert.Nil(t, err)
+		_, err = part.Write([]byte(file.content))
+		assert.Nil(t, err)
+	}
+	assert.Nil(t, writer.Close())
+
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set(ContentType, writer.FormDataContentType())
+	return r
+}
+
+func readFile(t *testing.T, file *File) string {
+	f, err := file.Open()
+	assert.Nil(t, err)
+	defer f.Close()
+
+	content, err := ioutil.ReadAll(f)
+	assert.Nil(t, err)
+	return string(content)
+}
@@ -0,0 +1,230 @@
+package httpx
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/mapping"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errorHandlerKey = errorHandlerCtxKey{}
+
+type (
+	// A CodeError is an error with the http status, the business code, the message and the details,
+	// which is written as problem+json by Error.
+	CodeError struct {
+		Status  int
+		Code    int
+		Message string
+		Details []interface{}
+	}
+
+	// A Problem is the problem details of an error, defined by RFC 7807.
+	// Code and Details are the extension members, which are the business code and details.
+	Problem struct {
+		Type     string        `json:"type,omitempty"`
+		Title    string        `json:"title"`
+		Status   int           `json:"status"`
+		Detail   string        `json:"detail,omitempty"`
+		Instance string        `json:"instance,omitempty"`
+		Code     int           `json:"code,omitempty"`
+		Details  []interface{} `json:"details,omitempty"`
+	}
+
+	// ErrorHandler converts the errors into the http statuses and the bodies.
+	ErrorHandler func(ctx context.Context, err error) (int, interface{})
+
+	errorHandlerCtxKey struct{}
+
+	contextResponseWriter struct {
+		http.ResponseWriter
+		ctx context.Context
+	}
+)
+
+// NewCodeError returns a CodeError.
+func NewCodeError(status, code int, msg string, details ...interface{}) *CodeError {
+	return &CodeError{
+		Status:  status,
+		Code:    code,
+		Message: msg,
+		Details: details,
+	}
+}
+
+// Error returns the message of e.
+func (e *CodeError) Error() string {
+	return fmt.Sprintf("code: %d, message: %s", e.Code, e.Message)
+}
+
+// ContextWithErrorHandler returns a context that carries the error handler, which is used by ErrorCtx
+// in precedence over the one set by SetErrorHandler, to have different error handlers per server.
+func ContextWithErrorHandler(ctx context.Context, handler ErrorHandler) context.Context {
+	return context.WithValue(ctx, errorHandlerKey, handler)
+}
+
+// ResponseWriterWithContext returns a ResponseWriter that carries ctx, which is used by Error
+// to find the error handler put by ContextWithErrorHandler, because Error has no request context.
+func ResponseWriterWithContext(w http.ResponseWriter, ctx context.Context) http.ResponseWriter {
+	return &contextResponseWriter{
+		ResponseWriter: w,
+		ctx:            ctx,
+	}
+}
+
+// GrpcStatusToHttp returns the http status of the grpc code,
+// the custom codes, which are usually business errors, are treated as 400 Bad Request.
+func GrpcStatusToHttp(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		// 499 Client Closed Request
+		return 499
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		return http.StatusInternalServerError
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusBadRequest
+	}
+}
+
+// ToProblem converts err into a Problem, the CodeErrors, the grpc status errors
+// and the validation errors are converted with their statuses, codes and details,
+// and the other errors are converted with 400 Bad Request.
+// The grpc errors with Unknown, Internal and DataLoss are logged with ctx, and converted
+// without the messages and details, to not leak the internals to the clients.
+func ToProblem(ctx context.Context, err error) Problem {
+	var cerr *CodeError
+	var verr *mapping.ValidationError
+	if errors.As(err, &cerr) {
+		code := cerr.Status
+		if code == 0 {
+			code = http.StatusBadRequest
+		}
+		return newProblem(code, cerr.Code, cerr.Message, cerr.Details)
+	} else if st, ok := grpcStatus(err); ok {
+		code := GrpcStatusToHttp(st.Code())
+		if isInternal(st.Code()) {
+			logx.WithContext(ctx).Errorf("internal error from grpc: %s", err)
+			return newProblem(code, int(st.Code()), http.StatusText(code), nil)
+		}
+
+		return newProblem(code, int(st.Code()), st.Message(), st.Details())
+	} else if errors.As(err, &verr) {
+		details := make([]interface{}, 0, len(verr.Fields))
+		for _, field := range verr.Fields {
+			details = append(details, field)
+		}
+		return newProblem(http.StatusBadRequest, 0, verr.Message, details)
+	}
+
+	return newProblem(http.StatusBadRequest, 0, err.Error(), nil)
+}
+
+// WriteProblem writes p into w as problem+json, with the status of p.
+func WriteProblem(w http.ResponseWriter, p Problem) {
+	bs, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set(ContentType, ApplicationProblemJson)
+	w.WriteHeader(p.Status)
+	writeBytes(w, bs)
+}
+
+// Flush implements the http.Flusher interface.
+func (w *contextResponseWriter) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Hijack implements the http.Hijacker interface.
+func (w *contextResponseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if hijacked, ok := w.ResponseWriter.(http.Hijacker); ok {
+		return hijacked.Hijack()
+	}
+
+	return nil, nil, errors.New("server doesn't support hijacking")
+}
+
+func errorHandlerFromContext(ctx context.Context) (ErrorHandler, bool) {
+	handler, ok := ctx.Value(errorHandlerKey).(ErrorHandler)
+	return handler, ok && handler != nil
+}
+
+// grpcStatus returns the status of the grpc error, which is not converted from a non-grpc error.
+func grpcStatus(err error) (*status.Status, bool) {
+	var se interface {
+		GRPCStatus() *status.Status
+	}
+	if !errors.As(err, &se) {
+		return nil, false
+	}
+
+	st := se.GRPCStatus()
+	return st, st != nil && st.Code() != codes.OK
+}
+
+// isInternal checks if the grpc code is for the internal errors, which are not exposed to the clients.
+func isInternal(code codes.Code) bool {
+	switch code {
+	case codes.Unknown, codes.Internal, codes.DataLoss:
+		return true
+	default:
+		return false
+	}
+}
+
+// isProblem checks if err has the status and code to be written as a Problem.
+func isProblem(err error) bool {
+	var cerr *CodeError
+	if errors.As(err, &cerr) {
+		return true
+	}
+
+	_, ok := grpcStatus(err)
+	return ok
+}
+
+func newProblem(status, code int, detail string, details []interface{}) Problem {
+	if len(details) == 0 {
+		details = nil
+	}
+
+	return Problem{
+		Title:   http.StatusText(status),
+		Status:  status,
+		Detail:  detail,
+		Code:    code,
+		Details: details,
+	}
+}
@@ -0,0 +1,172 @@
+package httpx
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/mapping"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCodeError(t *testing.T) {
+	err := NewCodeError(http.StatusNotFound, 1001, "user not found", "foo")
+	assert.Equal(t, "code: 1001, message: user not found", err.Error())
+	assert.Equal(t, []interface{}{"foo"}, err.Details)
+}
+
+func TestGrpcStatusToHttp(t *testing.T) {
+	tests := []struct {
+		code   codes.Code
+		expect int
+	}{
+		{code: codes.OK, expect: http.StatusOK},
+		{code: codes.Canceled, expect: 499},
+		{code: codes.Unknown, expect: http.StatusInternalServerError},
+		{code: codes.InvalidArgument, expect: http.StatusBadRequest},
+		{code: codes.DeadlineExceeded, expect: http.StatusGatewayTimeout},
+		{code: codes.NotFound, expect: http.StatusNotFound},
+		{code: codes.AlreadyExists, expect: http.StatusConflict},
+		{code: codes.PermissionDenied, expect: http.StatusForbidden},
+		{code: codes.ResourceExhausted, expect: http.StatusTooManyRequests},
+		{code: codes.FailedPrecondition, expect: http.StatusBadRequest},
+		{code: codes.Aborted, expect: http.StatusConflict},
+		{code: codes.OutOfRange, expect: http.StatusBadRequest},
+		{code: codes.Unimplemented, expect: http.StatusNotImplemented},
+		{code: codes.Internal, expect: http.StatusInternalServerError},
+		{code: codes.Unavailable, expect: http.StatusServiceUnavailable},
+		{code: codes.DataLoss, expect: http.StatusInternalServerError},
+		{code: codes.Unauthenticated, expect: http.StatusUnauthorized},
+		{code: codes.Code(100), expect: http.StatusBadRequest},
+	}
+
+	for _, test := range tests {
+		t.Run(test.code.String(), func(t *testing.T) {
+			assert.Equal(t, test.expect, GrpcStatusToHttp(test.code))
+		})
+	}
+}
+
+func TestToProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		expect Problem
+	}{
+		{
+			name: "code error",
+			err:  NewCodeError(http.StatusNotFound, 1001, "user not found"),
+			expect: Problem{
+				Title:  http.StatusText(http.StatusNotFound),
+				Status: http.StatusNotFound,
+				Detail: "user not found",
+				Code:   1001,
+			},
+		},
+		{
+			name: "code error without status",
+			err:  fmt.Errorf("wrapped: %w", NewCodeError(0, 1002, "bad name", "name")),
+			expect: Problem{
+				Title:   http.StatusText(http.StatusBadRequest),
+				Status:  http.StatusBadRequest,
+				Detail:  "bad name",
+				Code:    1002,
+				Details: []interface{}{"name"},
+			},
+		},
+		{
+			name: "grpc status error",
+			err:  status.Error(codes.NotFound, "not found"),
+			expect: Problem{
+				Title:  http.StatusText(http.StatusNotFound),
+				Status: http.StatusNotFound,
+				Detail: "not found",
+				Code:   int(codes.NotFound),
+			},
+		},
+		{
+			name: "grpc internal error",
+			err:  status.Error(codes.Internal, "pq: relation \"users\" does not exist"),
+			expect: Problem{
+				Title:  http.StatusText(http.StatusInternalServerError),
+				Status: http.StatusInternalServerError,
+				Detail: http.StatusText(http.StatusInternalServerError),
+				Code:   int(codes.Internal),
+			},
+		},
+		{
+			name: "grpc unknown error",
+			err:  status.Error(codes.Unknown, "panic: runtime error"),
+			expect: Problem{
+				Title:  http.StatusText(http.StatusInternalServerError),
+				Status: http.StatusInternalServerError,
+				Detail: http.StatusText(http.StatusInternalServerError),
+				Code:   int(codes.Unknown),
+			},
+		},
+		{
+			name: "grpc custom code",
+			err:  status.Error(codes.Code(100), "insufficient balance"),
+			expect: Problem{
+				Title:  http.StatusText(http.StatusBadRequest),
+				Status: http.StatusBadRequest,
+				Detail: "insufficient balance",
+				Code:   100,
+			},
+		},
+		{
+			name: "validation error",
+			err: &mapping.ValidationError{
+				Message: "validation failed",
+				Fields: []mapping.FieldError{
+					{Field: "name", Rule: "required", Message: "is required"},
+				},
+			},
+			expect: Problem{
+				Title:  http.StatusText(http.StatusBadRequest),
+				Status: http.StatusBadRequest,
+				Detail: "validation failed",
+				Details: []interface{}{
+					mapping.FieldError{Field: "name", Rule: "required", Message: "is required"},
+				},
+			},
+		},
+		{
+			name: "plain error",
+			err:  errors.New("foo"),
+			expect: Problem{
+				Title:  http.StatusText(http.StatusBadRequest),
+				Status: http.StatusBadRequest,
+				Detail: "foo",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expect, ToProblem(context.Background(), test.err))
+		})
+	}
+}
+
+func TestWriteProblem(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblem(w, ToProblem(context.Background(), NewCodeError(http.StatusConflict, 1003, "duplicated")))
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, ApplicationProblemJson, w.Header().Get(ContentType))
+	assert.Equal(t, `{"title":"Conflict","status":409,"detail":"duplicated","code":1003}`, w.Body.String())
+}
+
+func TestWriteProblemMarshalError(t *testing.T) {
+	w := httptest.NewRecorder()
+	WriteProblem(w, Problem{
+		Status:  http.StatusBadRequest,
+		Details: []interface{}{make(chan int)},
+	})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
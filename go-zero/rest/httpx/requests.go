@@ -2,6 +2,7 @@ package httpx
 
 import (
 	"io"
+	"io/ioutil"
 	"net/http"
 	"net/textproto"
 	"strings"
@@ -27,8 +28,17 @@ var (
 		mapping.WithCanonicalKeyFunc(textproto.CanonicalMIMEHeaderKey))
 )
 
+// A Validator is a request that validates itself after being parsed,
+// it's used to check the rules that can't be expressed in validate tags.
+type Validator interface {
+	Validate() error
+}
+
 // Parse parses the request.
 // 解析路径参数、表单、头部、body等携带内容
+// The parsed v is validated with the validate tags, see mapping.Validate,
+// all the failed fields are returned in a *mapping.ValidationError.
+// If all passed and v implements Validator, its Validate method is called.
 func Parse(r *http.Request, v interface{}) error {
 	if err := ParsePath(r, v); err != nil {
 		return err
@@ -42,7 +52,31 @@ func Parse(r *http.Request, v interface{}) error {
 		return err
 	}
 
-	return ParseJsonBody(r, v)
+	if err := ParseBody(r, v); err != nil {
+		return err
+	}
+
+	return validate(v)
+}
+
+// ParseBody parses the request body by its Content-Type with the registered codecs, see RegisterCodec.
+// The bodies with json or unknown content types are parsed by ParseJsonBody.
+func ParseBody(r *http.Request, v interface{}) error {
+	if withJsonBody(r) || r.ContentLength <= 0 {
+		return ParseJsonBody(r, v)
+	}
+
+	codec, ok := GetCodec(r.Header.Get(ContentType))
+	if !ok {
+		return ParseJsonBody(r, v)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxBodyLen))
+	if err != nil {
+		return err
+	}
+
+	return codec.Unmarshal(body, v)
 }
 
 // ParseHeaders parses the headers request.
@@ -60,12 +94,27 @@ func ParseHeaders(r *http.Request, v interface{}) error {
 }
 
 // ParseForm parses the form request.
+// The files in multipart forms are bound to the fields with file tags, see File.
 func ParseForm(r *http.Request, v interface{}) error {
 	if err := r.ParseForm(); err != nil {
 		return err
 	}
 
-	if err := r.ParseMultipartForm(maxMemory); err != nil {
+	if fields := fileFields(v); len(fields) > 0 {
+		// stream the multipart form to save the files into temporary files, instead of in memory
+		var files map[string][]*File
+		if isMultipartForm(r) {
+			var err error
+			if files, err = parseMultipartForm(r, fields); err != nil {
+				return err
+			}
+		}
+
+		if err := bindFiles(v, fields, files); err != nil {
+			removeFiles(files)
+			return err
+		}
+	} else if err := r.ParseMultipartForm(maxMemory); err != nil {
 		if err != http.ErrNotMultipart {
 			return err
 		}
@@ -126,6 +175,18 @@ func ParsePath(r *http.Request, v interface{}) error {
 	return pathUnmarshaler.Unmarshal(m, v)
 }
 
+func validate(v interface{}) error {
+	if err := mapping.Validate(v); err != nil {
+		return err
+	}
+
+	if val, ok := v.(Validator); ok {
+		return val.Validate()
+	}
+
+	return nil
+}
+
 func withJsonBody(r *http.Request) bool {
 	return r.ContentLength > 0 && strings.Contains(r.Header.Get(ContentType), ApplicationJson)
 }
@@ -1,6 +1,8 @@
 package httpx
 
 import (
+	"bytes"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"strconv"
@@ -8,7 +10,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/mapping"
 	"github.com/zeromicro/go-zero/rest/pathvar"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 func TestParseForm(t *testing.T) {
@@ -224,6 +229,64 @@ func TestParseJsonBody(t *testing.T) {
 	})
 }
 
+func TestParseBody(t *testing.T) {
+	type person struct {
+		Name string `json:"name" xml:"name"`
+		Age  int    `json:"age" xml:"age"`
+	}
+
+	t.Run("xml", func(t *testing.T) {
+		body := `<person><name>kevin</name><age>18</age></person>`
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+		r.Header.Set(ContentType, "application/xml; charset=utf-8")
+
+		var v person
+		assert.Nil(t, Parse(r, &v))
+		assert.Equal(t, person{Name: "kevin", Age: 18}, v)
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		body, err := msgpackCodec{}.Marshal(person{Name: "kevin", Age: 18})
+		assert.Nil(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set(ContentType, ApplicationXMsgpack)
+
+		var v person
+		assert.Nil(t, Parse(r, &v))
+		assert.Equal(t, person{Name: "kevin", Age: 18}, v)
+	})
+
+	t.Run("protobuf", func(t *testing.T) {
+		body, err := proto.Marshal(wrapperspb.String("kevin"))
+		assert.Nil(t, err)
+		r := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		r.Header.Set(ContentType, ApplicationProtobuf)
+
+		var v wrapperspb.StringValue
+		assert.Nil(t, ParseBody(r, &v))
+		assert.Equal(t, "kevin", v.GetValue())
+	})
+
+	t.Run("bad body", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("<person>"))
+		r.Header.Set(ContentType, TextXml)
+
+		var v person
+		assert.NotNil(t, Parse(r, &v))
+	})
+
+	t.Run("unknown content type", func(t *testing.T) {
+		r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader("kevin"))
+		r.Header.Set(ContentType, "text/plain")
+
+		var v struct {
+			Name string `json:"name,optional"`
+		}
+		assert.Nil(t, Parse(r, &v))
+		assert.Equal(t, "", v.Name)
+	})
+}
+
 func TestParseRequired(t *testing.T) {
 	v := struct {
 		Name    string  `form:"name"`
@@ -291,6 +354,52 @@ func TestParseHeaders_Error(t *testing.T) {
 	assert.NotNil(t, Parse(r, &v))
 }
 
+func TestParseValidate(t *testing.T) {
+	var v struct {
+		Name  string   `json:"name" validate:"min=2"`
+		Email string   `json:"email" validate:"email"`
+		Tags  []string `json:"tags" validate:"max=1"`
+	}
+
+	body := `{"name":"k", "email":"kevin@example.com", "tags":["a","b"]}`
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	r.Header.Set(ContentType, ApplicationJson)
+	err := Parse(r, &v)
+	ve, ok := err.(*mapping.ValidationError)
+	assert.True(t, ok)
+	assert.Equal(t, 2, len(ve.Fields))
+	assert.Equal(t, "name", ve.Fields[0].Field)
+	assert.Equal(t, "tags", ve.Fields[1].Field)
+}
+
+func TestParseValidator(t *testing.T) {
+	var v validatedRequest
+	r := httptest.NewRequest(http.MethodGet, "/a?from=5&to=3", nil)
+	assert.Equal(t, errBadRange, Parse(r, &v))
+
+	r = httptest.NewRequest(http.MethodGet, "/a?from=1&to=3", nil)
+	assert.Nil(t, Parse(r, &v))
+
+	r = httptest.NewRequest(http.MethodGet, "/a?from=-1&to=3", nil)
+	_, ok := Parse(r, &v).(*mapping.ValidationError)
+	assert.True(t, ok)
+}
+
+var errBadRange = errors.New("bad range")
+
+type validatedRequest struct {
+	From int `form:"from" validate:"min=0"`
+	To   int `form:"to"`
+}
+
+func (r *validatedRequest) Validate() error {
+	if r.From > r.To {
+		return errBadRange
+	}
+
+	return nil
+}
+
 func BenchmarkParseRaw(b *testing.B) {
 	r, err := http.NewRequest(http.MethodGet, "http://hello.com/a?name=hello&age=18&percent=3.4", nil)
 	if err != nil {
@@ -1,27 +1,58 @@
 package httpx
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
 	"sync"
 
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/mapping"
 )
 
+const varyHeader = "Vary"
+
 var (
 	errorHandler func(error) (int, interface{})
 	lock         sync.RWMutex
 )
 
 // Error writes err into w.
+// A *CodeError or a grpc status error is written as problem+json, see ToProblem,
+// a *mapping.ValidationError is written as json with the failed fields in details.
+// The error handler of the server is used if w is from ResponseWriterWithContext, like ErrorCtx.
 func Error(w http.ResponseWriter, err error, fns ...func(w http.ResponseWriter, err error)) {
+	ctx := context.Background()
+	if cw, ok := w.(*contextResponseWriter); ok {
+		ctx = cw.ctx
+	}
+
+	ErrorCtx(ctx, w, err, fns...)
+}
+
+// ErrorCtx writes err into w, the error handler in ctx, see ContextWithErrorHandler,
+// is used in precedence over the one set by SetErrorHandler.
+func ErrorCtx(ctx context.Context, w http.ResponseWriter, err error,
+	fns ...func(w http.ResponseWriter, err error)) {
+	if handler, ok := errorHandlerFromContext(ctx); ok {
+		code, body := handler(ctx, err)
+		writeProblemBody(ctx, w, code, body)
+		return
+	}
+
 	lock.RLock()
 	handler := errorHandler
 	lock.RUnlock()
 
 	if handler == nil {
+		var verr *mapping.ValidationError
 		if len(fns) > 0 {
 			fns[0](w, err)
+		} else if isProblem(err) {
+			WriteProblem(w, ToProblem(ctx, err))
+		} else if errors.As(err, &verr) {
+			WriteJson(w, http.StatusBadRequest, verr)
 		} else {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 		}
@@ -29,17 +60,7 @@ func Error(w http.ResponseWriter, err error, fns ...func(w http.ResponseWriter,
 	}
 
 	code, body := handler(err)
-	if body == nil {
-		w.WriteHeader(code)
-		return
-	}
-
-	e, ok := body.(error)
-	if ok {
-		http.Error(w, e.Error(), code)
-	} else {
-		WriteJson(w, code, body)
-	}
+	writeErrorBody(w, code, body)
 }
 
 // Ok writes HTTP 200 OK into w.
@@ -52,6 +73,11 @@ func OkJson(w http.ResponseWriter, v interface{}) {
 	WriteJson(w, http.StatusOK, v)
 }
 
+// OkNegotiated writes v into w with 200 OK, encoded with the codec negotiated by the Accept header of r.
+func OkNegotiated(w http.ResponseWriter, r *http.Request, v interface{}) {
+	WriteNegotiated(w, r, http.StatusOK, v)
+}
+
 // SetErrorHandler sets the error handler, which is called on calling Error.
 func SetErrorHandler(handler func(error) (int, interface{})) {
 	lock.Lock()
@@ -66,7 +92,89 @@ func WriteJson(w http.ResponseWriter, code int, v interface{}) {
 
 	if bs, err := json.Marshal(v); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-	} else if n, err := w.Write(bs); err != nil {
+	} else {
+		writeBytes(w, bs)
+	}
+}
+
+// WriteNegotiated writes v into w with code, encoded with the codec negotiated
+// by the Accept header of r, see RegisterCodec. The json codec is used if no Accept header.
+// The codecs returning ErrUnsupportedValue are skipped, like protobuf on the values
+// not proto.Message, and 406 Not Acceptable is written if no acceptable codec supports v.
+// The other marshaling errors are logged and written as 500 Internal Server Error.
+func WriteNegotiated(w http.ResponseWriter, r *http.Request, code int, v interface{}) {
+	w.Header().Add(varyHeader, Accept)
+	for _, c := range negotiate(r.Header.Get(Accept)) {
+		bs, err := c.codec.Marshal(v)
+		if errors.Is(err, ErrUnsupportedValue) {
+			continue
+		}
+		if err != nil {
+			logx.WithContext(r.Context()).Errorf("fail to marshal response with %s: %s", c.contentType, err)
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set(ContentType, c.contentType)
+		w.WriteHeader(code)
+		writeBytes(w, bs)
+		return
+	}
+
+	http.Error(w, http.StatusText(http.StatusNotAcceptable), http.StatusNotAcceptable)
+}
+
+// writeErrorBody writes the body returned by the error handler set by SetErrorHandler.
+func writeErrorBody(w http.ResponseWriter, code int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(code)
+		return
+	}
+
+	e, ok := body.(error)
+	if ok {
+		http.Error(w, e.Error(), code)
+	} else {
+		WriteJson(w, code, body)
+	}
+}
+
+// writeProblemBody writes the body returned by the error handler in ctx,
+// the Problems and the errors with codes are written as problem+json.
+func writeProblemBody(ctx context.Context, w http.ResponseWriter, code int, body interface{}) {
+	if body == nil {
+		w.WriteHeader(code)
+		return
+	}
+
+	switch v := body.(type) {
+	case Problem:
+		writeProblemWithCode(w, code, v)
+	case *Problem:
+		writeProblemWithCode(w, code, *v)
+	case error:
+		if isProblem(v) {
+			p := ToProblem(ctx, v)
+			p.Title = ""
+			writeProblemWithCode(w, code, p)
+		} else {
+			http.Error(w, v.Error(), code)
+		}
+	default:
+		WriteJson(w, code, body)
+	}
+}
+
+func writeProblemWithCode(w http.ResponseWriter, code int, p Problem) {
+	p.Status = code
+	if len(p.Title) == 0 {
+		p.Title = http.StatusText(code)
+	}
+	WriteProblem(w, p)
+}
+
+func writeBytes(w http.ResponseWriter, bs []byte) {
+	if n, err := w.Write(bs); err != nil {
 		// http.ErrHandlerTimeout has been handled by http.TimeoutHandler,
 		// so it's ignored here.
 		if err != http.ErrHandlerTimeout {
@@ -1,13 +1,20 @@
 package httpx
 
 import (
+	"context"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/mapping"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
 )
 
 type message struct {
@@ -59,6 +66,16 @@ func TestError(t *testing.T) {
 			expectBody:    body,
 			expectCode:    http.StatusForbidden,
 		},
+		{
+			name:  "customized error handler return grpc error",
+			input: body,
+			errorHandler: func(err error) (int, interface{}) {
+				return http.StatusForbidden, status.Error(codes.PermissionDenied, err.Error())
+			},
+			expectHasBody: true,
+			expectBody:    "rpc error: code = PermissionDenied desc = foo",
+			expectCode:    http.StatusForbidden,
+		},
 		{
 			name:  "customized error handler return nil",
 			input: body,
@@ -107,6 +124,126 @@ func TestErrorWithHandler(t *testing.T) {
 	assert.Equal(t, "foo", strings.TrimSpace(w.builder.String()))
 }
 
+func TestErrorWithValidation(t *testing.T) {
+	w := tracedResponseWriter{
+		headers: make(map[string][]string),
+	}
+	Error(&w, &mapping.ValidationError{
+		Message: "validation failed",
+		Fields: []mapping.FieldError{
+			{Field: "name", Rule: "required", Message: "is required"},
+		},
+	})
+	assert.Equal(t, http.StatusBadRequest, w.code)
+	assert.Equal(t, ApplicationJson, w.headers[ContentType][0])
+	assert.Equal(t, `{"message":"validation failed","fields":[{"field":"name","rule":"required","message":"is required"}]}`,
+		w.builder.String())
+}
+
+func TestErrorWithProblem(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		code   int
+		expect string
+	}{
+		{
+			name:   "code error",
+			err:    NewCodeError(http.StatusNotFound, 1001, "user not found"),
+			code:   http.StatusNotFound,
+			expect: `{"title":"Not Found","status":404,"detail":"user not found","code":1001}`,
+		},
+		{
+			name:   "grpc status error",
+			err:    status.Error(codes.PermissionDenied, "denied"),
+			code:   http.StatusForbidden,
+			expect: `{"title":"Forbidden","status":403,"detail":"denied","code":7}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Error(w, test.err)
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, ApplicationProblemJson, w.Header().Get(ContentType))
+			assert.Equal(t, test.expect, w.Body.String())
+		})
+	}
+}
+
+func TestErrorCtx(t *testing.T) {
+	lock.RLock()
+	prev := errorHandler
+	lock.RUnlock()
+	SetErrorHandler(func(err error) (int, interface{}) {
+		return http.StatusTeapot, nil
+	})
+	defer func() {
+		lock.Lock()
+		errorHandler = prev
+		lock.Unlock()
+	}()
+
+	t.Run("global handler", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		ErrorCtx(context.Background(), w, errors.New("foo"))
+		assert.Equal(t, http.StatusTeapot, w.Code)
+	})
+
+	t.Run("context handler", func(t *testing.T) {
+		ctx := ContextWithErrorHandler(context.Background(), func(ctx context.Context, err error) (int, interface{}) {
+			return http.StatusUnprocessableEntity, err
+		})
+		w := httptest.NewRecorder()
+		ErrorCtx(ctx, w, NewCodeError(http.StatusBadRequest, 1001, "bad"))
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+		assert.Equal(t, ApplicationProblemJson, w.Header().Get(ContentType))
+		assert.Equal(t, `{"title":"Unprocessable Entity","status":422,"detail":"bad","code":1001}`,
+			w.Body.String())
+	})
+
+	t.Run("context handler returns problem", func(t *testing.T) {
+		ctx := ContextWithErrorHandler(context.Background(), func(ctx context.Context, err error) (int, interface{}) {
+			return http.StatusConflict, &Problem{Type: "https://example.com/conflict", Detail: err.Error()}
+		})
+		w := httptest.NewRecorder()
+		ErrorCtx(ctx, w, errors.New("foo"))
+		assert.Equal(t, http.StatusConflict, w.Code)
+		assert.Equal(t, ApplicationProblemJson, w.Header().Get(ContentType))
+		assert.Equal(t, `{"type":"https://example.com/conflict","title":"Conflict","status":409,"detail":"foo"}`,
+			w.Body.String())
+	})
+
+	t.Run("context handler returns plain error", func(t *testing.T) {
+		ctx := ContextWithErrorHandler(context.Background(), func(ctx context.Context, err error) (int, interface{}) {
+			return http.StatusForbidden, err
+		})
+		w := httptest.NewRecorder()
+		ErrorCtx(ctx, w, errors.New("foo"))
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Equal(t, "foo", strings.TrimSpace(w.Body.String()))
+	})
+}
+
+func TestErrorWithContextWriter(t *testing.T) {
+	ctx := ContextWithErrorHandler(context.Background(), func(ctx context.Context, err error) (int, interface{}) {
+		return http.StatusUnprocessableEntity, err
+	})
+	recorder := httptest.NewRecorder()
+	w := ResponseWriterWithContext(recorder, ctx)
+	Error(w, NewCodeError(http.StatusBadRequest, 1001, "bad"))
+	assert.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+	assert.Equal(t, ApplicationProblemJson, recorder.Header().Get(ContentType))
+	assert.Equal(t, `{"title":"Unprocessable Entity","status":422,"detail":"bad","code":1001}`,
+		recorder.Body.String())
+
+	_, ok := w.(http.Flusher)
+	assert.True(t, ok)
+	_, _, err := w.(http.Hijacker).Hijack()
+	assert.NotNil(t, err)
+}
+
 func TestOk(t *testing.T) {
 	w := tracedResponseWriter{
 		headers: make(map[string][]string),
@@ -146,6 +283,89 @@ func TestWriteJsonLessWritten(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.code)
 }
 
+func TestWriteNegotiated(t *testing.T) {
+	tests := []struct {
+		name        string
+		accept      string
+		value       interface{}
+		code        int
+		contentType string
+		body        string
+	}{
+		{
+			name:        "default json",
+			code:        http.StatusOK,
+			contentType: ApplicationJson,
+			body:        `{"name":"anyone"}`,
+		},
+		{
+			name:        "xml",
+			accept:      "application/xml",
+			code:        http.StatusOK,
+			contentType: ApplicationXml,
+			body:        `<message><Name>anyone</Name></message>`,
+		},
+		{
+			name:   "not acceptable",
+			accept: "text/html",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			name:   "not proto message",
+			accept: "application/x-protobuf",
+			code:   http.StatusNotAcceptable,
+		},
+		{
+			name:   "marshal error",
+			accept: "application/json",
+			value:  map[string]interface{}{"ch": make(chan int)},
+			code:   http.StatusInternalServerError,
+		},
+		{
+			name:        "skip the codecs unable to marshal",
+			accept:      "application/x-protobuf, application/xml;q=0.5",
+			code:        http.StatusOK,
+			contentType: ApplicationXml,
+			body:        `<message><Name>anyone</Name></message>`,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(test.accept) > 0 {
+				r.Header.Set(Accept, test.accept)
+			}
+			w := httptest.NewRecorder()
+			var v interface{} = message{Name: "anyone"}
+			if test.value != nil {
+				v = test.value
+			}
+			WriteNegotiated(w, r, http.StatusOK, v)
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, Accept, w.Header().Get("Vary"))
+			if test.code == http.StatusOK {
+				assert.Equal(t, test.contentType, w.Header().Get(ContentType))
+				assert.Equal(t, test.body, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestOkNegotiated(t *testing.T) {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(Accept, "application/x-protobuf, application/json;q=0.9")
+	w := httptest.NewRecorder()
+	OkNegotiated(w, r, wrapperspb.String("anyone"))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, ApplicationXProtobuf, w.Header().Get(ContentType))
+
+	var val wrapperspb.StringValue
+	assert.Nil(t, proto.Unmarshal(w.Body.Bytes(), &val))
+	assert.Equal(t, "anyone", val.GetValue())
+}
+
 type tracedResponseWriter struct {
 	headers     map[string][]string
 	builder     strings.Builder
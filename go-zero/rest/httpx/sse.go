@@ -0,0 +1,53 @@
+package httpx
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// An Event is a Server-Sent Event.
+type Event struct {
+	Id    string
+	Event string
+	Data  string
+	Retry time.Duration
+}
+
+// WriteEvent writes e into w in the text/event-stream format,
+// and flushes it to the client if w supports flushing.
+func WriteEvent(w http.ResponseWriter, e Event) error {
+	var buf bytes.Buffer
+	if len(e.Id) > 0 {
+		writeEventField(&buf, "id", e.Id)
+	}
+	if len(e.Event) > 0 {
+		writeEventField(&buf, "event", e.Event)
+	}
+	if e.Retry > 0 {
+		writeEventField(&buf, "retry", strconv.FormatInt(int64(e.Retry/time.Millisecond), 10))
+	}
+	for _, line := range strings.Split(e.Data, "\n") {
+		writeEventField(&buf, "data", line)
+	}
+	buf.WriteByte('\n')
+
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return err
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+func writeEventField(buf *bytes.Buffer, name, value string) {
+	buf.WriteString(name)
+	buf.WriteString(": ")
+	buf.WriteString(strings.TrimSuffix(value, "\r"))
+	buf.WriteByte('\n')
+}
@@ -0,0 +1,29 @@
+package httpx
+
+import (
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteEvent(t *testing.T) {
+	w := httptest.NewRecorder()
+	assert.Nil(t, WriteEvent(w, Event{
+		Id:    "1",
+		Event: "order",
+		Data:  "line1\nline2",
+		Retry: time.Second,
+	}))
+	assert.Equal(t, "id: 1\nevent: order\nretry: 1000\ndata: line1\ndata: line2\n\n", w.Body.String())
+	assert.True(t, w.Flushed)
+}
+
+func TestWriteEventError(t *testing.T) {
+	w := tracedResponseWriter{
+		headers: make(map[string][]string),
+		timeout: true,
+	}
+	assert.NotNil(t, WriteEvent(&w, Event{Data: "foo"}))
+}
@@ -1,8 +1,22 @@
 package httpx
 
 const (
+	// Accept means Accept.
+	Accept = "Accept"
 	// ApplicationJson means application/json.
 	ApplicationJson = "application/json"
+	// ApplicationMsgpack means application/msgpack.
+	ApplicationMsgpack = "application/msgpack"
+	// ApplicationProblemJson means application/problem+json.
+	ApplicationProblemJson = "application/problem+json"
+	// ApplicationProtobuf means application/protobuf.
+	ApplicationProtobuf = "application/protobuf"
+	// ApplicationXml means application/xml.
+	ApplicationXml = "application/xml"
+	// ApplicationXMsgpack means application/x-msgpack.
+	ApplicationXMsgpack = "application/x-msgpack"
+	// ApplicationXProtobuf means application/x-protobuf.
+	ApplicationXProtobuf = "application/x-protobuf"
 	// ContentEncoding means Content-Encoding.
 	ContentEncoding = "Content-Encoding"
 	// ContentSecurity means X-Content-Security.
@@ -13,6 +27,10 @@ const (
 	KeyField = "key"
 	// SecretField means secret.
 	SecretField = "secret"
+	// TextEventStream means text/event-stream.
+	TextEventStream = "text/event-stream"
+	// TextXml means text/xml.
+	TextXml = "text/xml"
 	// TypeField means type.
 	TypeField = "type"
 	// CryptionType means cryption.
@@ -0,0 +1,40 @@
+package rest
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var errIdempotencyUserKey = errors.New("UserKey is required to identify the users for idempotency")
+
+// idempotencyUserFunc returns the func to get the users from the requests for idempotency.
+// The client ips are not allowed, because the users behind the same NAT or proxy would share
+// the keys and get the responses of each other. The empty user is returned if the claim
+// or header is missing, and the request is processed without idempotency.
+func idempotencyUserFunc(c IdempotencyConf) (func(r *http.Request) string, error) {
+	if len(c.UserKey) == 0 {
+		return nil, errIdempotencyUserKey
+	}
+
+	switch c.UserBy {
+	case "", rateLimitByJwt:
+		return func(r *http.Request) string {
+			if val := r.Context().Value(c.UserKey); val != nil {
+				return rateLimitByJwt + ":" + fmt.Sprint(val)
+			}
+
+			return ""
+		}, nil
+	case rateLimitByHeader:
+		return func(r *http.Request) string {
+			if val := r.Header.Get(c.UserKey); len(val) > 0 {
+				return rateLimitByHeader + ":" + val
+			}
+
+			return ""
+		}, nil
+	default:
+		return nil, fmt.Errorf("idempotency users can't be identified by %q, use jwt or header", c.UserBy)
+	}
+}
@@ -0,0 +1,80 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdempotencyUserFunc(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   IdempotencyConf
+		header string
+		claim  interface{}
+		expect string
+		err    bool
+	}{
+		{
+			name:   "jwt by default",
+			conf:   IdempotencyConf{UserKey: "uid"},
+			claim:  123,
+			expect: "jwt:123",
+		},
+		{
+			name:   "jwt",
+			conf:   IdempotencyConf{UserBy: rateLimitByJwt, UserKey: "uid"},
+			claim:  "kevin",
+			expect: "jwt:kevin",
+		},
+		{
+			name: "jwt missing",
+			conf: IdempotencyConf{UserBy: rateLimitByJwt, UserKey: "uid"},
+		},
+		{
+			name:   "header",
+			conf:   IdempotencyConf{UserBy: rateLimitByHeader, UserKey: "X-User"},
+			header: "kevin",
+			expect: "header:kevin",
+		},
+		{
+			name: "header missing",
+			conf: IdempotencyConf{UserBy: rateLimitByHeader, UserKey: "X-User"},
+		},
+		{
+			name: "ip",
+			conf: IdempotencyConf{UserBy: rateLimitByIp, UserKey: "X-User"},
+			err:  true,
+		},
+		{
+			name: "no user key",
+			conf: IdempotencyConf{UserBy: rateLimitByHeader},
+			err:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			fn, err := idempotencyUserFunc(test.conf)
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			r := httptest.NewRequest(http.MethodPost, "/", nil)
+			r.RemoteAddr = "192.0.2.1:1234"
+			if len(test.header) > 0 {
+				r.Header.Set("X-User", test.header)
+			}
+			if test.claim != nil {
+				r = r.WithContext(context.WithValue(r.Context(), "uid", test.claim))
+			}
+			assert.Equal(t, test.expect, fn(r))
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wildcard  = "*"
+	separator = ", "
+)
+
+var (
+	// ErrCredentialsWithAllOrigins is an error that indicates the credentials are allowed for all origins,
+	// which lets any site send the requests with the cookies of the users.
+	ErrCredentialsWithAllOrigins = errors.New("cors: Credentials requires explicit Origins, not empty or *")
+
+	defaultMethods = []string{
+		http.MethodGet, http.MethodHead, http.MethodPost,
+		http.MethodPatch, http.MethodPut, http.MethodDelete,
+	}
+	defaultHeaders = []string{
+		"Content-Type", "Origin", "X-CSRF-Token", "Authorization", "AccessToken", "Token", "Range",
+	}
+)
+
+type (
+	// Options defines a CORS policy.
+	// Origins are the allowed origins, like https://foo.com, or the wildcard subdomain patterns,
+	// like https://*.foo.com, all origins are allowed if empty or with *.
+	// Methods and Headers are the allowed methods and request headers, the defaults are used if empty,
+	// all the request headers are allowed with *.
+	// ExposeHeaders are the response headers that the browsers are allowed to access.
+	// Credentials allows the requests with credentials, like cookies, the Origins are required then.
+	// MaxAge is how long the preflight results can be cached, not set if not positive.
+	Options struct {
+		Origins       []string
+		Methods       []string
+		Headers       []string
+		ExposeHeaders []string
+		Credentials   bool
+		MaxAge        time.Duration
+	}
+
+	// A Policy applies the CORS Options on the requests.
+	Policy struct {
+		allOrigins    bool
+		origins       []string
+		patterns      []originPattern
+		methods       []string
+		methodsVal    string
+		allHeaders    bool
+		headers       map[string]struct{}
+		headersVal    string
+		exposeHeaders string
+		credentials   bool
+		maxAge        string
+	}
+
+	originPattern struct {
+		prefix string
+		suffix string
+	}
+)
+
+// NewPolicy returns a Policy with opts, ErrCredentialsWithAllOrigins is returned
+// if Credentials is set without explicit Origins.
+func NewPolicy(opts Options) (*Policy, error) {
+	p := &Policy{
+		allOrigins:    len(opts.Origins) == 0,
+		headers:       make(map[string]struct{}),
+		exposeHeaders: strings.Join(opts.ExposeHeaders, separator),
+		credentials:   opts.Credentials,
+	}
+
+	for _, origin := range opts.Origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == wildcard:
+			p.allOrigins = true
+		case strings.Contains(origin, wildcard):
+			index := strings.Index(origin, wildcard)
+			p.patterns = append(p.patterns, originPattern{
+				prefix: origin[:index],
+				suffix: origin[index+len(wildcard):],
+			})
+		default:
+			p.origins = append(p.origins, origin)
+		}
+	}
+
+	methods := opts.Methods
+	if len(methods) == 0 {
+		methods = defaultMethods
+	}
+	for _, method := range methods {
+		p.methods = append(p.methods, strings.ToUpper(strings.TrimSpace(method)))
+	}
+	p.methodsVal = strings.Join(p.methods, separator)
+
+	headers := opts.Headers
+	if len(headers) == 0 {
+		headers = defaultHeaders
+	}
+	for _, header := range headers {
+		if header == wildcard {
+			p.allHeaders = true
+			continue
+		}
+		p.headers[strings.ToLower(header)] = struct{}{}
+	}
+	p.headersVal = strings.Join(headers, separator)
+
+	if opts.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(opts.MaxAge / time.Second))
+	}
+
+	if p.allOrigins && p.credentials {
+		return nil, ErrCredentialsWithAllOrigins
+	}
+
+	return p, nil
+}
+
+// Handler is a middleware that adds the CORS headers to the responses of the allowed origins,
+// and short-circuits the preflight requests.
+func (p *Policy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPreflight(r) {
+			p.ServeHTTP(w, r)
+			return
+		}
+
+		header := w.Header()
+		header.Add(varyHeader, originHeader)
+		if origin := r.Header.Get(originHeader); p.isOriginAllowed(origin) {
+			p.setOriginHeaders(header, origin)
+			if len(p.exposeHeaders) > 0 {
+				header.Set(exposeHeaders, p.exposeHeaders)
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ServeHTTP responds the preflight requests with 204, the CORS headers are set
+// only if the origin, method and headers are all allowed.
+func (p *Policy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	header := w.Header()
+	header.Add(varyHeader, originHeader)
+	header.Add(varyHeader, requestMethod)
+	header.Add(varyHeader, requestHeaders)
+
+	origin := r.Header.Get(originHeader)
+	reqHeaders := r.Header.Get(requestHeaders)
+	if p.isOriginAllowed(origin) && p.isMethodAllowed(r.Header.Get(requestMethod)) &&
+		p.areHeadersAllowed(reqHeaders) {
+		p.setOriginHeaders(header, origin)
+		header.Set(allowMethods, p.methodsVal)
+		if p.allHeaders && len(reqHeaders) > 0 {
+			// the browsers don't accept * with credentials, so echo the requested headers
+			header.Set(allowHeaders, reqHeaders)
+		} else if !p.allHeaders {
+			header.Set(allowHeaders, p.headersVal)
+		}
+		if len(p.maxAge) > 0 {
+			header.Set(maxAgeHeader, p.maxAge)
+		}
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Policy) areHeadersAllowed(reqHeaders string) bool {
+	if p.allHeaders {
+		return true
+	}
+
+	for _, header := range strings.Split(reqHeaders, ",") {
+		header = strings.ToLower(strings.TrimSpace(header))
+		if len(header) == 0 {
+			continue
+		}
+		if _, ok := p.headers[header]; !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *Policy) isMethodAllowed(method string) bool {
+	method = strings.ToUpper(method)
+	// the simple methods are always allowed by the browsers
+	if method == http.MethodGet || method == http.MethodHead || method == http.MethodPost {
+		return true
+	}
+
+	for _, m := range p.methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) isOriginAllowed(origin string) bool {
+	if len(origin) == 0 {
+		return false
+	}
+	if p.allOrigins {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	for _, o := range p.origins {
+		if o == origin {
+			return true
+		}
+	}
+
+	for _, pattern := range p.patterns {
+		if len(origin) > len(pattern.prefix)+len(pattern.suffix) &&
+			strings.HasPrefix(origin, pattern.prefix) && strings.HasSuffix(origin, pattern.suffix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p *Policy) setOriginHeaders(header http.Header, origin string) {
+	if p.allOrigins {
+		header.Set(allowOrigin, allOrigins)
+	} else {
+		header.Set(allowOrigin, origin)
+	}
+
+	if p.credentials {
+		header.Set(allowCredentials, allowTrue)
+	}
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && len(r.Header.Get(requestMethod)) > 0
+}
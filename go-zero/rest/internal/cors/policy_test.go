@@ -0,0 +1,221 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPolicy_Origins(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		origin  string
+		expect  string
+		allowed bool
+	}{
+		{
+			name:    "all origins",
+			origin:  "http://local",
+			expect:  allOrigins,
+			allowed: true,
+		},
+		{
+			name:    "exact origin",
+			opts:    Options{Origins: []string{"http://remote", "HTTP://Local"}},
+			origin:  "http://local",
+			expect:  "http://local",
+			allowed: true,
+		},
+		{
+			name:    "wildcard subdomain",
+			opts:    Options{Origins: []string{"https://*.foo.com"}},
+			origin:  "https://a.b.foo.com",
+			expect:  "https://a.b.foo.com",
+			allowed: true,
+		},
+		{
+			name:   "wildcard subdomain without subdomain",
+			opts:   Options{Origins: []string{"https://*.foo.com"}},
+			origin: "https://.foo.com",
+		},
+		{
+			name:   "wildcard subdomain with other domain",
+			opts:   Options{Origins: []string{"https://*.foo.com"}},
+			origin: "https://evilfoo.com",
+		},
+		{
+			name:   "not allowed",
+			opts:   Options{Origins: []string{"http://remote"}},
+			origin: "http://local",
+		},
+		{
+			name: "without origin",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var called bool
+			handler := newPolicyForTest(t, test.opts).Handler(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					called = true
+				}))
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if len(test.origin) > 0 {
+				r.Header.Set(originHeader, test.origin)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.True(t, called)
+			assert.Equal(t, test.expect, w.Header().Get(allowOrigin))
+			assert.Equal(t, []string{originHeader}, w.Header().Values(varyHeader))
+		})
+	}
+}
+
+func TestPolicy_Headers(t *testing.T) {
+	handler := newPolicyForTest(t, Options{
+		Origins:       []string{"http://local"},
+		ExposeHeaders: []string{"X-Total", "X-Request-Id"},
+		Credentials:   true,
+	}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set(originHeader, "http://local")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "http://local", w.Header().Get(allowOrigin))
+	assert.Equal(t, allowTrue, w.Header().Get(allowCredentials))
+	assert.Equal(t, "X-Total, X-Request-Id", w.Header().Get(exposeHeaders))
+	assert.Empty(t, w.Header().Get(allowMethods))
+}
+
+func TestPolicy_Preflight(t *testing.T) {
+	tests := []struct {
+		name         string
+		opts         Options
+		method       string
+		headers      string
+		allowed      bool
+		allowHeaders string
+		maxAge       string
+	}{
+		{
+			name:         "defaults",
+			method:       http.MethodPut,
+			headers:      "content-type, authorization",
+			allowed:      true,
+			allowHeaders: allowHeadersVal,
+		},
+		{
+			name: "custom",
+			opts: Options{
+				Methods: []string{"get", "patch"},
+				Headers: []string{"X-Custom"},
+				MaxAge:  time.Hour,
+			},
+			method:       http.MethodPatch,
+			headers:      "x-custom",
+			allowed:      true,
+			allowHeaders: "X-Custom",
+			maxAge:       "3600",
+		},
+		{
+			name:    "simple method",
+			opts:    Options{Methods: []string{http.MethodPut}},
+			method:  http.MethodPost,
+			allowed: true,
+			// the defaults
+			allowHeaders: allowHeadersVal,
+		},
+		{
+			name:   "method not allowed",
+			opts:   Options{Methods: []string{http.MethodPut}},
+			method: http.MethodDelete,
+		},
+		{
+			name:    "header not allowed",
+			method:  http.MethodPut,
+			headers: "X-Custom",
+		},
+		{
+			name:         "all headers",
+			opts:         Options{Headers: []string{"*"}},
+			method:       http.MethodPut,
+			headers:      "X-Custom, X-Another",
+			allowed:      true,
+			allowHeaders: "X-Custom, X-Another",
+		},
+		{
+			name:    "all headers without request headers",
+			opts:    Options{Headers: []string{"*"}},
+			method:  http.MethodPut,
+			allowed: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			handler := newPolicyForTest(t, test.opts).Handler(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					t.Fatal("preflight requests should be short-circuited")
+				}))
+			r := httptest.NewRequest(http.MethodOptions, "/", nil)
+			r.Header.Set(originHeader, "http://local")
+			r.Header.Set(requestMethod, test.method)
+			if len(test.headers) > 0 {
+				r.Header.Set(requestHeaders, test.headers)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, http.StatusNoContent, w.Code)
+			assert.Equal(t, []string{originHeader, requestMethod, requestHeaders},
+				w.Header().Values(varyHeader))
+			if !test.allowed {
+				assert.Empty(t, w.Header().Get(allowOrigin))
+				assert.Empty(t, w.Header().Get(allowMethods))
+				return
+			}
+
+			assert.Equal(t, allOrigins, w.Header().Get(allowOrigin))
+			assert.NotEmpty(t, w.Header().Get(allowMethods))
+			assert.Equal(t, test.allowHeaders, w.Header().Get(allowHeaders))
+			assert.Equal(t, test.maxAge, w.Header().Get(maxAgeHeader))
+		})
+	}
+}
+
+func TestNewPolicy_CredentialsWithAllOrigins(t *testing.T) {
+	for _, origins := range [][]string{nil, {"*"}, {"http://local", "*"}} {
+		_, err := NewPolicy(Options{Origins: origins, Credentials: true})
+		assert.Equal(t, ErrCredentialsWithAllOrigins, err)
+	}
+
+	_, err := NewPolicy(Options{Origins: []string{"https://*.foo.com"}, Credentials: true})
+	assert.Nil(t, err)
+}
+
+func TestPolicy_OptionsWithoutPreflight(t *testing.T) {
+	var called bool
+	handler := newPolicyForTest(t, Options{}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+	}))
+	r := httptest.NewRequest(http.MethodOptions, "/", nil)
+	r.Header.Set(originHeader, "http://local")
+	handler.ServeHTTP(httptest.NewRecorder(), r)
+	assert.True(t, called)
+}
+
+func newPolicyForTest(t *testing.T, opts Options) *Policy {
+	p, err := NewPolicy(opts)
+	assert.Nil(t, err)
+	return p
+}
@@ -0,0 +1,235 @@
+package fileserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	acceptEncoding  = "Accept-Encoding"
+	cacheControl    = "Cache-Control"
+	contentEncoding = "Content-Encoding"
+	etagHeader      = "ETag"
+	varyHeader      = "Vary"
+	gzipEncoding    = "gzip"
+	gzipExt         = ".gz"
+	defaultIndex    = "index.html"
+	noCache         = "no-cache"
+	etagHashLen     = 16
+)
+
+type (
+	// Options defines the options of a file server.
+	Options struct {
+		// IndexFile is the file to serve for directories, default to be index.html.
+		IndexFile string
+		// MaxAge is used in Cache-Control for the files, index files are never cached.
+		MaxAge time.Duration
+		// SPAFallback means to serve the root index file if the file not found,
+		// to let the single page applications do the routing.
+		SPAFallback bool
+	}
+
+	fileServer struct {
+		prefix string
+		fsys   fs.FS
+		opts   Options
+		// the etags of the files without modification time, like the files in embed.FS,
+		// which are immutable, so their etags can be cached.
+		etags sync.Map
+	}
+)
+
+// Middleware returns a middleware that serves the files in fsys on given path prefix.
+// The requests that are not GET or HEAD, or don't match any file, are passed to next.
+func Middleware(prefix string, fsys fs.FS, opts Options) func(http.Handler) http.Handler {
+	if len(opts.IndexFile) == 0 {
+		opts.IndexFile = defaultIndex
+	}
+
+	server := &fileServer{
+		prefix: path.Clean("/" + prefix),
+		fsys:   fsys,
+		opts:   opts,
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodGet && r.Method != http.MethodHead {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			name, ok := server.resolve(r.URL.Path)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if server.serveFile(w, r, name) {
+				return
+			}
+
+			if server.opts.SPAFallback && len(path.Ext(name)) == 0 &&
+				server.serveFile(w, r, server.opts.IndexFile) {
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (s *fileServer) resolve(reqPath string) (string, bool) {
+	reqPath = path.Clean("/" + reqPath)
+	if s.prefix != "/" {
+		if reqPath != s.prefix && !strings.HasPrefix(reqPath, s.prefix+"/") {
+			return "", false
+		}
+		reqPath = strings.TrimPrefix(reqPath, s.prefix)
+	}
+
+	name := strings.TrimPrefix(reqPath, "/")
+	if len(name) == 0 {
+		name = "."
+	}
+	if !fs.ValidPath(name) {
+		return "", false
+	}
+
+	return name, true
+}
+
+// serveFile serves the file with given name, returns false if no such file.
+func (s *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string) bool {
+	info, err := fs.Stat(s.fsys, name)
+	if err != nil {
+		return false
+	}
+
+	if info.IsDir() {
+		name = path.Join(name, s.opts.IndexFile)
+		if info, err = fs.Stat(s.fsys, name); err != nil || info.IsDir() {
+			return false
+		}
+	}
+
+	servedName := name
+	header := w.Header()
+	header.Add(varyHeader, acceptEncoding)
+	if acceptsGzip(r) {
+		if gzInfo, err := fs.Stat(s.fsys, name+gzipExt); err == nil && !gzInfo.IsDir() {
+			servedName = name + gzipExt
+			info = gzInfo
+			header.Set(contentEncoding, gzipEncoding)
+		}
+	}
+
+	content, err := s.open(servedName)
+	if err != nil {
+		header.Del(contentEncoding)
+		return false
+	}
+	defer content.Close()
+
+	etag, err := s.etag(servedName, info)
+	if err != nil {
+		header.Del(contentEncoding)
+		return false
+	}
+
+	header.Set(etagHeader, etag)
+	if path.Base(name) == s.opts.IndexFile || s.opts.MaxAge <= 0 {
+		header.Set(cacheControl, noCache)
+	} else {
+		header.Set(cacheControl, fmt.Sprintf("public, max-age=%d", int64(s.opts.MaxAge/time.Second)))
+	}
+
+	// use the original name to detect the content type, even if the gzipped one served.
+	http.ServeContent(w, r, path.Base(name), info.ModTime(), content)
+	return true
+}
+
+func (s *fileServer) etag(name string, info fs.FileInfo) (string, error) {
+	if !info.ModTime().IsZero() {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size()), nil
+	}
+
+	if val, ok := s.etags.Load(name); ok {
+		return val.(string), nil
+	}
+
+	content, err := fs.ReadFile(s.fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256(content)
+	etag := fmt.Sprintf(`"%s"`, hex.EncodeToString(sum[:])[:etagHashLen])
+	s.etags.Store(name, etag)
+	return etag, nil
+}
+
+// open opens the file with given name, and makes it seekable for range requests.
+func (s *fileServer) open(name string) (readSeekCloser, error) {
+	file, err := s.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if rsc, ok := file.(readSeekCloser); ok {
+		return rsc, nil
+	}
+
+	defer file.Close()
+	content, err := ioutil.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	return nopCloser{bytes.NewReader(content)}, nil
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, encoding := range strings.Split(r.Header.Get(acceptEncoding), ",") {
+		parts := strings.Split(encoding, ";")
+		if strings.TrimSpace(parts[0]) != gzipEncoding {
+			continue
+		}
+
+		for _, param := range parts[1:] {
+			if strings.ReplaceAll(param, " ", "") == "q=0" {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+type (
+	readSeekCloser interface {
+		io.ReadSeeker
+		io.Closer
+	}
+
+	nopCloser struct {
+		io.ReadSeeker
+	}
+)
+
+func (nopCloser) Close() error {
+	return nil
+}
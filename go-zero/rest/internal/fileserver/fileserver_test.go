@@ -0,0 +1,229 @@
+package fileserver
+
+import (
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	modTime := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+	fsys := fstest.MapFS{
+		"index.html":        {Data: []byte("<html>index</html>")},
+		"app.js":            {Data: []byte("console.log('app')")},
+		"app.js.gz":         {Data: []byte("gzipped")},
+		"logo.txt":          {Data: []byte("0123456789"), ModTime: modTime},
+		"docs/index.html":   {Data: []byte("docs")},
+		"empty/placeholder": {Data: []byte("")},
+	}
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		header   map[string]string
+		opts     Options
+		code     int
+		body     string
+		encoding string
+		next     bool
+	}{
+		{
+			name:   "file",
+			method: http.MethodGet,
+			path:   "/static/app.js",
+			code:   http.StatusOK,
+			body:   "console.log('app')",
+		},
+		{
+			name:     "gzipped",
+			method:   http.MethodGet,
+			path:     "/static/app.js",
+			header:   map[string]string{acceptEncoding: "deflate, gzip"},
+			code:     http.StatusOK,
+			body:     "gzipped",
+			encoding: gzipEncoding,
+		},
+		{
+			name:   "gzip refused",
+			method: http.MethodGet,
+			path:   "/static/app.js",
+			header: map[string]string{acceptEncoding: "gzip;q=0"},
+			code:   http.StatusOK,
+			body:   "console.log('app')",
+		},
+		{
+			name:   "range",
+			method: http.MethodGet,
+			path:   "/static/logo.txt",
+			header: map[string]string{"Range": "bytes=2-4"},
+			code:   http.StatusPartialContent,
+			body:   "234",
+		},
+		{
+			name:   "not modified",
+			method: http.MethodGet,
+			path:   "/static/logo.txt",
+			header: map[string]string{"If-Modified-Since": modTime.Format(http.TimeFormat)},
+			code:   http.StatusNotModified,
+		},
+		{
+			name:   "root index",
+			method: http.MethodGet,
+			path:   "/static",
+			code:   http.StatusOK,
+			body:   "<html>index</html>",
+		},
+		{
+			name:   "dir index",
+			method: http.MethodGet,
+			path:   "/static/docs/",
+			code:   http.StatusOK,
+			body:   "docs",
+		},
+		{
+			name:   "dir without index",
+			method: http.MethodGet,
+			path:   "/static/empty",
+			next:   true,
+		},
+		{
+			name:   "not found",
+			method: http.MethodGet,
+			path:   "/static/orders/1",
+			next:   true,
+		},
+		{
+			name:   "spa fallback",
+			method: http.MethodGet,
+			path:   "/static/orders/1",
+			opts:   Options{SPAFallback: true},
+			code:   http.StatusOK,
+			body:   "<html>index</html>",
+		},
+		{
+			name:   "spa fallback with ext",
+			method: http.MethodGet,
+			path:   "/static/missing.js",
+			opts:   Options{SPAFallback: true},
+			next:   true,
+		},
+		{
+			name:   "other prefix",
+			method: http.MethodGet,
+			path:   "/staticfoo/app.js",
+			next:   true,
+		},
+		{
+			name:   "post",
+			method: http.MethodPost,
+			path:   "/static/app.js",
+			next:   true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var called bool
+			handler := Middleware("/static/", fsys, test.opts)(http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					called = true
+				}))
+			r := httptest.NewRequest(test.method, test.path, nil)
+			for k, v := range test.header {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			assert.Equal(t, test.next, called)
+			if test.next {
+				return
+			}
+
+			assert.Equal(t, test.code, w.Code)
+			assert.Equal(t, test.body, w.Body.String())
+			assert.Equal(t, test.encoding, w.Header().Get(contentEncoding))
+			assert.Equal(t, acceptEncoding, w.Header().Get(varyHeader))
+		})
+	}
+}
+
+func TestMiddleware_ETag(t *testing.T) {
+	fsys := fstest.MapFS{
+		"app.js": {Data: []byte("app")},
+	}
+	handler := Middleware("/", fsys, Options{
+		MaxAge: time.Hour,
+	})(http.NotFoundHandler())
+
+	r := httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "public, max-age=3600", w.Header().Get(cacheControl))
+	assert.True(t, strings.Contains(w.Header().Get("Content-Type"), "javascript"))
+	etag := w.Header().Get(etagHeader)
+	assert.NotEmpty(t, etag)
+
+	r = httptest.NewRequest(http.MethodGet, "/app.js", nil)
+	r.Header.Set("If-None-Match", etag)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotModified, w.Code)
+
+	r = httptest.NewRequest(http.MethodGet, "/index.html", nil)
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestMiddleware_NotSeekable(t *testing.T) {
+	handler := Middleware("/", unseekableFS{fstest.MapFS{
+		"a.txt": {Data: []byte("abcdef")},
+	}}, Options{})(http.NotFoundHandler())
+
+	r := httptest.NewRequest(http.MethodGet, "/a.txt", nil)
+	r.Header.Set("Range", "bytes=1-2")
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusPartialContent, w.Code)
+	assert.Equal(t, "bc", w.Body.String())
+}
+
+type (
+	unseekableFS struct {
+		fsys fs.FS
+	}
+
+	unseekableFile struct {
+		file fs.File
+	}
+)
+
+func (u unseekableFS) Open(name string) (fs.File, error) {
+	file, err := u.fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return unseekableFile{file: file}, nil
+}
+
+func (u unseekableFile) Stat() (fs.FileInfo, error) {
+	return u.file.Stat()
+}
+
+func (u unseekableFile) Read(bs []byte) (int, error) {
+	return u.file.Read(bs)
+}
+
+func (u unseekableFile) Close() error {
+	return u.file.Close()
+}
@@ -0,0 +1,253 @@
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+)
+
+const (
+	openApiVersion   = "3.0.3"
+	defaultVersion   = "1.0.0"
+	jwtScheme        = "jwt"
+	applicationJson  = "application/json"
+	multipartForm    = "multipart/form-data"
+	textEventStream  = "text/event-stream"
+	successCode      = "200"
+	successDesc      = "OK"
+	paramStartSymbol = ':'
+	catchAllSymbol   = '*'
+	leftBrace        = '{'
+	rightBrace       = '}'
+)
+
+type (
+	// A RouteInfo describes a registered route.
+	RouteInfo struct {
+		Method    string `json:"method"`
+		Path      string `json:"path"`
+		Jwt       bool   `json:"jwt"`
+		Signature bool   `json:"signature"`
+		Priority  bool   `json:"priority"`
+		SSE       bool   `json:"sse"`
+		WebSocket bool   `json:"websocket"`
+		// Timeout is the effective timeout, like 3s, empty means not limited.
+		Timeout  string      `json:"timeout,omitempty"`
+		Request  interface{} `json:"-"`
+		Response interface{} `json:"-"`
+	}
+
+	// A Document is an OpenAPI document.
+	Document struct {
+		OpenApi    string                          `json:"openapi"`
+		Info       Info                            `json:"info"`
+		Paths      map[string]map[string]Operation `json:"paths"`
+		Components *Components                     `json:"components,omitempty"`
+	}
+
+	// Info is the metadata of a Document.
+	Info struct {
+		Title   string `json:"title"`
+		Version string `json:"version"`
+	}
+
+	// An Operation describes an api operation on a path.
+	Operation struct {
+		Parameters  []Parameter            `json:"parameters,omitempty"`
+		RequestBody *RequestBody           `json:"requestBody,omitempty"`
+		Responses   map[string]Response    `json:"responses"`
+		Security    []map[string][]string  `json:"security,omitempty"`
+		Extensions  map[string]interface{} `json:"x-go-zero,omitempty"`
+	}
+
+	// A Parameter is a path, query or header parameter.
+	Parameter struct {
+		Name     string  `json:"name"`
+		In       string  `json:"in"`
+		Required bool    `json:"required,omitempty"`
+		Schema   *Schema `json:"schema"`
+	}
+
+	// A RequestBody is the body of a request.
+	RequestBody struct {
+		Required bool                 `json:"required,omitempty"`
+		Content  map[string]MediaType `json:"content"`
+	}
+
+	// A Response describes a response.
+	Response struct {
+		Description string               `json:"description"`
+		Content     map[string]MediaType `json:"content,omitempty"`
+	}
+
+	// A MediaType holds the schema of a content type.
+	MediaType struct {
+		Schema *Schema `json:"schema"`
+	}
+
+	// Components holds the reusable objects.
+	Components struct {
+		SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+	}
+
+	// A SecurityScheme describes an authentication method.
+	SecurityScheme struct {
+		Type         string `json:"type"`
+		Scheme       string `json:"scheme"`
+		BearerFormat string `json:"bearerFormat,omitempty"`
+	}
+)
+
+// NewDocument returns an OpenAPI document that describes the routes.
+func NewDocument(title string, routes []RouteInfo) *Document {
+	doc := &Document{
+		OpenApi: openApiVersion,
+		Info: Info{
+			Title:   title,
+			Version: defaultVersion,
+		},
+		Paths: make(map[string]map[string]Operation),
+	}
+
+	var withJwt bool
+	for _, route := range routes {
+		path, pathParams := convertPath(route.Path)
+		ops, ok := doc.Paths[path]
+		if !ok {
+			ops = make(map[string]Operation)
+			doc.Paths[path] = ops
+		}
+
+		ops[strings.ToLower(route.Method)] = newOperation(route, pathParams)
+		withJwt = withJwt || route.Jwt
+	}
+
+	if withJwt {
+		doc.Components = &Components{
+			SecuritySchemes: map[string]SecurityScheme{
+				jwtScheme: {
+					Type:         "http",
+					Scheme:       "bearer",
+					BearerFormat: "JWT",
+				},
+			},
+		}
+	}
+
+	return doc
+}
+
+// convertPath converts /users/:id to /users/{id}, and returns the path parameters.
+// The constraints like :id{int} are dropped, and the catch-all segments like *path
+// are converted to {path} too.
+func convertPath(path string) (string, []string) {
+	var params []string
+	segments := splitPath(path)
+	for i, segment := range segments {
+		if len(segment) == 0 || (segment[0] != paramStartSymbol && segment[0] != catchAllSymbol) {
+			continue
+		}
+
+		name := segment[1:]
+		if index := strings.IndexByte(name, leftBrace); index >= 0 {
+			name = name[:index]
+		}
+		params = append(params, name)
+		segments[i] = "{" + name + "}"
+	}
+
+	return strings.Join(segments, "/"), params
+}
+
+// splitPath splits the path by slashes, the slashes in constraints like :id{[^/]+} are kept.
+func splitPath(path string) []string {
+	var segments []string
+	var depth, start int
+	for i := 0; i < len(path); i++ {
+		switch path[i] {
+		case leftBrace:
+			depth++
+		case rightBrace:
+			depth--
+		case '/':
+			if depth == 0 {
+				segments = append(segments, path[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(segments, path[start:])
+}
+
+func newOperation(route RouteInfo, pathParams []string) Operation {
+	op := Operation{
+		Responses: map[string]Response{
+			successCode: {
+				Description: successDesc,
+			},
+		},
+		Extensions: map[string]interface{}{
+			"signature": route.Signature,
+			"priority":  route.Priority,
+		},
+	}
+	if len(route.Timeout) > 0 {
+		op.Extensions["timeout"] = route.Timeout
+	}
+	if route.Jwt {
+		op.Security = []map[string][]string{{jwtScheme: {}}}
+	}
+
+	declared := make(map[string]bool)
+	if route.Request != nil {
+		params, body := requestSchemas(route.Request)
+		for _, param := range params {
+			declared[param.In+param.Name] = true
+		}
+		op.Parameters = params
+		if body != nil && route.Method != http.MethodGet && route.Method != http.MethodHead {
+			contentType := applicationJson
+			if hasFiles(body) {
+				contentType = multipartForm
+			}
+			op.RequestBody = &RequestBody{
+				Required: len(body.Required) > 0,
+				Content: map[string]MediaType{
+					contentType: {Schema: body},
+				},
+			}
+		}
+	}
+
+	for _, name := range pathParams {
+		if declared[inPath+name] {
+			continue
+		}
+
+		op.Parameters = append(op.Parameters, Parameter{
+			Name:     name,
+			In:       inPath,
+			Required: true,
+			Schema:   &Schema{Type: typeString},
+		})
+	}
+	sort.SliceStable(op.Parameters, func(i, j int) bool {
+		return op.Parameters[i].In == inPath && op.Parameters[j].In != inPath
+	})
+
+	if route.Response != nil {
+		contentType := applicationJson
+		if route.SSE {
+			contentType = textEventStream
+		}
+		op.Responses[successCode] = Response{
+			Description: successDesc,
+			Content: map[string]MediaType{
+				contentType: {Schema: SchemaOf(route.Response)},
+			},
+		}
+	}
+
+	return op
+}
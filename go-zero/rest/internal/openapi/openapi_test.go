@@ -0,0 +1,138 @@
+package openapi
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertPath(t *testing.T) {
+	tests := []struct {
+		path   string
+		expect string
+		params []string
+	}{
+		{
+			path:   "/",
+			expect: "/",
+		},
+		{
+			path:   "/users/:id",
+			expect: "/users/{id}",
+			params: []string{"id"},
+		},
+		{
+			path:   "/users/:id{[0-9]+}/files/*path",
+			expect: "/users/{id}/files/{path}",
+			params: []string{"id", "path"},
+		},
+		{
+			path:   "/users/:id{[^/]+}/books",
+			expect: "/users/{id}/books",
+			params: []string{"id"},
+		},
+		{
+			path:   "/users/:id/books/:book",
+			expect: "/users/{id}/books/{book}",
+			params: []string{"id", "book"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.path, func(t *testing.T) {
+			path, params := convertPath(test.path)
+			assert.Equal(t, test.expect, path)
+			assert.Equal(t, test.params, params)
+		})
+	}
+}
+
+func TestNewDocument(t *testing.T) {
+	type (
+		request struct {
+			Id    int    `path:"id"`
+			Page  int    `form:"page,default=1"`
+			Token string `header:"token"`
+			Name  string `json:"name"`
+		}
+		response struct {
+			Id int `json:"id"`
+		}
+	)
+
+	doc := NewDocument("foo", []RouteInfo{
+		{
+			Method:    http.MethodPut,
+			Path:      "/users/:id",
+			Jwt:       true,
+			Signature: true,
+			Timeout:   "3s",
+			Request:   request{},
+			Response:  &response{},
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/users/:id/books/:book",
+		},
+		{
+			Method:   http.MethodGet,
+			Path:     "/events",
+			SSE:      true,
+			Response: response{},
+		},
+	})
+
+	assert.Equal(t, openApiVersion, doc.OpenApi)
+	assert.Equal(t, "foo", doc.Info.Title)
+	assert.Contains(t, doc.Components.SecuritySchemes, jwtScheme)
+
+	op := doc.Paths["/users/{id}"]["put"]
+	assert.Equal(t, []Parameter{
+		{
+			Name:     "id",
+			In:       inPath,
+			Required: true,
+			Schema:   &Schema{Type: typeInteger},
+		},
+		{
+			Name:   "page",
+			In:     inQuery,
+			Schema: &Schema{Type: typeInteger, Default: "1"},
+		},
+		{
+			Name:     "token",
+			In:       inHeader,
+			Required: true,
+			Schema:   &Schema{Type: typeString},
+		},
+	}, op.Parameters)
+	assert.True(t, op.RequestBody.Required)
+	assert.Equal(t, typeString, op.RequestBody.Content[applicationJson].Schema.Properties["name"].Type)
+	assert.Equal(t, typeInteger,
+		op.Responses[successCode].Content[applicationJson].Schema.Properties["id"].Type)
+	assert.Equal(t, []map[string][]string{{jwtScheme: {}}}, op.Security)
+	assert.Equal(t, true, op.Extensions["signature"])
+	assert.Equal(t, "3s", op.Extensions["timeout"])
+
+	op = doc.Paths["/users/{id}/books/{book}"]["get"]
+	assert.Equal(t, 2, len(op.Parameters))
+	assert.Equal(t, "book", op.Parameters[1].Name)
+	assert.Nil(t, op.RequestBody)
+	assert.Nil(t, op.Security)
+
+	op = doc.Paths["/events"]["get"]
+	assert.Contains(t, op.Responses[successCode].Content, textEventStream)
+}
+
+func TestNewDocumentWithoutJwt(t *testing.T) {
+	doc := NewDocument("foo", []RouteInfo{
+		{
+			Method: http.MethodGet,
+			Path:   "/",
+		},
+	})
+	assert.Nil(t, doc.Components)
+	assert.Contains(t, doc.Paths["/"], "get")
+}
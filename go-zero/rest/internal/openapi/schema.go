@@ -0,0 +1,326 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	inPath   = "path"
+	inQuery  = "query"
+	inHeader = "header"
+
+	typeArray   = "array"
+	typeBoolean = "boolean"
+	typeInteger = "integer"
+	typeNumber  = "number"
+	typeObject  = "object"
+	typeString  = "string"
+
+	jsonTagKey   = "json"
+	formTagKey   = "form"
+	pathTagKey   = "path"
+	headerTagKey = "header"
+	fileTagKey   = "file"
+
+	formatBinary = "binary"
+
+	optionalOption = "optional"
+	optionsOption  = "options="
+	defaultOption  = "default="
+	rangeOption    = "range="
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(time.Duration(0))
+	bytesType    = reflect.TypeOf([]byte(nil))
+	rawJsonType  = reflect.TypeOf(json.RawMessage(nil))
+)
+
+// A Schema describes the data type of a value.
+type Schema struct {
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              string             `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+}
+
+// SchemaOf returns the schema of v, the fields are named by their json tags.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOfType(reflect.TypeOf(v), make(map[reflect.Type]bool))
+}
+
+// requestSchemas returns the parameters and the body schema of the request type v,
+// the fields with form, path and header tags are parameters, the others are in body.
+func requestSchemas(v interface{}) ([]Parameter, *Schema) {
+	tp := indirectType(reflect.TypeOf(v))
+	if tp.Kind() != reflect.Struct {
+		return nil, schemaOfType(tp, make(map[reflect.Type]bool))
+	}
+
+	var params []Parameter
+	body := &Schema{Type: typeObject}
+	visited := map[reflect.Type]bool{tp: true}
+	collectRequestFields(tp, &params, body, visited)
+	if len(body.Properties) == 0 {
+		return params, nil
+	}
+
+	return params, body
+}
+
+func collectRequestFields(tp reflect.Type, params *[]Parameter, body *Schema,
+	visited map[reflect.Type]bool) {
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup(fileTagKey); ok {
+			addFileProperty(body, field, tag)
+			continue
+		}
+
+		if in, tag, ok := parameterTag(field); ok {
+			name, opts := parseTag(tag, field.Name)
+			if name == "-" {
+				continue
+			}
+
+			schema := schemaOfType(field.Type, visited)
+			applyOptions(schema, opts)
+			*params = append(*params, Parameter{
+				Name:     name,
+				In:       in,
+				Required: in == inPath || !isOptional(field.Type, opts),
+				Schema:   schema,
+			})
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup(jsonTagKey)
+		if field.Anonymous && !hasTag && indirectType(field.Type).Kind() == reflect.Struct {
+			collectRequestFields(indirectType(field.Type), params, body, visited)
+			continue
+		}
+
+		addProperty(body, field, tag, visited)
+	}
+}
+
+func schemaOfType(tp reflect.Type, visited map[reflect.Type]bool) *Schema {
+	if tp == nil {
+		return &Schema{}
+	}
+
+	tp = indirectType(tp)
+	switch tp {
+	case timeType:
+		return &Schema{Type: typeString, Format: "date-time"}
+	case durationType:
+		return &Schema{Type: typeString, Format: "duration"}
+	case bytesType:
+		return &Schema{Type: typeString, Format: "byte"}
+	case rawJsonType:
+		return &Schema{}
+	}
+
+	switch tp.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: typeBoolean}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uintptr:
+		return &Schema{Type: typeInteger}
+	case reflect.Int32, reflect.Uint32:
+		return &Schema{Type: typeInteger, Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: typeInteger, Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: typeNumber, Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: typeNumber, Format: "double"}
+	case reflect.String:
+		return &Schema{Type: typeString}
+	case reflect.Slice, reflect.Array:
+		return &Schema{
+			Type:  typeArray,
+			Items: schemaOfType(tp.Elem(), visited),
+		}
+	case reflect.Map:
+		return &Schema{
+			Type:                 typeObject,
+			AdditionalProperties: schemaOfType(tp.Elem(), visited),
+		}
+	case reflect.Struct:
+		// recursive types are described as plain objects on the second visit.
+		if visited[tp] {
+			return &Schema{Type: typeObject}
+		}
+
+		visited[tp] = true
+		defer delete(visited, tp)
+
+		schema := &Schema{Type: typeObject}
+		collectProperties(tp, schema, visited)
+		return schema
+	default:
+		return &Schema{}
+	}
+}
+
+func collectProperties(tp reflect.Type, schema *Schema, visited map[reflect.Type]bool) {
+	for i := 0; i < tp.NumField(); i++ {
+		field := tp.Field(i)
+		if len(field.PkgPath) > 0 && !field.Anonymous {
+			continue
+		}
+
+		tag, hasTag := field.Tag.Lookup(jsonTagKey)
+		if field.Anonymous && !hasTag && indirectType(field.Type).Kind() == reflect.Struct {
+			collectProperties(indirectType(field.Type), schema, visited)
+			continue
+		}
+
+		addProperty(schema, field, tag, visited)
+	}
+}
+
+// addFileProperty adds the uploaded file field as a binary property of the multipart body.
+func addFileProperty(body *Schema, field reflect.StructField, tag string) {
+	name, opts := parseTag(tag, field.Name)
+	prop := &Schema{Type: typeString, Format: formatBinary}
+	if field.Type.Kind() == reflect.Slice {
+		prop = &Schema{Type: typeArray, Items: prop}
+	}
+	if body.Properties == nil {
+		body.Properties = make(map[string]*Schema)
+	}
+	body.Properties[name] = prop
+	// the file fields are pointers or slices, which are required unless optional
+	for _, opt := range opts {
+		if opt == optionalOption {
+			return
+		}
+	}
+	body.Required = append(body.Required, name)
+}
+
+func addProperty(schema *Schema, field reflect.StructField, tag string,
+	visited map[reflect.Type]bool) {
+	name, opts := parseTag(tag, field.Name)
+	if name == "-" {
+		return
+	}
+
+	prop := schemaOfType(field.Type, visited)
+	applyOptions(prop, opts)
+	if schema.Properties == nil {
+		schema.Properties = make(map[string]*Schema)
+	}
+	schema.Properties[name] = prop
+	if !isOptional(field.Type, opts) {
+		schema.Required = append(schema.Required, name)
+	}
+}
+
+func applyOptions(schema *Schema, opts []string) {
+	for _, opt := range opts {
+		switch {
+		case strings.HasPrefix(opt, optionsOption):
+			schema.Enum = strings.Split(opt[len(optionsOption):], "|")
+		case strings.HasPrefix(opt, defaultOption):
+			schema.Default = opt[len(defaultOption):]
+		case strings.HasPrefix(opt, rangeOption):
+			applyRange(schema, opt[len(rangeOption):])
+		}
+	}
+}
+
+// applyRange applies the range like [1:10] or (0:100], the exclusiveness is ignored.
+func applyRange(schema *Schema, val string) {
+	val = strings.Trim(val, "[]()")
+	bounds := strings.Split(val, ":")
+	if len(bounds) != 2 {
+		return
+	}
+
+	if min, err := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64); err == nil {
+		schema.Minimum = &min
+	}
+	if max, err := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64); err == nil {
+		schema.Maximum = &max
+	}
+}
+
+// hasFiles checks if the body has the uploaded files, which is sent as multipart form.
+func hasFiles(body *Schema) bool {
+	for _, prop := range body.Properties {
+		if prop.Format == formatBinary || prop.Items != nil && prop.Items.Format == formatBinary {
+			return true
+		}
+	}
+
+	return false
+}
+
+func indirectType(tp reflect.Type) reflect.Type {
+	for tp != nil && tp.Kind() == reflect.Ptr {
+		tp = tp.Elem()
+	}
+
+	return tp
+}
+
+func isOptional(tp reflect.Type, opts []string) bool {
+	if tp.Kind() == reflect.Ptr {
+		return true
+	}
+
+	for _, opt := range opts {
+		if opt == optionalOption || strings.HasPrefix(opt, defaultOption) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parameterTag(field reflect.StructField) (string, string, bool) {
+	if tag, ok := field.Tag.Lookup(pathTagKey); ok {
+		return inPath, tag, true
+	}
+	if tag, ok := field.Tag.Lookup(formTagKey); ok {
+		return inQuery, tag, true
+	}
+	if tag, ok := field.Tag.Lookup(headerTagKey); ok {
+		return inHeader, tag, true
+	}
+
+	return "", "", false
+}
+
+func parseTag(tag, fieldName string) (string, []string) {
+	segments := strings.Split(tag, ",")
+	name := strings.TrimSpace(segments[0])
+	if len(name) == 0 {
+		name = fieldName
+	}
+
+	opts := make([]string, 0, len(segments)-1)
+	for _, segment := range segments[1:] {
+		opts = append(opts, strings.TrimSpace(segment))
+	}
+
+	return name, opts
+}
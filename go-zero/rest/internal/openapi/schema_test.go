@@ -0,0 +1,111 @@
+package openapi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaOf(t *testing.T) {
+	type (
+		Base struct {
+			Id int64 `json:"id"`
+		}
+		node struct {
+			Base
+			Name     string         `json:"name,options=a|b"`
+			Age      int            `json:"age,range=[1:120]"`
+			Score    float64        `json:"score,optional"`
+			Nick     *string        `json:"nick"`
+			Created  time.Time      `json:"created"`
+			Tags     []string       `json:"tags,default=x"`
+			Attrs    map[string]int `json:"attrs,optional"`
+			Children []*node        `json:"children,optional"`
+			Ignored  string         `json:"-"`
+			Untagged bool
+			private  string
+		}
+	)
+
+	schema := SchemaOf(node{})
+	assert.Equal(t, typeObject, schema.Type)
+	assert.ElementsMatch(t, []string{"id", "name", "age", "created", "Untagged"}, schema.Required)
+	assert.Equal(t, &Schema{Type: typeInteger, Format: "int64"}, schema.Properties["id"])
+	assert.Equal(t, []string{"a", "b"}, schema.Properties["name"].Enum)
+	assert.Equal(t, 1.0, *schema.Properties["age"].Minimum)
+	assert.Equal(t, 120.0, *schema.Properties["age"].Maximum)
+	assert.Equal(t, &Schema{Type: typeNumber, Format: "double"}, schema.Properties["score"])
+	assert.Equal(t, &Schema{Type: typeString, Format: "date-time"}, schema.Properties["created"])
+	assert.Equal(t, "x", schema.Properties["tags"].Default)
+	assert.Equal(t, typeString, schema.Properties["tags"].Items.Type)
+	assert.Equal(t, typeInteger, schema.Properties["attrs"].AdditionalProperties.Type)
+	assert.Equal(t, &Schema{Type: typeObject}, schema.Properties["children"].Items)
+	assert.Equal(t, typeBoolean, schema.Properties["Untagged"].Type)
+	assert.NotContains(t, schema.Properties, "Ignored")
+	assert.NotContains(t, schema.Properties, "-")
+	assert.NotContains(t, schema.Properties, "private")
+}
+
+func TestSchemaOfBasicTypes(t *testing.T) {
+	assert.Equal(t, &Schema{}, SchemaOf(nil))
+	assert.Equal(t, &Schema{Type: typeString}, SchemaOf("a"))
+	assert.Equal(t, &Schema{Type: typeString, Format: "byte"}, SchemaOf([]byte("a")))
+	assert.Equal(t, &Schema{Type: typeString, Format: "duration"}, SchemaOf(time.Second))
+	assert.Equal(t, &Schema{Type: typeInteger, Format: "int32"}, SchemaOf(int32(1)))
+	assert.Equal(t, &Schema{Type: typeNumber, Format: "float"}, SchemaOf(float32(1)))
+	assert.Equal(t, &Schema{Type: typeArray, Items: &Schema{Type: typeInteger}}, SchemaOf([]int{1}))
+}
+
+func TestRequestSchemas(t *testing.T) {
+	type request struct {
+		Id    string `path:"id"`
+		Trace string `header:"X-Trace,optional"`
+	}
+
+	params, body := requestSchemas(&request{})
+	assert.Nil(t, body)
+	assert.Equal(t, []Parameter{
+		{
+			Name:     "id",
+			In:       inPath,
+			Required: true,
+			Schema:   &Schema{Type: typeString},
+		},
+		{
+			Name:   "X-Trace",
+			In:     inHeader,
+			Schema: &Schema{Type: typeString},
+		},
+	}, params)
+
+	params, body = requestSchemas([]string{})
+	assert.Nil(t, params)
+	assert.Equal(t, typeArray, body.Type)
+}
+
+func TestRequestSchemasWithFiles(t *testing.T) {
+	type file struct{}
+	type request struct {
+		Name   string  `form:"name"`
+		Avatar *file   `file:"avatar"`
+		Photos []*file `file:"photos,optional"`
+	}
+
+	params, body := requestSchemas(&request{})
+	assert.Len(t, params, 1)
+	assert.True(t, hasFiles(body))
+	assert.Equal(t, []string{"avatar"}, body.Required)
+	assert.Equal(t, &Schema{Type: typeString, Format: formatBinary}, body.Properties["avatar"])
+	assert.Equal(t, &Schema{
+		Type:  typeArray,
+		Items: &Schema{Type: typeString, Format: formatBinary},
+	}, body.Properties["photos"])
+
+	doc := NewDocument("foo", []RouteInfo{{
+		Method:  "POST",
+		Path:    "/upload",
+		Request: &request{},
+	}})
+	assert.Contains(t, doc.Paths["/upload"]["post"].RequestBody.Content, multipartForm)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"github.com/zeromicro/go-zero/core/health"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/proc"
 )
@@ -44,6 +45,8 @@ func start(host string, port int, handler http.Handler, run func(srv *http.Serve
 	}
 
 	waitForCalled := proc.AddWrapUpListener(func() { // 关闭监听器
+		// report not ready and wait for the load balancers to drain the traffic
+		health.Drain()
 		if e := server.Shutdown(context.Background()); err != nil {
 			logx.Error(e)
 		}
@@ -0,0 +1,77 @@
+package rest
+
+import (
+	"net/http"
+
+	"github.com/justinas/alice"
+	"github.com/zeromicro/go-zero/rest/handler"
+	"github.com/zeromicro/go-zero/rest/httpx"
+	"github.com/zeromicro/go-zero/rest/internal/openapi"
+)
+
+// bindIntrospection binds the endpoints that expose the registered routes,
+// it's called after binding the routes, to describe all of them.
+func (ng *engine) bindIntrospection(router httpx.Router) error {
+	c := ng.conf.Introspection
+	if !c.Enabled {
+		return nil
+	}
+
+	routes := ng.routeInfos()
+	doc := openapi.NewDocument(ng.conf.Name, routes)
+	endpoints := map[string]interface{}{
+		c.RoutesPath:  routes,
+		c.OpenApiPath: doc,
+	}
+	for path, resp := range endpoints {
+		if len(path) == 0 {
+			continue
+		}
+
+		chain := alice.New(
+			handler.RequestIdHandler,
+			handler.TracingHandler(ng.conf.Name, path),
+			ng.getLogHandler(),
+			handler.RecoverHandler,
+		)
+		if err := router.Handle(http.MethodGet, path, chain.Then(introspectionHandler(resp))); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (ng *engine) routeInfos() []openapi.RouteInfo {
+	infos := make([]openapi.RouteInfo, 0)
+	for _, frs := range ng.routes {
+		for _, route := range frs.routes {
+			fr := ng.overrideRoute(frs, route)
+			var timeout string
+			if !fr.sse && !fr.websocket {
+				timeout = ng.checkedTimeout(fr.timeout).String()
+			}
+
+			infos = append(infos, openapi.RouteInfo{
+				Method:    route.Method,
+				Path:      route.Path,
+				Jwt:       fr.jwt.enabled,
+				Signature: fr.signature.enabled,
+				Priority:  fr.priority,
+				SSE:       fr.sse,
+				WebSocket: fr.websocket,
+				Timeout:   timeout,
+				Request:   route.Request,
+				Response:  route.Response,
+			})
+		}
+	}
+
+	return infos
+}
+
+func introspectionHandler(resp interface{}) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpx.OkJson(w, resp)
+	})
+}
@@ -0,0 +1,104 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/requestid"
+	"github.com/zeromicro/go-zero/rest/internal/openapi"
+)
+
+func TestEngine_Introspection(t *testing.T) {
+	type (
+		request struct {
+			Id   string `path:"id"`
+			Name string `json:"name"`
+		}
+		response struct {
+			Name string `json:"name"`
+		}
+	)
+
+	var c RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+Port: 54321
+Introspection:
+  Enabled: true
+`), &c))
+	ng := newEngine(c)
+	ng.addRoutes(featuredRoutes{
+		timeout: time.Second,
+		jwt: jwtSetting{
+			enabled: true,
+		},
+		routes: []Route{{
+			Method:   http.MethodPost,
+			Path:     "/users/:id",
+			Handler:  func(w http.ResponseWriter, r *http.Request) {},
+			Request:  request{},
+			Response: response{},
+		}},
+	})
+	ng.addRoutes(featuredRoutes{
+		sse: true,
+		routes: []Route{{
+			Method:  http.MethodGet,
+			Path:    "/events",
+			Handler: func(w http.ResponseWriter, r *http.Request) {},
+		}},
+	})
+	rt := newRouterForTest(t, ng)
+
+	r := httptest.NewRequest(http.MethodGet, "/debug/routes", nil)
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotEmpty(t, w.Header().Get(requestid.HeaderKey))
+	var routes []openapi.RouteInfo
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &routes))
+	assert.Equal(t, []openapi.RouteInfo{
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/:id",
+			Jwt:     true,
+			Timeout: "1s",
+		},
+		{
+			Method: http.MethodGet,
+			Path:   "/events",
+			SSE:    true,
+		},
+	}, routes)
+
+	r = httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, r)
+	assert.Equal(t, http.StatusOK, w.Code)
+	var doc openapi.Document
+	assert.Nil(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, "foo", doc.Info.Title)
+	op := doc.Paths["/users/{id}"]["post"]
+	assert.Equal(t, "id", op.Parameters[0].Name)
+	assert.Equal(t, []string{"name"}, op.RequestBody.Content["application/json"].Schema.Required)
+	assert.Equal(t, "1s", op.Extensions["timeout"])
+	assert.NotNil(t, doc.Components)
+	assert.Contains(t, doc.Paths, "/events")
+}
+
+func TestEngine_IntrospectionDisabled(t *testing.T) {
+	var c RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &c))
+	rt := newRouterForTest(t, newEngine(c))
+
+	for _, path := range []string{"/debug/routes", "/openapi.json"} {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, r)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	}
+}
@@ -0,0 +1,115 @@
+package rest
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	rateLimitByIp     = "ip"
+	rateLimitByJwt    = "jwt"
+	rateLimitByHeader = "header"
+	rateLimitByPath   = "path"
+
+	forwardedForHeader = "X-Forwarded-For"
+)
+
+// rateLimitKeyFunc returns the func to get the rate limit keys from the requests,
+// falls back to the client ip if the claim or header is missing.
+func rateLimitKeyFunc(c RateLimitConf) (func(r *http.Request) string, error) {
+	clientIp, err := clientIpFunc(c.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+
+	switch c.KeyBy {
+	case rateLimitByJwt:
+		return func(r *http.Request) string {
+			if val := r.Context().Value(c.Key); val != nil {
+				return rateLimitByJwt + ":" + fmt.Sprint(val)
+			}
+
+			return clientIp(r)
+		}, nil
+	case rateLimitByHeader:
+		return func(r *http.Request) string {
+			if val := r.Header.Get(c.Key); len(val) > 0 {
+				return rateLimitByHeader + ":" + val
+			}
+
+			return clientIp(r)
+		}, nil
+	case rateLimitByPath:
+		return func(r *http.Request) string {
+			return rateLimitByPath
+		}, nil
+	default:
+		return clientIp, nil
+	}
+}
+
+// clientIpFunc returns the func to get the client ips from the requests.
+// The peer address is used, unless it's one of the trusted proxies, like 10.0.0.0/8,
+// then the rightmost hop in X-Forwarded-For that is not a trusted proxy is used,
+// because the hops on the left are set by the clients and can be forged.
+func clientIpFunc(trustedProxies []string) (func(r *http.Request) string, error) {
+	var nets []*net.IPNet
+	for _, proxy := range trustedProxies {
+		proxy = strings.TrimSpace(proxy)
+		if !strings.Contains(proxy, "/") {
+			if ip := net.ParseIP(proxy); ip != nil && ip.To4() != nil {
+				proxy += "/32"
+			} else {
+				proxy += "/128"
+			}
+		}
+
+		_, ipNet, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("bad trusted proxy %q: %w", proxy, err)
+		}
+		nets = append(nets, ipNet)
+	}
+
+	trusted := func(addr string) bool {
+		ip := net.ParseIP(addr)
+		if ip == nil {
+			return false
+		}
+
+		for _, ipNet := range nets {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	return func(r *http.Request) string {
+		addr := r.RemoteAddr
+		if host, _, err := net.SplitHostPort(addr); err == nil {
+			addr = host
+		}
+		if !trusted(addr) {
+			return addr
+		}
+
+		hops := strings.Split(strings.Join(r.Header.Values(forwardedForHeader), ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if len(hop) == 0 {
+				continue
+			}
+
+			addr = hop
+			if !trusted(hop) {
+				break
+			}
+		}
+
+		return addr
+	}, nil
+}
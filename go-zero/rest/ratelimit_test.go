@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimitKeyFunc(t *testing.T) {
+	tests := []struct {
+		name   string
+		conf   RateLimitConf
+		setup  func(r *http.Request) *http.Request
+		expect string
+	}{
+		{
+			name:   "ip",
+			conf:   RateLimitConf{KeyBy: rateLimitByIp},
+			expect: "192.0.2.1",
+		},
+		{
+			name: "forged forwarded ip",
+			conf: RateLimitConf{KeyBy: rateLimitByIp},
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Forwarded-For", "10.0.0.1, 10.0.0.2")
+				return r
+			},
+			expect: "192.0.2.1",
+		},
+		{
+			name: "forwarded ip from trusted proxy",
+			conf: RateLimitConf{KeyBy: rateLimitByIp, TrustedProxies: []string{"192.0.2.1", "10.0.0.0/8"}},
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-Forwarded-For", "1.1.1.1, 2.2.2.2, 10.0.0.2")
+				return r
+			},
+			expect: "2.2.2.2",
+		},
+		{
+			name: "forwarded ip all trusted",
+			conf: RateLimitConf{KeyBy: rateLimitByIp, TrustedProxies: []string{"192.0.2.0/24", "10.0.0.0/8"}},
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Add("X-Forwarded-For", "10.0.0.1")
+				r.Header.Add("X-Forwarded-For", "10.0.0.2")
+				return r
+			},
+			expect: "10.0.0.1",
+		},
+		{
+			name:   "trusted proxy without forwarded ip",
+			conf:   RateLimitConf{KeyBy: rateLimitByIp, TrustedProxies: []string{"192.0.2.1"}},
+			expect: "192.0.2.1",
+		},
+		{
+			name: "jwt",
+			conf: RateLimitConf{KeyBy: rateLimitByJwt, Key: "uid"},
+			setup: func(r *http.Request) *http.Request {
+				return r.WithContext(context.WithValue(r.Context(), "uid", 123))
+			},
+			expect: "jwt:123",
+		},
+		{
+			name:   "jwt missing",
+			conf:   RateLimitConf{KeyBy: rateLimitByJwt, Key: "uid"},
+			expect: "192.0.2.1",
+		},
+		{
+			name: "header",
+			conf: RateLimitConf{KeyBy: rateLimitByHeader, Key: "X-App"},
+			setup: func(r *http.Request) *http.Request {
+				r.Header.Set("X-App", "mall")
+				return r
+			},
+			expect: "header:mall",
+		},
+		{
+			name:   "header missing",
+			conf:   RateLimitConf{KeyBy: rateLimitByHeader, Key: "X-App"},
+			expect: "192.0.2.1",
+		},
+		{
+			name:   "path",
+			conf:   RateLimitConf{KeyBy: rateLimitByPath},
+			expect: rateLimitByPath,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if test.setup != nil {
+				r = test.setup(r)
+			}
+			keyFunc, err := rateLimitKeyFunc(test.conf)
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, keyFunc(r))
+		})
+	}
+}
+
+func TestRateLimitKeyFunc_BadTrustedProxy(t *testing.T) {
+	_, err := rateLimitKeyFunc(RateLimitConf{TrustedProxies: []string{"foo"}})
+	assert.NotNil(t, err)
+	_, err = rateLimitKeyFunc(RateLimitConf{TrustedProxies: []string{"10.0.0.0/33"}})
+	assert.NotNil(t, err)
+	_, err = rateLimitKeyFunc(RateLimitConf{TrustedProxies: []string{"::1", "fd00::/8"}})
+	assert.Nil(t, err)
+}
+
+func TestWithRateLimit(t *testing.T) {
+	ng := newEngine(RestConf{
+		RateLimit: RateLimitConf{
+			Period: 3600,
+			Quota:  100,
+		},
+	})
+	ng.addRoutes(featuredRoutes{
+		routes: []Route{{
+			Method:  http.MethodGet,
+			Path:    "/",
+			Handler: func(w http.ResponseWriter, r *http.Request) {},
+		}},
+	})
+	var fr featuredRoutes
+	WithRateLimit(RateLimitConf{
+		Period: 3600,
+		Quota:  1,
+	})(&fr)
+	fr.routes = []Route{{
+		Method:  http.MethodGet,
+		Path:    "/limited",
+		Handler: func(w http.ResponseWriter, r *http.Request) {},
+	}}
+	ng.addRoutes(fr)
+
+	rt := newRouterForTest(t, ng)
+	for i := 0; i < 2; i++ {
+		w := httptest.NewRecorder()
+		rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "100", w.Header().Get("X-RateLimit-Limit"))
+	}
+
+	w := httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	rt.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/limited", nil))
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+}
@@ -0,0 +1,106 @@
+package rest
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/collection"
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"github.com/zeromicro/go-zero/core/timex"
+	"github.com/zeromicro/go-zero/rest/handler"
+)
+
+const responseCacheName = "rest"
+
+var errResponseNotFound = errors.New("cached response not found")
+
+type (
+	redisResponseStore struct {
+		cache cache.Cache
+	}
+
+	// localResponseStore stores the responses in process, the expire of each item is checked
+	// on getting, because collection.Cache expires all the items after the same duration.
+	localResponseStore struct {
+		cache *collection.Cache
+	}
+
+	localResponse struct {
+		val      string
+		expireAt time.Duration
+	}
+)
+
+// MustNewResponseCache returns a ResponseCache with c, exits on any error.
+func MustNewResponseCache(c ResponseCacheConf) *handler.ResponseCache {
+	rc, err := NewResponseCache(c)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	return rc
+}
+
+// NewResponseCache returns a ResponseCache with c, which caches the responses of the routes
+// added with WithResponseCache, and purges the responses by its Purge method.
+func NewResponseCache(c ResponseCacheConf) (*handler.ResponseCache, error) {
+	if len(c.Cache) > 0 {
+		store := redisResponseStore{
+			cache: cache.New(c.Cache, syncx.NewSingleFlight(), cache.NewStat(responseCacheName),
+				errResponseNotFound),
+		}
+		return handler.NewResponseCache(store, c.Expire, c.VaryHeaders, c.VaryClaims), nil
+	}
+
+	// the purged versions are kept longer than the responses, see handler.ResponseCache.Purge
+	local, err := collection.NewCache(c.Expire*2, collection.WithLimit(c.Limit),
+		collection.WithName(responseCacheName))
+	if err != nil {
+		return nil, err
+	}
+
+	return handler.NewResponseCache(localResponseStore{cache: local}, c.Expire,
+		c.VaryHeaders, c.VaryClaims), nil
+}
+
+func (s localResponseStore) Get(_ context.Context, key string) (string, bool, error) {
+	val, ok := s.cache.Get(key)
+	if !ok {
+		return "", false, nil
+	}
+
+	resp := val.(localResponse)
+	if timex.Now() > resp.expireAt {
+		return "", false, nil
+	}
+
+	return resp.val, true, nil
+}
+
+func (s localResponseStore) Set(_ context.Context, key, val string, expire time.Duration) error {
+	s.cache.Set(key, localResponse{
+		val:      val,
+		expireAt: timex.Now() + expire,
+	})
+	return nil
+}
+
+func (s redisResponseStore) Get(ctx context.Context, key string) (string, bool, error) {
+	var val string
+	err := s.cache.GetCtx(ctx, key, &val)
+	if s.cache.IsNotFound(err) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	return val, true, nil
+}
+
+func (s redisResponseStore) Set(ctx context.Context, key, val string, expire time.Duration) error {
+	return s.cache.SetWithExpireCtx(ctx, key, val, expire)
+}
@@ -0,0 +1,91 @@
+package rest
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/collection"
+	"github.com/zeromicro/go-zero/core/stores/cache"
+	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/rest/handler"
+)
+
+func TestNewResponseCache_Local(t *testing.T) {
+	rc := MustNewResponseCache(ResponseCacheConf{
+		Expire: time.Minute,
+		Limit:  10,
+	})
+	testResponseCache(t, rc)
+}
+
+func TestNewResponseCache_Redis(t *testing.T) {
+	s, err := miniredis.Run()
+	assert.Nil(t, err)
+	defer s.Close()
+
+	rc, err := NewResponseCache(ResponseCacheConf{
+		Cache: cache.CacheConf{
+			{
+				RedisConf: redis.RedisConf{
+					Host: s.Addr(),
+					Type: redis.NodeType,
+				},
+				Weight: 100,
+			},
+		},
+		Expire: time.Minute,
+	})
+	assert.Nil(t, err)
+	testResponseCache(t, rc)
+}
+
+func TestLocalResponseStore(t *testing.T) {
+	local, err := collection.NewCache(time.Minute)
+	assert.Nil(t, err)
+	store := localResponseStore{cache: local}
+
+	ctx := context.Background()
+	assert.Nil(t, store.Set(ctx, "foo", "bar", time.Minute))
+	val, ok, err := store.Get(ctx, "foo")
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "bar", val)
+
+	assert.Nil(t, store.Set(ctx, "foo", "bar", -time.Second))
+	_, ok, err = store.Get(ctx, "foo")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = store.Get(ctx, "not-exist")
+	assert.Nil(t, err)
+	assert.False(t, ok)
+}
+
+func testResponseCache(t *testing.T, rc *handler.ResponseCache) {
+	var count int32
+	h := rc.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		_, _ = w.Write([]byte("foo"))
+	}))
+
+	serve := func() string {
+		r := httptest.NewRequest(http.MethodGet, "/products/1", nil)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Body.String()
+	}
+
+	assert.Equal(t, "foo", serve())
+	assert.Equal(t, "foo", serve())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	assert.Nil(t, rc.Purge(context.Background(), "/products/1"))
+	assert.Equal(t, "foo", serve())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&count))
+}
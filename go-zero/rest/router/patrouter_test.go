@@ -84,6 +84,57 @@ func TestPatRouterNotAllowed(t *testing.T) {
 	assert.True(t, notAllowed)
 }
 
+func TestPatRouterWithWildcards(t *testing.T) {
+	router := NewRouter()
+	handle := func(name string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Route", name)
+			for k, v := range pathvar.Vars(r) {
+				w.Header().Set("X-Var-"+k, v)
+			}
+		})
+	}
+	assert.Nil(t, router.Handle(http.MethodGet, "/files/*path", handle("files")))
+	assert.Nil(t, router.Handle(http.MethodGet, "/users/:id{int}", handle("id")))
+	assert.Nil(t, router.Handle(http.MethodGet, "/users/:name", handle("name")))
+	assert.NotNil(t, router.Handle(http.MethodGet, "/users/:nick", handle("nick")))
+
+	tests := []struct {
+		path  string
+		route string
+		vars  map[string]string
+	}{
+		{
+			path:  "/files/css/site.css",
+			route: "files",
+			vars:  map[string]string{"path": "css/site.css"},
+		},
+		{
+			path:  "/users/123",
+			route: "id",
+			vars:  map[string]string{"id": "123"},
+		},
+		{
+			path:  "/users/kevin",
+			route: "name",
+			vars:  map[string]string{"name": "kevin"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.path, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, test.path, nil)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, r)
+			assert.Equal(t, test.route, w.Header().Get("X-Route"))
+			for k, v := range test.vars {
+				assert.Equal(t, v, w.Header().Get("X-Var-"+k))
+			}
+		})
+	}
+}
+
 func TestPatRouter(t *testing.T) {
 	tests := []struct {
 		method string
@@ -2,6 +2,7 @@ package rest
 
 import (
 	"crypto/tls"
+	"io/fs"
 	"log"
 	"net/http"
 	"path"
@@ -11,6 +12,7 @@ import (
 	"github.com/zeromicro/go-zero/rest/handler"
 	"github.com/zeromicro/go-zero/rest/httpx"
 	"github.com/zeromicro/go-zero/rest/internal/cors"
+	"github.com/zeromicro/go-zero/rest/internal/fileserver"
 	"github.com/zeromicro/go-zero/rest/router"
 )
 
@@ -25,6 +27,9 @@ type (
 		ngin   *engine
 		// http 服务者
 		router httpx.Router
+		// the user-defined not found handler, and the file servers before it
+		notFound    http.Handler
+		fileServers []func(http.Handler) http.Handler
 	}
 )
 
@@ -61,6 +66,8 @@ func NewServer(c RestConf, opts ...RunOption) (*Server, error) {
 }
 
 // AddRoutes add given routes into the Server.
+// It panics with all the conflicts if any route conflicts with the added ones,
+// like /users/:name conflicts with /users/:id.
 // 添加多个路由
 func (s *Server) AddRoutes(rs []Route, opts ...RouteOption) {
 	r := featuredRoutes{
@@ -69,7 +76,9 @@ func (s *Server) AddRoutes(rs []Route, opts ...RouteOption) {
 	for _, opt := range opts { // 运行：配置 featuredRoutes 参数的方法
 		opt(&r)
 	}
-	s.ngin.addRoutes(r)
+	if err := s.ngin.addRoutes(r); err != nil {
+		panic(err)
+	}
 }
 
 // AddRoute adds given route into the Server.
@@ -78,6 +87,18 @@ func (s *Server) AddRoute(r Route, opts ...RouteOption) {
 	s.AddRoutes([]Route{r}, opts...)
 }
 
+// AddWebSocketRoute adds given websocket route into the Server.
+// The connection is upgraded after the jwt authorization and signature verification,
+// and closed when the Server shuts down.
+func (s *Server) AddWebSocketRoute(r WebSocketRoute, opts ...RouteOption) {
+	route := Route{
+		Method:  http.MethodGet,
+		Path:    r.Path,
+		Handler: s.ngin.webSocketHandler(r.Handler),
+	}
+	s.AddRoutes([]Route{route}, append(opts, withWebSocket())...)
+}
+
 // Start starts the Server.
 // Graceful shutdown is enabled by default.
 // Use proc.SetTimeToForceQuit to customize the graceful shutdown period.
@@ -110,6 +131,15 @@ func WithCors(origin ...string) RunOption {
 	}
 }
 
+// WithCorsConf returns a RouteOption to apply the CORS config on the given routes,
+// which overrides the Cors in RestConf, the CORS is disabled on the routes if c is not enabled.
+func WithCorsConf(c CorsConf) RouteOption {
+	return func(r *featuredRoutes) {
+		r.cors.enabled = true
+		r.cors.CorsConf = c
+	}
+}
+
 // WithCustomCors returns a func to enable CORS for given origin, or default to all origins (*),
 // fn lets caller customizing the response.
 func WithCustomCors(middlewareFn func(header http.Header), notAllowedFn func(http.ResponseWriter),
@@ -120,6 +150,15 @@ func WithCustomCors(middlewareFn func(header http.Header), notAllowedFn func(htt
 	}
 }
 
+// WithIdempotency returns a RouteOption to deduplicate the requests with Idempotency-Key headers,
+// the responses are replayed for the retried requests, see IdempotencyConf.
+func WithIdempotency(c IdempotencyConf) RouteOption {
+	return func(r *featuredRoutes) {
+		r.idempotency.enabled = true
+		r.idempotency.IdempotencyConf = c
+	}
+}
+
 // WithJwt returns a func to enable jwt authentication in given route.
 func WithJwt(secret string) RouteOption {
 	return func(r *featuredRoutes) {
@@ -129,6 +168,23 @@ func WithJwt(secret string) RouteOption {
 	}
 }
 
+// WithJwtConf returns a func to enable jwt authentication in given route with c,
+// which supports the tokens signed with RSA, ECDSA or EdDSA, the JWKS and the claims checks.
+func WithJwtConf(c JwtConf) RouteOption {
+	return func(r *featuredRoutes) {
+		if len(c.Secret) > 0 {
+			validateSecret(c.Secret)
+		} else if len(c.PublicKeys) == 0 && len(c.JwksFile) == 0 && len(c.JwksUrl) == 0 {
+			panic("jwt config requires a secret, public keys or JWKS")
+		}
+
+		r.jwt.enabled = true
+		r.jwt.secret = c.Secret
+		r.jwt.prevSecret = c.PrevSecret
+		r.jwt.conf = &c
+	}
+}
+
 // WithJwtTransition returns a func to enable jwt authentication as well as jwt secret transition.
 // Which means old and new jwt secrets work together for a period.
 // 新旧 jwt 密钥共用时期
@@ -143,6 +199,14 @@ func WithJwtTransition(secret, prevSecret string) RouteOption {
 	}
 }
 
+// WithMaxBytes returns a RouteOption to limit the request bodies of the given routes,
+// instead of the MaxBytes in RestConf, like the routes to upload files.
+func WithMaxBytes(maxBytes int64) RouteOption {
+	return func(r *featuredRoutes) {
+		r.maxBytes = maxBytes
+	}
+}
+
 // WithMiddlewares adds given middlewares to given routes.
 func WithMiddlewares(ms []Middleware, rs ...Route) []Route {
 	for i := len(ms) - 1; i >= 0; i-- {
@@ -157,21 +221,59 @@ func WithMiddleware(middleware Middleware, rs ...Route) []Route {
 
 	for i := range rs {
 		route := rs[i]
-		routes[i] = Route{
-			Method:  route.Method,
-			Path:    route.Path,
-			Handler: middleware(route.Handler),
-		}
+		route.Handler = middleware(route.Handler)
+		routes[i] = route
 	}
 
 	return routes
 }
 
+// WithErrorHandler returns a RunOption that converts the errors written by httpx.Error
+// and httpx.ErrorCtx with handler, only for the routes of this server, in precedence over httpx.SetErrorHandler.
+func WithErrorHandler(handler httpx.ErrorHandler) RunOption {
+	return func(srv *Server) {
+		srv.ngin.setErrorHandler(handler)
+	}
+}
+
+// WithFileIndex returns a FileServerOption to serve the file with given name for directories.
+// Default to be index.html.
+func WithFileIndex(name string) FileServerOption {
+	return func(opts *fileserver.Options) {
+		opts.IndexFile = name
+	}
+}
+
+// WithFileMaxAge returns a FileServerOption to let the clients cache the files for given duration.
+// The index files are always revalidated.
+func WithFileMaxAge(maxAge time.Duration) FileServerOption {
+	return func(opts *fileserver.Options) {
+		opts.MaxAge = maxAge
+	}
+}
+
+// WithFileServer returns a RunOption to serve the files in fsys under given path prefix,
+// use os.DirFS to serve a directory, or an embed.FS to serve the embedded files.
+// The files are served only if no route matches the request, the not found handler is
+// called if no file matches either.
+// ETag, Last-Modified, range requests and precompressed .gz files are supported.
+func WithFileServer(prefix string, fsys fs.FS, opts ...FileServerOption) RunOption {
+	return func(server *Server) {
+		var options fileserver.Options
+		for _, opt := range opts {
+			opt(&options)
+		}
+
+		server.fileServers = append(server.fileServers, fileserver.Middleware(prefix, fsys, options))
+		server.setNotFoundHandler()
+	}
+}
+
 // WithNotFoundHandler returns a RunOption with not found handler set to given handler.
 func WithNotFoundHandler(handler http.Handler) RunOption {
 	return func(server *Server) {
-		notFoundHandler := server.ngin.notFoundHandler(handler)
-		server.router.SetNotFoundHandler(notFoundHandler)
+		server.notFound = handler
+		server.setNotFoundHandler()
 	}
 }
 
@@ -187,12 +289,8 @@ func WithPrefix(group string) RouteOption {
 	return func(r *featuredRoutes) {
 		var routes []Route
 		for _, rt := range r.routes {
-			p := path.Join(group, rt.Path)
-			routes = append(routes, Route{
-				Method:  rt.Method,
-				Path:    p,
-				Handler: rt.Handler,
-			})
+			rt.Path = path.Join(group, rt.Path)
+			routes = append(routes, rt)
 		}
 		r.routes = routes
 	}
@@ -205,6 +303,23 @@ func WithPriority() RouteOption {
 	}
 }
 
+// WithRateLimit returns a RouteOption to limit the requests with given config,
+// which overrides the RateLimit in RestConf.
+func WithRateLimit(c RateLimitConf) RouteOption {
+	return func(r *featuredRoutes) {
+		r.rateLimit.enabled = true
+		r.rateLimit.RateLimitConf = c
+	}
+}
+
+// WithResponseCache returns a RouteOption to cache the responses of the GET routes in rc,
+// which is created by NewResponseCache, and shared by the routes to purge the responses.
+func WithResponseCache(rc *handler.ResponseCache) RouteOption {
+	return func(r *featuredRoutes) {
+		r.cache = rc
+	}
+}
+
 // WithRouter returns a RunOption that make server run with given router.
 func WithRouter(router httpx.Router) RunOption {
 	return func(server *Server) {
@@ -212,6 +327,14 @@ func WithRouter(router httpx.Router) RunOption {
 	}
 }
 
+// WithSPAFallback returns a FileServerOption to serve the root index file for the paths
+// without extensions that match no file, to let the single page applications do the routing.
+func WithSPAFallback() FileServerOption {
+	return func(opts *fileserver.Options) {
+		opts.SPAFallback = true
+	}
+}
+
 // WithSignature returns a RouteOption to enable signature verification.
 func WithSignature(signature SignatureConf) RouteOption {
 	return func(r *featuredRoutes) {
@@ -222,6 +345,14 @@ func WithSignature(signature SignatureConf) RouteOption {
 	}
 }
 
+// WithSSE returns a RouteOption to serve the routes as Server-Sent Events streams.
+// The streams are not limited by the timeout, and are kept alive with heartbeats.
+func WithSSE() RouteOption {
+	return func(r *featuredRoutes) {
+		r.sse = true
+	}
+}
+
 // WithTimeout returns a RouteOption to set timeout with given value.
 func WithTimeout(timeout time.Duration) RouteOption {
 	return func(r *featuredRoutes) {
@@ -250,6 +381,27 @@ func WithUnsignedCallback(callback handler.UnsignedCallback) RunOption {
 	}
 }
 
+func (s *Server) setNotFoundHandler() {
+	var handler http.Handler
+	if s.notFound != nil {
+		handler = s.notFound
+	} else if len(s.fileServers) > 0 {
+		handler = http.NotFoundHandler()
+	}
+
+	for i := len(s.fileServers) - 1; i >= 0; i-- {
+		handler = s.fileServers[i](handler)
+	}
+
+	s.router.SetNotFoundHandler(s.ngin.notFoundHandler(handler))
+}
+
+func withWebSocket() RouteOption {
+	return func(r *featuredRoutes) {
+		r.websocket = true
+	}
+}
+
 func handleError(err error) {
 	// ErrServerClosed means the server is closed manually
 	if err == nil || err == http.ErrServerClosed {
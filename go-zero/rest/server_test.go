@@ -1,12 +1,14 @@
 package rest
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/stretchr/testify/assert"
@@ -227,6 +229,42 @@ func TestMultiMiddlewares(t *testing.T) {
 	}, m)
 }
 
+func TestServer_AddRoutesWithConflicts(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	srv := MustNewServer(cnf)
+	handler := func(w http.ResponseWriter, r *http.Request) {}
+	srv.AddRoutes([]Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/users/:id",
+			Handler: handler,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/:id",
+			Handler: handler,
+		},
+	})
+
+	assert.PanicsWithError(t, "GET: route /users/:name conflicts with /users/:id\n"+
+		"GET: route /users/:uid conflicts with /users/:id", func() {
+		srv.AddRoutes([]Route{
+			{
+				Method:  http.MethodGet,
+				Path:    "/users/:name",
+				Handler: handler,
+			},
+			{
+				Method:  http.MethodGet,
+				Path:    "/users/:uid",
+				Handler: handler,
+			},
+		})
+	})
+	assert.Equal(t, 1, len(srv.ngin.routes))
+}
+
 func TestWithPrefix(t *testing.T) {
 	fr := featuredRoutes{
 		routes: []Route{
@@ -246,6 +284,75 @@ func TestWithPrefix(t *testing.T) {
 	assert.EqualValues(t, []string{"/api/hello", "/api/world"}, vals)
 }
 
+func TestWithJwtConf(t *testing.T) {
+	var fr featuredRoutes
+	WithJwtConf(JwtConf{
+		Secret:     "thesecret",
+		PrevSecret: "prevsecret",
+		Issuer:     "foo",
+	})(&fr)
+	assert.True(t, fr.jwt.enabled)
+	assert.Equal(t, "thesecret", fr.jwt.secret)
+	assert.Equal(t, "prevsecret", fr.jwt.prevSecret)
+	assert.Equal(t, "foo", fr.jwt.conf.Issuer)
+
+	WithJwtConf(JwtConf{JwksUrl: "http://localhost/jwks"})(&fr)
+	assert.Empty(t, fr.jwt.secret)
+	assert.Equal(t, "http://localhost/jwks", fr.jwt.conf.JwksUrl)
+
+	assert.Panics(t, func() {
+		WithJwtConf(JwtConf{Secret: "short"})(&fr)
+	})
+	assert.Panics(t, func() {
+		WithJwtConf(JwtConf{Issuer: "foo"})(&fr)
+	})
+}
+
+func TestWithCorsConf(t *testing.T) {
+	var fr featuredRoutes
+	WithCorsConf(CorsConf{
+		Enabled: true,
+		Origins: []string{"https://*.foo.com"},
+	})(&fr)
+	assert.True(t, fr.cors.enabled)
+	assert.True(t, fr.cors.Enabled)
+	assert.Equal(t, []string{"https://*.foo.com"}, fr.cors.Origins)
+}
+
+func TestWithIdempotency(t *testing.T) {
+	var fr featuredRoutes
+	WithIdempotency(IdempotencyConf{
+		Expire:  time.Hour,
+		UserBy:  rateLimitByJwt,
+		UserKey: "uid",
+	})(&fr)
+	assert.True(t, fr.idempotency.enabled)
+	assert.Equal(t, time.Hour, fr.idempotency.Expire)
+	assert.Equal(t, "uid", fr.idempotency.UserKey)
+}
+
+func TestWithResponseCache(t *testing.T) {
+	rc := MustNewResponseCache(ResponseCacheConf{Expire: time.Minute})
+	var fr featuredRoutes
+	WithResponseCache(rc)(&fr)
+	assert.Equal(t, rc, fr.cache)
+}
+
+func TestWithErrorHandler(t *testing.T) {
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &cnf))
+	svr := MustNewServer(cnf, WithErrorHandler(func(ctx context.Context, err error) (int, interface{}) {
+		return http.StatusConflict, nil
+	}))
+	assert.NotNil(t, svr.ngin.errorHandler)
+}
+
+func TestWithMaxBytes(t *testing.T) {
+	var fr featuredRoutes
+	WithMaxBytes(1024)(&fr)
+	assert.Equal(t, int64(1024), fr.maxBytes)
+}
+
 func TestWithPriority(t *testing.T) {
 	var fr featuredRoutes
 	WithPriority()(&fr)
@@ -329,3 +436,63 @@ Port: 54321
 	}, "local")
 	opt(srv)
 }
+
+func TestWithFileServer(t *testing.T) {
+	const configYaml = `
+Name: foo
+Port: 54321
+`
+	var cnf RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(configYaml), &cnf))
+
+	fsys := fstest.MapFS{
+		"index.html": {Data: []byte("index")},
+		"api/info":   {Data: []byte("file")},
+	}
+	srv := MustNewServer(cnf, WithNotFoundHandler(http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusTeapot)
+		})), WithFileServer("/", fsys, WithSPAFallback()))
+	srv.AddRoute(Route{
+		Method: http.MethodGet,
+		Path:   "/api/info",
+		Handler: func(w http.ResponseWriter, r *http.Request) {
+			io.WriteString(w, "route")
+		},
+	})
+	assert.Nil(t, srv.ngin.bindRoutes(srv.router))
+
+	tests := []struct {
+		path string
+		code int
+		body string
+	}{
+		{
+			path: "/api/info",
+			code: http.StatusOK,
+			body: "route",
+		},
+		{
+			path: "/",
+			code: http.StatusOK,
+			body: "index",
+		},
+		{
+			path: "/orders/1",
+			code: http.StatusOK,
+			body: "index",
+		},
+		{
+			path: "/missing.js",
+			code: http.StatusTeapot,
+		},
+	}
+
+	for _, test := range tests {
+		r := httptest.NewRequest(http.MethodGet, test.path, nil)
+		w := httptest.NewRecorder()
+		srv.router.ServeHTTP(w, r)
+		assert.Equal(t, test.code, w.Code, test.path)
+		assert.Equal(t, test.body, w.Body.String(), test.path)
+	}
+}
@@ -0,0 +1,371 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"github.com/zeromicro/go-zero/core/threading"
+	"github.com/zeromicro/go-zero/core/timex"
+)
+
+const (
+	defaultJwksRefresh = time.Minute * 10
+	// the min interval to reload the JWKS on unknown kid, to avoid being flooded by bad tokens.
+	minJwksReloadInterval = time.Second * 10
+	jwksFetchTimeout      = time.Second * 10
+	maxJwksSize           = 1 << 20
+	kidHeader             = "kid"
+	sigUse                = "sig"
+)
+
+var (
+	// ErrKeyNotFound is an error that indicates no key matches the kid of the token.
+	ErrKeyNotFound = errors.New("no key found for token")
+	// ErrUnsupportedKey is an error that indicates the key type is not supported.
+	ErrUnsupportedKey = errors.New("unsupported key type")
+
+	errAlgMismatch = errors.New("token algorithm doesn't match the key")
+)
+
+type (
+	// KeySetOption defines the method to customize a KeySet.
+	KeySetOption func(ks *KeySet)
+
+	// A KeySet holds the public keys to verify the tokens signed with RSA, ECDSA or EdDSA,
+	// the keys are selected by the kid headers of the tokens. The keys from JWKS sources
+	// are refreshed periodically, and reloaded on unknown kids.
+	KeySet struct {
+		static  map[string]publicKey
+		sources []jwksSource
+		refresh time.Duration
+
+		lock       sync.RWMutex
+		keys       map[string]publicKey
+		loadLock   sync.Mutex
+		lastLoad   time.Duration
+		refreshing *syncx.AtomicBool
+	}
+
+	jwksSource func() ([]byte, error)
+
+	publicKey struct {
+		key interface{}
+		// the algorithm that the key is restricted to, empty means not restricted.
+		alg string
+	}
+
+	jsonWebKeySet struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+
+	jsonWebKey struct {
+		Kty string `json:"kty"`
+		Kid string `json:"kid"`
+		Use string `json:"use"`
+		Alg string `json:"alg"`
+		Crv string `json:"crv"`
+		N   string `json:"n"`
+		E   string `json:"e"`
+		X   string `json:"x"`
+		Y   string `json:"y"`
+	}
+)
+
+// NewKeySet returns a KeySet, the JWKS sources are loaded before returning.
+func NewKeySet(opts ...KeySetOption) (*KeySet, error) {
+	ks := &KeySet{
+		static:     make(map[string]publicKey),
+		refresh:    defaultJwksRefresh,
+		keys:       make(map[string]publicKey),
+		refreshing: syncx.NewAtomicBool(),
+	}
+	for _, opt := range opts {
+		opt(ks)
+	}
+
+	if err := ks.load(); err != nil {
+		return nil, err
+	}
+
+	return ks, nil
+}
+
+// Key returns the key to verify the token, selected by its kid header.
+// The only key is used if the token doesn't have kid and there is only one key.
+func (ks *KeySet) Key(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header[kidHeader].(string)
+	key, ok := ks.lookup(kid)
+	if !ok && len(kid) > 0 && ks.reloadable() {
+		if err := ks.load(); err != nil {
+			logx.Errorf("failed to reload JWKS: %v", err)
+		}
+		key, ok = ks.lookup(kid)
+	}
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	if len(key.alg) > 0 && key.alg != token.Method.Alg() {
+		return nil, errAlgMismatch
+	}
+
+	ks.refreshIfExpired()
+	return key.key, nil
+}
+
+func (ks *KeySet) load() error {
+	ks.loadLock.Lock()
+	defer ks.loadLock.Unlock()
+
+	keys := make(map[string]publicKey, len(ks.static))
+	for kid, key := range ks.static {
+		keys[kid] = key
+	}
+
+	for _, source := range ks.sources {
+		content, err := source()
+		if err != nil {
+			return err
+		}
+
+		loaded, err := parseJwks(content)
+		if err != nil {
+			return err
+		}
+
+		for kid, key := range loaded {
+			keys[kid] = key
+		}
+	}
+
+	ks.lock.Lock()
+	ks.keys = keys
+	ks.lastLoad = timex.Now()
+	ks.lock.Unlock()
+
+	return nil
+}
+
+func (ks *KeySet) lookup(kid string) (publicKey, bool) {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+
+	if key, ok := ks.keys[kid]; ok {
+		return key, true
+	}
+
+	if len(kid) == 0 && len(ks.keys) == 1 {
+		for _, key := range ks.keys {
+			return key, true
+		}
+	}
+
+	return publicKey{}, false
+}
+
+func (ks *KeySet) reloadable() bool {
+	if len(ks.sources) == 0 {
+		return false
+	}
+
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	return timex.Since(ks.lastLoad) > minJwksReloadInterval
+}
+
+// refreshIfExpired refreshes the keys in background, the current keys are used until refreshed.
+func (ks *KeySet) refreshIfExpired() {
+	if len(ks.sources) == 0 {
+		return
+	}
+
+	ks.lock.RLock()
+	expired := timex.Since(ks.lastLoad) > ks.refresh
+	ks.lock.RUnlock()
+	if !expired || !ks.refreshing.CompareAndSwap(false, true) {
+		return
+	}
+
+	threading.GoSafe(func() {
+		defer ks.refreshing.Set(false)
+
+		if err := ks.load(); err != nil {
+			logx.Errorf("failed to refresh JWKS: %v", err)
+		}
+	})
+}
+
+// ParsePublicKeyFromPEM parses the RSA, ECDSA or Ed25519 public key in PEM format.
+func ParsePublicKeyFromPEM(data []byte) (interface{}, error) {
+	if key, err := jwt.ParseRSAPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseECPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+	if key, err := jwt.ParseEdPublicKeyFromPEM(data); err == nil {
+		return key, nil
+	}
+
+	return nil, ErrUnsupportedKey
+}
+
+// WithJwksFile returns a KeySetOption to load the keys from the JWKS file.
+func WithJwksFile(file string) KeySetOption {
+	return func(ks *KeySet) {
+		ks.sources = append(ks.sources, func() ([]byte, error) {
+			return ioutil.ReadFile(file)
+		})
+	}
+}
+
+// WithJwksRefresh returns a KeySetOption to customize the refresh interval of the JWKS.
+func WithJwksRefresh(refresh time.Duration) KeySetOption {
+	return func(ks *KeySet) {
+		if refresh > 0 {
+			ks.refresh = refresh
+		}
+	}
+}
+
+// WithJwksUrl returns a KeySetOption to load the keys from the JWKS endpoint.
+func WithJwksUrl(url string) KeySetOption {
+	return func(ks *KeySet) {
+		ks.sources = append(ks.sources, func() ([]byte, error) {
+			return fetchJwks(url)
+		})
+	}
+}
+
+// WithPublicKey returns a KeySetOption to add a public key with kid,
+// the key without kid is used for the tokens without kid.
+func WithPublicKey(kid string, key interface{}) KeySetOption {
+	return func(ks *KeySet) {
+		ks.static[kid] = publicKey{key: key}
+	}
+}
+
+func decodeBigInt(val string) (*big.Int, error) {
+	data, err := base64.RawURLEncoding.DecodeString(val)
+	if err != nil {
+		return nil, err
+	}
+
+	return new(big.Int).SetBytes(data), nil
+}
+
+func fetchJwks(url string) ([]byte, error) {
+	client := http.Client{Timeout: jwksFetchTimeout}
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch JWKS from %s, status: %s", url, resp.Status)
+	}
+
+	return ioutil.ReadAll(io.LimitReader(resp.Body, maxJwksSize))
+}
+
+func parseJwks(content []byte) (map[string]publicKey, error) {
+	var set jsonWebKeySet
+	if err := json.Unmarshal(content, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]publicKey, len(set.Keys))
+	for _, jwk := range set.Keys {
+		// the keys for encryption are not for verifying signatures
+		if len(jwk.Use) > 0 && jwk.Use != sigUse {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err == ErrUnsupportedKey {
+			logx.Infof("JWKS key %q with kty %q is ignored", jwk.Kid, jwk.Kty)
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("bad JWKS key %q: %w", jwk.Kid, err)
+		}
+
+		keys[jwk.Kid] = publicKey{
+			key: key,
+			alg: jwk.Alg,
+		}
+	}
+
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeBigInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBigInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, ErrUnsupportedKey
+		}
+
+		x, err := decodeBigInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBigInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, ErrUnsupportedKey
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("bad ed25519 key size")
+		}
+
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, ErrUnsupportedKey
+	}
+}
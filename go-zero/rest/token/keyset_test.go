@@ -0,0 +1,274 @@
+package token
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/fs"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/timex"
+)
+
+func init() {
+	logx.Disable()
+}
+
+func TestKeySet_Jwks(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	content := marshalJwks(t, []jsonWebKey{
+		rsaJwk("rsa", &rsaKey.PublicKey),
+		ecJwk("ec", &ecKey.PublicKey),
+		{Kty: "OKP", Kid: "ed", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(edPub)},
+		{Kty: "oct", Kid: "hmac", Use: sigUse},
+		{Kty: "RSA", Kid: "enc", Use: "enc"},
+	})
+	file, err := fs.TempFilenameWithText(string(content))
+	assert.Nil(t, err)
+	defer os.Remove(file)
+
+	ks, err := NewKeySet(WithJwksFile(file))
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    string
+		key    interface{}
+		err    error
+	}{
+		{
+			name:   "rsa",
+			method: jwt.SigningMethodRS256,
+			kid:    "rsa",
+			key:    rsaKey,
+		},
+		{
+			name:   "ecdsa",
+			method: jwt.SigningMethodES256,
+			kid:    "ec",
+			key:    ecKey,
+		},
+		{
+			name:   "eddsa",
+			method: jwt.SigningMethodEdDSA,
+			kid:    "ed",
+			key:    edKey,
+		},
+		{
+			name:   "unknown kid",
+			method: jwt.SigningMethodRS256,
+			kid:    "unknown",
+			key:    rsaKey,
+			err:    ErrKeyNotFound,
+		},
+		{
+			name:   "without kid",
+			method: jwt.SigningMethodRS256,
+			key:    rsaKey,
+			err:    ErrKeyNotFound,
+		},
+		{
+			name:   "algorithm mismatch",
+			method: jwt.SigningMethodRS384,
+			kid:    "rsa",
+			key:    rsaKey,
+			err:    errAlgMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			tok := jwt.NewWithClaims(test.method, jwt.MapClaims{"foo": "bar"})
+			if len(test.kid) > 0 {
+				tok.Header[kidHeader] = test.kid
+			}
+			signed, err := tok.SignedString(test.key)
+			assert.Nil(t, err)
+
+			parsed, err := jwt.Parse(signed, ks.Key)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.True(t, parsed.Valid)
+		})
+	}
+}
+
+func TestKeySet_JwksUrl(t *testing.T) {
+	oldKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	newKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	var rotated int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys := []jsonWebKey{rsaJwk("old", &oldKey.PublicKey)}
+		if atomic.LoadInt32(&rotated) > 0 {
+			keys = append(keys, rsaJwk("new", &newKey.PublicKey))
+		}
+		_, _ = w.Write(marshalJwks(t, keys))
+	}))
+	defer svr.Close()
+
+	ks, err := NewKeySet(WithJwksUrl(svr.URL), WithJwksRefresh(time.Hour))
+	assert.Nil(t, err)
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"})
+	tok.Header[kidHeader] = "new"
+	signed, err := tok.SignedString(newKey)
+	assert.Nil(t, err)
+
+	atomic.StoreInt32(&rotated, 1)
+	// reloading on unknown kid is limited
+	_, err = jwt.Parse(signed, ks.Key)
+	assert.ErrorIs(t, err, ErrKeyNotFound)
+
+	ks.lastLoad = timex.Now() - minJwksReloadInterval*2
+	_, err = jwt.Parse(signed, ks.Key)
+	assert.Nil(t, err)
+}
+
+func TestKeySet_Refresh(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+
+	var count int32
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&count, 1)
+		_, _ = w.Write(marshalJwks(t, []jsonWebKey{rsaJwk("foo", &key.PublicKey)}))
+	}))
+	defer svr.Close()
+
+	ks, err := NewKeySet(WithJwksUrl(svr.URL), WithJwksRefresh(time.Minute))
+	assert.Nil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&count))
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"foo": "bar"})
+	tok.Header[kidHeader] = "foo"
+	signed, err := tok.SignedString(key)
+	assert.Nil(t, err)
+
+	ks.lock.Lock()
+	ks.lastLoad = timex.Now() - time.Hour
+	ks.lock.Unlock()
+	_, err = jwt.Parse(signed, ks.Key)
+	assert.Nil(t, err)
+	assert.Eventually(t, func() bool {
+		return atomic.LoadInt32(&count) == 2
+	}, time.Second, time.Millisecond*10)
+}
+
+func TestKeySet_BadJwks(t *testing.T) {
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer svr.Close()
+
+	_, err := NewKeySet(WithJwksUrl(svr.URL))
+	assert.NotNil(t, err)
+
+	tests := []string{
+		`bad`,
+		`{"keys":[{"kty":"RSA","kid":"foo","n":"!","e":"AQAB"}]}`,
+		`{"keys":[{"kty":"EC","kid":"foo","crv":"P-256","x":"!","y":"AQAB"}]}`,
+		`{"keys":[{"kty":"OKP","kid":"foo","crv":"Ed25519","x":"AQAB"}]}`,
+	}
+	for _, test := range tests {
+		file, err := fs.TempFilenameWithText(test)
+		assert.Nil(t, err)
+		_, err = NewKeySet(WithJwksFile(file))
+		assert.NotNil(t, err, test)
+		assert.Nil(t, os.Remove(file))
+	}
+
+	_, err = NewKeySet(WithJwksFile("not-exist"))
+	assert.NotNil(t, err)
+}
+
+func TestKeySet_PublicKey(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	assert.Nil(t, err)
+	ks, err := NewKeySet(WithPublicKey("", &key.PublicKey))
+	assert.Nil(t, err)
+
+	tok := jwt.NewWithClaims(jwt.SigningMethodES384, jwt.MapClaims{"foo": "bar"})
+	signed, err := tok.SignedString(key)
+	assert.Nil(t, err)
+	_, err = jwt.Parse(signed, ks.Key)
+	assert.Nil(t, err)
+}
+
+func TestParsePublicKeyFromPEM(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.Nil(t, err)
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	assert.Nil(t, err)
+
+	for _, pub := range []interface{}{&rsaKey.PublicKey, &ecKey.PublicKey, edPub} {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		assert.Nil(t, err)
+		key, err := ParsePublicKeyFromPEM(pem.EncodeToMemory(&pem.Block{
+			Type:  "PUBLIC KEY",
+			Bytes: der,
+		}))
+		assert.Nil(t, err)
+		assert.Equal(t, pub, key)
+	}
+
+	_, err = ParsePublicKeyFromPEM([]byte("bad"))
+	assert.Equal(t, ErrUnsupportedKey, err)
+}
+
+func ecJwk(kid string, key *ecdsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "EC",
+		Kid: kid,
+		Crv: key.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(key.X.Bytes()),
+		Y:   base64.RawURLEncoding.EncodeToString(key.Y.Bytes()),
+	}
+}
+
+func marshalJwks(t *testing.T, keys []jsonWebKey) []byte {
+	content, err := json.Marshal(jsonWebKeySet{Keys: keys})
+	assert.Nil(t, err)
+	return content
+}
+
+func rsaJwk(kid string, key *rsa.PublicKey) jsonWebKey {
+	return jsonWebKey{
+		Kty: "RSA",
+		Kid: kid,
+		Alg: jwt.SigningMethodRS256.Alg(),
+		N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
@@ -1,6 +1,7 @@
 package token
 
 import (
+	"errors"
 	"net/http"
 	"sync"
 	"sync/atomic"
@@ -13,6 +14,15 @@ import (
 
 const claimHistoryResetDuration = time.Hour * 24
 
+var (
+	// ErrInvalidAudience is an error that indicates the aud claim is not accepted.
+	ErrInvalidAudience = errors.New("invalid token audience")
+	// ErrInvalidIssuer is an error that indicates the iss claim is not accepted.
+	ErrInvalidIssuer = errors.New("invalid token issuer")
+
+	errNoSecret = errors.New("no secret for hmac signed token")
+)
+
 type (
 	// ParseOption defines the method to customize a TokenParser.
 	ParseOption func(parser *TokenParser)
@@ -22,6 +32,11 @@ type (
 		resetTime     time.Duration
 		resetDuration time.Duration
 		history       sync.Map
+		keys          *KeySet
+		validMethods  []string
+		audiences     []string
+		issuer        string
+		leeway        time.Duration
 	}
 )
 
@@ -79,10 +94,17 @@ func (tp *TokenParser) ParseToken(r *http.Request, secret, prevSecret string) (*
 }
 
 func (tp *TokenParser) doParseToken(r *http.Request, secret string) (*jwt.Token, error) {
-	return request.ParseFromRequest(r, request.AuthorizationHeaderExtractor,
-		func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		}, request.WithParser(newParser()))
+	token, err := request.ParseFromRequest(r, request.AuthorizationHeaderExtractor,
+		tp.keyFunc(secret), request.WithParser(tp.newParser()))
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tp.validateClaims(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
 }
 
 func (tp *TokenParser) incrementCount(secret string) {
@@ -103,6 +125,27 @@ func (tp *TokenParser) incrementCount(secret string) {
 	}
 }
 
+// keyFunc returns the secret for the hmac signed tokens,
+// and the keys in the KeySet for the tokens signed with RSA, ECDSA or EdDSA.
+func (tp *TokenParser) keyFunc(secret string) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if tp.keys == nil {
+			return []byte(secret), nil
+		}
+
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			// don't accept the tokens signed with empty secret
+			if len(secret) == 0 {
+				return nil, errNoSecret
+			}
+
+			return []byte(secret), nil
+		}
+
+		return tp.keys.Key(token)
+	}
+}
+
 func (tp *TokenParser) loadCount(secret string) uint64 {
 	value, ok := tp.history.Load(secret)
 	if ok {
@@ -112,6 +155,99 @@ func (tp *TokenParser) loadCount(secret string) uint64 {
 	return 0
 }
 
+func (tp *TokenParser) newParser() *jwt.Parser {
+	opts := []jwt.ParserOption{
+		jwt.WithJSONNumber(),
+		// the claims are validated by validateClaims, to allow the clock skew.
+		jwt.WithoutClaimsValidation(),
+	}
+	if len(tp.validMethods) > 0 {
+		opts = append(opts, jwt.WithValidMethods(tp.validMethods))
+	}
+
+	return jwt.NewParser(opts...)
+}
+
+// validateClaims validates the exp, iat and nbf claims with the leeway,
+// and the aud and iss claims if required.
+func (tp *TokenParser) validateClaims(token *jwt.Token) error {
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return token.Claims.Valid()
+	}
+
+	vErr := new(jwt.ValidationError)
+	now := time.Now().Unix()
+	leeway := int64(tp.leeway / time.Second)
+	if !claims.VerifyExpiresAt(now-leeway, false) {
+		vErr.Inner = errors.New("token is expired")
+		vErr.Errors |= jwt.ValidationErrorExpired
+	}
+	if !claims.VerifyIssuedAt(now+leeway, false) {
+		vErr.Inner = errors.New("token used before issued")
+		vErr.Errors |= jwt.ValidationErrorIssuedAt
+	}
+	if !claims.VerifyNotBefore(now+leeway, false) {
+		vErr.Inner = errors.New("token is not valid yet")
+		vErr.Errors |= jwt.ValidationErrorNotValidYet
+	}
+	if len(tp.audiences) > 0 && !tp.verifyAudience(claims) {
+		vErr.Inner = ErrInvalidAudience
+		vErr.Errors |= jwt.ValidationErrorAudience
+	}
+	if len(tp.issuer) > 0 && !claims.VerifyIssuer(tp.issuer, true) {
+		vErr.Inner = ErrInvalidIssuer
+		vErr.Errors |= jwt.ValidationErrorIssuer
+	}
+
+	if vErr.Errors != 0 {
+		return vErr
+	}
+
+	return nil
+}
+
+func (tp *TokenParser) verifyAudience(claims jwt.MapClaims) bool {
+	for _, aud := range tp.audiences {
+		if claims.VerifyAudience(aud, true) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithAudiences returns a func to customize a TokenParser with the accepted audiences,
+// the tokens are accepted if their aud claims contain any of them.
+func WithAudiences(audiences ...string) ParseOption {
+	return func(parser *TokenParser) {
+		parser.audiences = audiences
+	}
+}
+
+// WithIssuer returns a func to customize a TokenParser with the required issuer.
+func WithIssuer(issuer string) ParseOption {
+	return func(parser *TokenParser) {
+		parser.issuer = issuer
+	}
+}
+
+// WithKeySet returns a func to customize a TokenParser to verify the tokens
+// signed with RSA, ECDSA or EdDSA with the keys in ks.
+func WithKeySet(ks *KeySet) ParseOption {
+	return func(parser *TokenParser) {
+		parser.keys = ks
+	}
+}
+
+// WithLeeway returns a func to customize a TokenParser with the leeway
+// to allow the clock skew on validating the exp, iat and nbf claims.
+func WithLeeway(leeway time.Duration) ParseOption {
+	return func(parser *TokenParser) {
+		parser.leeway = leeway
+	}
+}
+
 // WithResetDuration returns a func to customize a TokenParser with reset duration.
 func WithResetDuration(duration time.Duration) ParseOption {
 	return func(parser *TokenParser) {
@@ -119,6 +255,10 @@ func WithResetDuration(duration time.Duration) ParseOption {
 	}
 }
 
-func newParser() *jwt.Parser {
-	return jwt.NewParser(jwt.WithJSONNumber())
+// WithValidMethods returns a func to customize a TokenParser with the accepted algorithms,
+// like RS256 and ES256, all algorithms are accepted if not set.
+func WithValidMethods(methods ...string) ParseOption {
+	return func(parser *TokenParser) {
+		parser.validMethods = methods
+	}
 }
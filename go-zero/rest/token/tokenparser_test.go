@@ -1,6 +1,8 @@
 package token
 
 import (
+	"crypto/rand"
+	"crypto/rsa"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -70,6 +72,154 @@ func TestTokenParser_Expired(t *testing.T) {
 	assert.Equal(t, "value", tok.Claims.(jwt.MapClaims)["key"])
 }
 
+func TestTokenParser_Claims(t *testing.T) {
+	const key = "14F17379-EB8F-411B-8F12-6929002DCA76"
+	now := time.Now()
+	tests := []struct {
+		name   string
+		claims jwt.MapClaims
+		opts   []ParseOption
+		valid  bool
+	}{
+		{
+			name:   "expired",
+			claims: jwt.MapClaims{"exp": now.Add(-time.Second * 5).Unix()},
+		},
+		{
+			name:   "expired within leeway",
+			claims: jwt.MapClaims{"exp": now.Add(-time.Second * 5).Unix()},
+			opts:   []ParseOption{WithLeeway(time.Minute)},
+			valid:  true,
+		},
+		{
+			name:   "not valid yet",
+			claims: jwt.MapClaims{"nbf": now.Add(time.Second * 5).Unix()},
+		},
+		{
+			name:   "not valid yet within leeway",
+			claims: jwt.MapClaims{"nbf": now.Add(time.Second * 5).Unix()},
+			opts:   []ParseOption{WithLeeway(time.Minute)},
+			valid:  true,
+		},
+		{
+			name:   "issued in future",
+			claims: jwt.MapClaims{"iat": now.Add(time.Second * 5).Unix()},
+		},
+		{
+			name:   "audience",
+			claims: jwt.MapClaims{"aud": []string{"foo", "bar"}},
+			opts:   []ParseOption{WithAudiences("bar", "baz")},
+			valid:  true,
+		},
+		{
+			name:   "bad audience",
+			claims: jwt.MapClaims{"aud": "foo"},
+			opts:   []ParseOption{WithAudiences("bar")},
+		},
+		{
+			name:   "missing audience",
+			claims: jwt.MapClaims{},
+			opts:   []ParseOption{WithAudiences("bar")},
+		},
+		{
+			name:   "issuer",
+			claims: jwt.MapClaims{"iss": "foo"},
+			opts:   []ParseOption{WithIssuer("foo")},
+			valid:  true,
+		},
+		{
+			name:   "bad issuer",
+			claims: jwt.MapClaims{"iss": "bar"},
+			opts:   []ParseOption{WithIssuer("foo")},
+		},
+		{
+			name:   "bad method",
+			claims: jwt.MapClaims{},
+			opts:   []ParseOption{WithValidMethods("RS256")},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, test.claims).SignedString([]byte(key))
+			assert.Nil(t, err)
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Set("Authorization", "Bearer "+tok)
+
+			_, err = NewTokenParser(test.opts...).ParseToken(req, key, "")
+			if test.valid {
+				assert.Nil(t, err)
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestTokenParser_KeySet(t *testing.T) {
+	const key = "14F17379-EB8F-411B-8F12-6929002DCA76"
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.Nil(t, err)
+	ks, err := NewKeySet(WithPublicKey("foo", &rsaKey.PublicKey))
+	assert.Nil(t, err)
+
+	rsaToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{"key": "value"})
+	rsaToken.Header[kidHeader] = "foo"
+	signedRsa, err := rsaToken.SignedString(rsaKey)
+	assert.Nil(t, err)
+	signedHmac, err := buildToken(key, map[string]interface{}{"key": "value"}, 3600)
+	assert.Nil(t, err)
+	signedEmpty, err := buildToken("", map[string]interface{}{"key": "value"}, 3600)
+	assert.Nil(t, err)
+
+	tests := []struct {
+		name   string
+		token  string
+		secret string
+		valid  bool
+	}{
+		{
+			name:  "rsa",
+			token: signedRsa,
+			valid: true,
+		},
+		{
+			name:   "rsa with secret",
+			token:  signedRsa,
+			secret: key,
+			valid:  true,
+		},
+		{
+			name:   "hmac",
+			token:  signedHmac,
+			secret: key,
+			valid:  true,
+		},
+		{
+			name:  "hmac without secret",
+			token: signedEmpty,
+		},
+	}
+
+	parser := NewTokenParser(WithKeySet(ks))
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://localhost", nil)
+			req.Header.Set("Authorization", "Bearer "+test.token)
+
+			tok, err := parser.ParseToken(req, test.secret, "")
+			if test.valid {
+				assert.Nil(t, err)
+				assert.Equal(t, "value", tok.Claims.(jwt.MapClaims)["key"])
+			} else {
+				assert.NotNil(t, err)
+			}
+		})
+	}
+}
+
 func buildToken(secretKey string, payloads map[string]interface{}, seconds int64) (string, error) {
 	now := time.Now().Unix()
 	claims := make(jwt.MapClaims)
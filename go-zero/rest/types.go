@@ -3,6 +3,10 @@ package rest
 import (
 	"net/http"
 	"time"
+
+	"github.com/zeromicro/go-zero/rest/handler"
+	"github.com/zeromicro/go-zero/rest/internal/fileserver"
+	"golang.org/x/net/websocket"
 )
 
 type (
@@ -14,6 +18,24 @@ type (
 		Method  string
 		Path    string
 		Handler http.HandlerFunc
+		// Request and Response are optional samples of the request and response types,
+		// which are used to describe the route in the OpenAPI document.
+		Request  interface{}
+		Response interface{}
+	}
+
+	// FileServerOption defines the method to customize a file server.
+	FileServerOption func(opts *fileserver.Options)
+
+	// A WebSocketHandler handles an upgraded websocket connection.
+	// The request context, which carries the tracing span and the jwt claims,
+	// can be retrieved by conn.Request().Context().
+	WebSocketHandler func(conn *websocket.Conn)
+
+	// A WebSocketRoute is a websocket route.
+	WebSocketRoute struct {
+		Path    string
+		Handler WebSocketHandler
 	}
 
 	// RouteOption defines the method to customize a featured route.
@@ -23,6 +45,23 @@ type (
 		enabled    bool
 		secret     string
 		prevSecret string // 旧密钥 用于新旧密钥切换的过渡期
+		// the public keys and the claims checks, nil if only with secrets.
+		conf *JwtConf
+	}
+
+	corsSetting struct {
+		CorsConf
+		enabled bool
+	}
+
+	idempotencySetting struct {
+		IdempotencyConf
+		enabled bool
+	}
+
+	rateLimitSetting struct {
+		RateLimitConf
+		enabled bool
 	}
 
 	signatureSetting struct {
@@ -31,10 +70,18 @@ type (
 	}
 
 	featuredRoutes struct {
-		timeout   time.Duration // 超时
-		priority  bool // 是否优先级
-		jwt       jwtSetting
-		signature signatureSetting // 验签配置
-		routes    []Route // 通过AddRoutes添加的路由
+		timeout     time.Duration // 超时
+		maxBytes    int64 // 请求体大小限制
+		priority    bool // 是否优先级
+		disableLog  bool
+		sse         bool
+		websocket   bool
+		jwt         jwtSetting
+		signature   signatureSetting // 验签配置
+		rateLimit   rateLimitSetting
+		idempotency idempotencySetting
+		cors        corsSetting
+		cache       *handler.ResponseCache
+		routes      []Route // 通过AddRoutes添加的路由
 	}
 )
@@ -0,0 +1,140 @@
+package rest
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/zeromicro/go-zero/core/lang"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/syncx"
+	"github.com/zeromicro/go-zero/rest/internal"
+	"golang.org/x/net/websocket"
+)
+
+// allWebSocketOrigins allows the websocket connections from all origins.
+const allWebSocketOrigins = "*"
+
+var errOriginNotAllowed = errors.New("websocket origin not allowed")
+
+// webSocketManager limits the concurrent websocket connections,
+// and keeps track of them to close them on shutting down,
+// because http.Server.Shutdown doesn't close hijacked connections.
+type webSocketManager struct {
+	limit *syncx.Limit
+	// the allowed origins besides the host of the requests
+	origins    map[string]lang.PlaceholderType
+	allOrigins bool
+	lock       sync.Mutex
+	conns      map[*websocket.Conn]lang.PlaceholderType
+	closed     bool
+}
+
+func newWebSocketManager(maxConns int, origins []string) *webSocketManager {
+	manager := &webSocketManager{
+		origins: make(map[string]lang.PlaceholderType),
+		conns:   make(map[*websocket.Conn]lang.PlaceholderType),
+	}
+	for _, origin := range origins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		if origin == allWebSocketOrigins {
+			manager.allOrigins = true
+		} else {
+			manager.origins[origin] = lang.Placeholder
+		}
+	}
+	if maxConns > 0 {
+		limit := syncx.NewLimit(maxConns)
+		manager.limit = &limit
+	}
+
+	return manager
+}
+
+func (m *webSocketManager) add(conn *websocket.Conn) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	if m.closed {
+		return false
+	}
+
+	m.conns[conn] = lang.Placeholder
+	return true
+}
+
+func (m *webSocketManager) closeAll() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	m.closed = true
+	for conn := range m.conns {
+		if err := conn.Close(); err != nil {
+			logx.Error(err)
+		}
+	}
+	m.conns = make(map[*websocket.Conn]lang.PlaceholderType)
+}
+
+func (m *webSocketManager) handler(handle WebSocketHandler) http.HandlerFunc {
+	server := websocket.Server{
+		Handshake: m.acceptOrigin,
+		Handler: func(conn *websocket.Conn) {
+			if !m.add(conn) {
+				return
+			}
+			defer m.remove(conn)
+
+			handle(conn)
+		},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if m.limit == nil {
+			server.ServeHTTP(w, r)
+			return
+		}
+
+		if !m.limit.TryBorrow() {
+			internal.Errorf(r, "concurrent websocket connections over limit, rejected with code %d",
+				http.StatusServiceUnavailable)
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		defer func() {
+			if err := m.limit.Return(); err != nil {
+				logx.Error(err)
+			}
+		}()
+
+		server.ServeHTTP(w, r)
+	}
+}
+
+func (m *webSocketManager) remove(conn *websocket.Conn) {
+	m.lock.Lock()
+	delete(m.conns, conn)
+	m.lock.Unlock()
+}
+
+// acceptOrigin accepts the requests from the same origin, or the allowed origins,
+// to prevent the cross-site websocket hijacking with the cookies of the users.
+// The requests without Origin header, which are sent by non-browser clients, are accepted.
+func (m *webSocketManager) acceptOrigin(config *websocket.Config, r *http.Request) error {
+	origin, err := websocket.Origin(config, r)
+	if err != nil {
+		return err
+	}
+
+	config.Origin = origin
+	if origin == nil || m.allOrigins || strings.EqualFold(origin.Host, r.Host) {
+		return nil
+	}
+
+	if _, ok := m.origins[strings.ToLower(origin.Scheme+"://"+origin.Host)]; ok {
+		return nil
+	}
+
+	return errOriginNotAllowed
+}
@@ -0,0 +1,145 @@
+package rest
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/conf"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/rest/router"
+	"golang.org/x/net/websocket"
+)
+
+func TestWebSocketRoute(t *testing.T) {
+	logx.Disable()
+
+	ng := newEngine(RestConf{
+		Timeout:       10,
+		MaxWebSockets: 1,
+	})
+	connected := make(chan struct{})
+	ng.addRoutes(featuredRoutes{
+		websocket: true,
+		routes: []Route{{
+			Method: http.MethodGet,
+			Path:   "/ws",
+			Handler: ng.webSocketHandler(func(conn *websocket.Conn) {
+				connected <- struct{}{}
+				var msg string
+				for websocket.Message.Receive(conn, &msg) == nil {
+					if err := websocket.Message.Send(conn, "echo:"+msg); err != nil {
+						return
+					}
+				}
+			}),
+		}},
+	})
+	rt := router.NewRouter()
+	assert.Nil(t, ng.bindRoutes(rt))
+	ts := httptest.NewServer(rt)
+	defer ts.Close()
+
+	url := "ws" + strings.TrimPrefix(ts.URL, "http") + "/ws"
+	conn, err := websocket.Dial(url, "", ts.URL)
+	assert.Nil(t, err)
+	<-connected
+
+	// longer than the timeout, the connection must not be cut off
+	time.Sleep(time.Millisecond * 50)
+	assert.Nil(t, websocket.Message.Send(conn, "hello"))
+	var reply string
+	assert.Nil(t, websocket.Message.Receive(conn, &reply))
+	assert.Equal(t, "echo:hello", reply)
+
+	// over the limit of concurrent sockets
+	_, err = websocket.Dial(url, "", ts.URL)
+	assert.NotNil(t, err)
+
+	ng.webSockets.closeAll()
+	assert.NotNil(t, websocket.Message.Receive(conn, &reply))
+
+	// closed, no more connections accepted
+	conn, err = websocket.Dial(url, "", ts.URL)
+	if err == nil {
+		assert.NotNil(t, websocket.Message.Receive(conn, &reply))
+	}
+}
+
+func TestWebSocketRoute_Unauthorized(t *testing.T) {
+	logx.Disable()
+
+	var c RestConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Name: foo\nPort: 54321"), &c))
+	srv := MustNewServer(c)
+	srv.AddWebSocketRoute(WebSocketRoute{
+		Path: "/ws",
+		Handler: func(conn *websocket.Conn) {
+			t.Fail()
+		},
+	}, WithJwt("thesecret"))
+	assert.Nil(t, srv.ngin.bindRoutes(srv.router))
+	ts := httptest.NewServer(srv.router)
+	defer ts.Close()
+
+	resp, err := ts.Client().Get(ts.URL + "/ws")
+	assert.Nil(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestWebSocketManager_AcceptOrigin(t *testing.T) {
+	tests := []struct {
+		name    string
+		origins []string
+		origin  string
+		accept  bool
+	}{
+		{
+			name:   "no origin",
+			accept: true,
+		},
+		{
+			name:   "same origin",
+			origin: "https://foo.com",
+			accept: true,
+		},
+		{
+			name:   "cross origin",
+			origin: "https://evil.com",
+		},
+		{
+			name:    "allowed origin",
+			origins: []string{"https://Bar.com"},
+			origin:  "https://bar.com",
+			accept:  true,
+		},
+		{
+			name:    "allowed origin with other scheme",
+			origins: []string{"https://bar.com"},
+			origin:  "http://bar.com",
+		},
+		{
+			name:    "all origins",
+			origins: []string{"*"},
+			origin:  "https://evil.com",
+			accept:  true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			m := newWebSocketManager(0, test.origins)
+			r := httptest.NewRequest(http.MethodGet, "https://foo.com/ws", nil)
+			if len(test.origin) > 0 {
+				r.Header.Set("Origin", test.origin)
+			}
+			err := m.acceptOrigin(&websocket.Config{Version: websocket.ProtocolVersionHybi13}, r)
+			assert.Equal(t, test.accept, err == nil)
+		})
+	}
+}
@@ -1,16 +1,30 @@
 package zrpc
 
 import (
+	"fmt"
 	"log"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/zeromicro/go-zero/zrpc/internal"
 	"github.com/zeromicro/go-zero/zrpc/internal/auth"
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/consistenthash"
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/p2c"
 	"github.com/zeromicro/go-zero/zrpc/internal/clientinterceptors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
 )
 
+const defaultRetryBackoff = time.Millisecond * 100
+
 var (
+	// SetHashKey is an alias of consistenthash.SetHashKey.
+	SetHashKey = consistenthash.SetHashKey
+	// WithCanary is an alias of internal.WithCanary.
+	WithCanary = internal.WithCanary
+	// WithConsistentHash is an alias of internal.WithConsistentHash.
+	WithConsistentHash = internal.WithConsistentHash
 	// WithDialOption is an alias of internal.WithDialOption.
 	WithDialOption = internal.WithDialOption
 	// WithNonBlock sets the dialing to be nonblock.
@@ -60,6 +74,27 @@ func NewClient(c RpcClientConf, options ...ClientOption) (Client, error) {
 	if c.Timeout > 0 { // 超时
 		opts = append(opts, WithTimeout(time.Duration(c.Timeout)*time.Millisecond))
 	}
+	switch c.Balancer {
+	case "", p2c.Name:
+	case consistenthash.Name:
+		// the canary routing is done by p2c, it would be ignored silently with consistent_hash
+		if len(c.Canary.Label) > 0 {
+			return nil, fmt.Errorf("canary routing is not supported by balancer %q", c.Balancer)
+		}
+		opts = append(opts, WithConsistentHash(c.HashKey))
+	default:
+		return nil, fmt.Errorf("unknown balancer %q", c.Balancer)
+	}
+	if len(c.Canary.Label) > 0 {
+		opts = append(opts, WithCanary(c.Canary.Label, c.Canary.Percent))
+	}
+	if c.Retry.MaxAttempts > 1 || len(c.MethodRetry) > 0 {
+		retryOpt, err := buildRetryOption(c)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, retryOpt)
+	}
 
 	opts = append(opts, options...)
 
@@ -97,3 +132,61 @@ func (rc *RpcClient) Conn() *grpc.ClientConn {
 func SetClientSlowThreshold(threshold time.Duration) {
 	clientinterceptors.SetSlowThreshold(threshold)
 }
+
+func buildRetryOption(c RpcClientConf) (ClientOption, error) {
+	policy, err := buildRetryPolicy(c.Retry)
+	if err != nil {
+		return nil, err
+	}
+
+	methods := make(map[string]clientinterceptors.RetryPolicy)
+	for _, mc := range c.MethodRetry {
+		mp, err := buildRetryPolicy(mc.RetryConf)
+		if err != nil {
+			return nil, fmt.Errorf("retry of method %q: %w", mc.Method, err)
+		}
+
+		methods["/"+strings.TrimPrefix(mc.Method, "/")] = mp
+	}
+
+	return internal.WithRetry(policy, methods), nil
+}
+
+func buildRetryPolicy(c RetryConf) (clientinterceptors.RetryPolicy, error) {
+	policy := clientinterceptors.RetryPolicy{
+		MaxAttempts:    c.MaxAttempts,
+		InitialBackoff: c.InitialBackoff,
+		MaxBackoff:     c.MaxBackoff,
+		Budget:         c.Budget,
+	}
+	if policy.InitialBackoff <= 0 {
+		policy.InitialBackoff = defaultRetryBackoff
+	}
+
+	for _, name := range c.Codes {
+		code, err := parseCode(name)
+		if err != nil {
+			return policy, err
+		}
+
+		policy.Codes = append(policy.Codes, code)
+	}
+
+	return policy, nil
+}
+
+// parseCode parses the code names like Unavailable or UNAVAILABLE.
+func parseCode(name string) (codes.Code, error) {
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(name)))); err == nil {
+		return code, nil
+	}
+
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if strings.EqualFold(c.String(), name) {
+			return c, nil
+		}
+	}
+
+	return code, fmt.Errorf("unknown rpc code %q", name)
+}
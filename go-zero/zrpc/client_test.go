@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/canary"
 	"github.com/zeromicro/go-zero/core/discov"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/zrpc/internal/mock"
@@ -190,3 +192,165 @@ func TestNewClientWithError(t *testing.T) {
 	)
 	assert.NotNil(t, err)
 }
+
+func TestNewClientWithRetry(t *testing.T) {
+	var attempts int32
+	cli, err := NewClient(
+		RpcClientConf{
+			Endpoints: []string{"foo"},
+			Timeout:   1000,
+			Retry: RetryConf{
+				MaxAttempts:    2,
+				InitialBackoff: time.Millisecond,
+			},
+			MethodRetry: []MethodRetryConf{
+				{
+					Method: "mock.DepositService/Deposit",
+					RetryConf: RetryConf{
+						MaxAttempts:    3,
+						Codes:          []string{"INVALID_ARGUMENT"},
+						InitialBackoff: time.Millisecond,
+					},
+				},
+			},
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+		WithUnaryClientInterceptor(func(ctx context.Context, method string, req, reply interface{},
+			cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+			atomic.AddInt32(&attempts, 1)
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}),
+	)
+	assert.Nil(t, err)
+
+	_, err = mock.NewDepositServiceClient(cli.Conn()).Deposit(context.Background(),
+		&mock.DepositRequest{Amount: -1})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestNewClientWithBadRetry(t *testing.T) {
+	_, err := NewClient(RpcClientConf{
+		Endpoints: []string{"foo"},
+		Retry: RetryConf{
+			MaxAttempts: 2,
+			Codes:       []string{"foo"},
+		},
+	})
+	assert.NotNil(t, err)
+
+	_, err = NewClient(RpcClientConf{
+		Endpoints: []string{"foo"},
+		MethodRetry: []MethodRetryConf{
+			{
+				Method: "/foo.Foo/Bar",
+				RetryConf: RetryConf{
+					MaxAttempts: 2,
+					Codes:       []string{"foo"},
+				},
+			},
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestBuildRetryPolicy(t *testing.T) {
+	policy, err := buildRetryPolicy(RetryConf{
+		MaxAttempts: 3,
+		Codes:       []string{"Unavailable", "RESOURCE_EXHAUSTED", "deadlineexceeded"},
+		MaxBackoff:  time.Second,
+		Budget:      time.Second * 5,
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, 3, policy.MaxAttempts)
+	assert.Equal(t, []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.DeadlineExceeded}, policy.Codes)
+	assert.Equal(t, defaultRetryBackoff, policy.InitialBackoff)
+	assert.Equal(t, time.Second, policy.MaxBackoff)
+	assert.Equal(t, time.Second*5, policy.Budget)
+}
+
+func TestParseCode(t *testing.T) {
+	tests := []struct {
+		name   string
+		expect codes.Code
+		err    bool
+	}{
+		{name: "UNAVAILABLE", expect: codes.Unavailable},
+		{name: "Unavailable", expect: codes.Unavailable},
+		{name: "CANCELLED", expect: codes.Canceled},
+		{name: "Canceled", expect: codes.Canceled},
+		{name: "DeadlineExceeded", expect: codes.DeadlineExceeded},
+		{name: "foo", err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := parseCode(test.name)
+			if test.err {
+				assert.NotNil(t, err)
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, test.expect, code)
+		})
+	}
+}
+
+func TestNewClientWithConsistentHash(t *testing.T) {
+	cli, err := NewClient(
+		RpcClientConf{
+			Endpoints: []string{"foo"},
+			Timeout:   1000,
+			Balancer:  "consistent_hash",
+			HashKey:   "x-user-id",
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+	)
+	assert.Nil(t, err)
+
+	ctx := SetHashKey(context.Background(), "foo")
+	resp, err := mock.NewDepositServiceClient(cli.Conn()).Deposit(ctx, &mock.DepositRequest{Amount: 1})
+	assert.Nil(t, err)
+	assert.True(t, resp.GetOk())
+
+	_, err = NewClient(RpcClientConf{
+		Endpoints: []string{"foo"},
+		Balancer:  "foo",
+	})
+	assert.NotNil(t, err)
+
+	_, err = NewClient(RpcClientConf{
+		Endpoints: []string{"foo"},
+		Balancer:  "consistent_hash",
+		Canary: CanaryConf{
+			Label: "v2",
+		},
+	})
+	assert.NotNil(t, err)
+}
+
+func TestNewClientWithCanary(t *testing.T) {
+	cli, err := NewClient(
+		RpcClientConf{
+			Endpoints: []string{"foo"},
+			Timeout:   1000,
+			Canary: CanaryConf{
+				Label:   "v2",
+				Percent: 50,
+			},
+		},
+		WithDialOption(grpc.WithContextDialer(dialer())),
+	)
+	assert.Nil(t, err)
+
+	// fall back to the stable instances without the canary instances
+	for _, ctx := range []context.Context{
+		context.Background(),
+		canary.NewContext(context.Background(), "v2"),
+	} {
+		resp, err := mock.NewDepositServiceClient(cli.Conn()).Deposit(ctx, &mock.DepositRequest{Amount: 1})
+		assert.Nil(t, err)
+		assert.True(t, resp.GetOk())
+	}
+}
@@ -1,9 +1,14 @@
 package zrpc
 
 import (
+	"fmt"
+	"net/url"
+	"time"
+
 	"github.com/zeromicro/go-zero/core/discov"
 	"github.com/zeromicro/go-zero/core/service"
 	"github.com/zeromicro/go-zero/core/stores/redis"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
 	"github.com/zeromicro/go-zero/zrpc/resolver"
 )
 
@@ -19,6 +24,30 @@ type (
 		// setting 0 means no timeout
 		Timeout      int64 `json:",default=2000"`
 		CpuThreshold int64 `json:",default=900,range=[0:1000]"`
+		// serve the standard grpc health service with the readiness probes
+		Health bool `json:",optional"`
+		// the time to report not serving before stopping the server on shutting down,
+		// it should be less than the time to force quit, which is 5.5s
+		DrainDelay time.Duration `json:",optional"`
+		// serve the grpc reflection service, for the tools like grpcurl, only in DebugModes
+		Reflection bool `json:",optional"`
+		// serve the grpc channelz service, only in DebugModes
+		Channelz bool `json:",optional"`
+		// the modes of ServiceConf.Mode to serve the debug services, like Reflection and Channelz
+		DebugModes []string `json:",default=[dev,test]"`
+		// the metadata published to etcd with the address, enable it after all the clients
+		// are upgraded, because the older clients can't resolve the addresses with metadata.
+		Metadata MetadataConf `json:",optional"`
+	}
+
+	// A MetadataConf is the metadata of a rpc server instance.
+	MetadataConf struct {
+		// the zone of the instance, the clients prefer the instances in the same zone
+		Zone string `json:",optional"`
+		// the relative weight of the instance, setting 0 means the default weight 100
+		Weight  int               `json:",optional,range=[0:10000]"`
+		Version string            `json:",optional"`
+		Tags    map[string]string `json:",optional"`
 	}
 
 	// A RpcClientConf is a rpc client config.
@@ -30,6 +59,48 @@ type (
 		Token     string          `json:",optional"`
 		NonBlock  bool            `json:",optional"`
 		Timeout   int64           `json:",default=2000"`
+		// the zone of the client, the instances in the same zone are preferred with etcd
+		Zone string `json:",optional"`
+		// the load balancer, p2c_ewma or consistent_hash
+		Balancer string `json:",default=p2c_ewma,options=p2c_ewma|consistent_hash"`
+		// the outgoing metadata key to hash by with consistent_hash, like x-user-id,
+		// the hash keys can also be set by SetHashKey
+		HashKey string `json:",optional"`
+		// route the calls to the canary instances, works with p2c_ewma only
+		Canary CanaryConf `json:",optional"`
+		// the Timeout applies to each attempt, and Retry.Budget applies to all the attempts
+		Retry       RetryConf         `json:",optional"`
+		MethodRetry []MethodRetryConf `json:",optional"`
+	}
+
+	// A CanaryConf is the canary routing config of the rpc calls.
+	// The calls with the X-Canary headers in rest, or the x-canary metadata in zrpc, are routed to
+	// the instances matching the labels, and to the stable instances if no instances match.
+	CanaryConf struct {
+		// the label of the canary instances, like v2 to match Metadata.Version of the servers,
+		// or env=canary to match Metadata.Tags, the instances not matching it are stable.
+		Label string `json:",optional"`
+		// the percentage of the calls without labels routed to the canary instances
+		Percent int `json:",optional,range=[0:100]"`
+	}
+
+	// A RetryConf is the retry policy of the rpc calls.
+	RetryConf struct {
+		// no retry if not greater than 1
+		MaxAttempts int `json:",optional"`
+		// the retryable codes, like Unavailable or UNAVAILABLE, Unavailable is used if empty
+		Codes          []string      `json:",optional"`
+		InitialBackoff time.Duration `json:",default=100ms"`
+		MaxBackoff     time.Duration `json:",default=1s"`
+		// the overall deadline of all the attempts, setting 0 means no limit
+		Budget time.Duration `json:",optional"`
+	}
+
+	// A MethodRetryConf is the retry policy of a rpc method.
+	MethodRetryConf struct {
+		// the full method name, like /pkg.Service/Method
+		Method string
+		RetryConf
 	}
 )
 
@@ -54,11 +125,31 @@ func NewEtcdClientConf(hosts []string, key, app, token string) RpcClientConf {
 	}
 }
 
+func (mc MetadataConf) toInstanceMetadata() instance.Metadata {
+	return instance.Metadata{
+		Zone:    mc.Zone,
+		Weight:  mc.Weight,
+		Version: mc.Version,
+		Tags:    mc.Tags,
+	}
+}
+
 // HasEtcd checks if there is etcd settings in config.
 func (sc RpcServerConf) HasEtcd() bool {
 	return len(sc.Etcd.Hosts) > 0 && len(sc.Etcd.Key) > 0
 }
 
+// IsDebugMode checks if the debug services, like reflection and channelz, are allowed in the mode.
+func (sc RpcServerConf) IsDebugMode() bool {
+	for _, mode := range sc.DebugModes {
+		if mode == sc.Mode {
+			return true
+		}
+	}
+
+	return false
+}
+
 // Validate validates the config.
 func (sc RpcServerConf) Validate() error {
 	if !sc.Auth {
@@ -91,7 +182,12 @@ func (cc RpcClientConf) BuildTarget() (string, error) {
 	}
 
 	// etcd 服务发现
-	return resolver.BuildDiscovTarget(cc.Etcd.Hosts, cc.Etcd.Key), nil
+	target := resolver.BuildDiscovTarget(cc.Etcd.Hosts, cc.Etcd.Key)
+	if len(cc.Zone) > 0 {
+		target = fmt.Sprintf("%s?%s", target, url.Values{"zone": []string{cc.Zone}}.Encode())
+	}
+
+	return target, nil
 }
 
 // HasCredential checks if there is a credential in config.
@@ -2,8 +2,10 @@ package zrpc
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/conf"
 	"github.com/zeromicro/go-zero/core/discov"
 	"github.com/zeromicro/go-zero/core/service"
 	"github.com/zeromicro/go-zero/core/stores/redis"
@@ -40,3 +42,94 @@ func TestRpcServerConf(t *testing.T) {
 	conf.Redis.Host = "localhost:5678"
 	assert.Nil(t, conf.Validate())
 }
+
+func TestRpcClientConfBalancer(t *testing.T) {
+	var c RpcClientConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte("Endpoints:\n  - localhost:1234"), &c))
+	assert.Equal(t, "p2c_ewma", c.Balancer)
+
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Endpoints:
+  - localhost:1234
+Balancer: consistent_hash
+HashKey: x-user-id
+Retry:
+  MaxAttempts: 3`), &c))
+	assert.Equal(t, "consistent_hash", c.Balancer)
+	assert.Equal(t, "x-user-id", c.HashKey)
+	assert.Equal(t, 3, c.Retry.MaxAttempts)
+	assert.Equal(t, time.Millisecond*100, c.Retry.InitialBackoff)
+
+	assert.NotNil(t, conf.LoadConfigFromYamlBytes([]byte("Endpoints:\n  - localhost:1234\nBalancer: foo"), &c))
+}
+
+func TestRpcServerConfMetadata(t *testing.T) {
+	var c MetadataConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Zone: us-east-1a
+Weight: 50
+Version: v1.2.0
+Tags:
+  env: canary`), &c))
+	md := c.toInstanceMetadata()
+	assert.Equal(t, "us-east-1a", md.Zone)
+	assert.Equal(t, 50, md.Weight)
+	assert.Equal(t, "v1.2.0", md.Version)
+	assert.Equal(t, map[string]string{"env": "canary"}, md.Tags)
+}
+
+func TestRpcClientConfZone(t *testing.T) {
+	c := NewEtcdClientConf([]string{"localhost:1234"}, "key", "", "")
+	target, err := c.BuildTarget()
+	assert.Nil(t, err)
+	assert.Equal(t, "discov://localhost:1234/key", target)
+
+	c.Zone = "us-east-1a"
+	target, err = c.BuildTarget()
+	assert.Nil(t, err)
+	assert.Equal(t, "discov://localhost:1234/key?zone=us-east-1a", target)
+}
+
+func TestRpcClientConfCanary(t *testing.T) {
+	var c RpcClientConf
+	assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Endpoints:
+  - localhost:1234
+Canary:
+  Label: env=canary
+  Percent: 10`), &c))
+	assert.Equal(t, "env=canary", c.Canary.Label)
+	assert.Equal(t, 10, c.Canary.Percent)
+
+	assert.NotNil(t, conf.LoadConfigFromYamlBytes([]byte(`Endpoints:
+  - localhost:1234
+Canary:
+  Label: v2
+  Percent: 101`), &c))
+}
+
+func TestRpcServerConfDebugMode(t *testing.T) {
+	tests := []struct {
+		mode   string
+		modes  string
+		expect bool
+	}{
+		{mode: "dev", expect: true},
+		{mode: "test", expect: true},
+		{mode: "pre", expect: false},
+		{mode: "pro", expect: false},
+		{mode: "pre", modes: "DebugModes: [dev, pre]", expect: true},
+		{mode: "test", modes: "DebugModes: [dev, pre]", expect: false},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.mode, func(t *testing.T) {
+			var c RpcServerConf
+			assert.Nil(t, conf.LoadConfigFromYamlBytes([]byte(`Name: foo
+ListenOn: localhost:8080
+Mode: `+test.mode+`
+Reflection: true
+`+test.modes), &c))
+			assert.True(t, c.Reflection)
+			assert.Equal(t, test.expect, c.IsDebugMode())
+		})
+	}
+}
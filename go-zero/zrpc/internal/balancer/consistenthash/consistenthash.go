@@ -0,0 +1,149 @@
+package consistenthash
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/hash"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+)
+
+const (
+	// Name is the name of consistent hash balancer.
+	Name = "consistent_hash"
+
+	// the max load of a node is loadFactor times of the average load,
+	// the requests are routed to the next nodes if exceeded.
+	loadFactor = 1.25
+	// separates the hash key and the probe times to find the next nodes.
+	probeSeparator = "#"
+)
+
+func init() {
+	balancer.Register(newBuilder())
+}
+
+type (
+	hashKeyCtx struct{}
+
+	pickerBuilder struct{}
+
+	picker struct {
+		ring     *hash.ConsistentHash
+		conns    map[string]*subConn
+		list     []*subConn
+		inflight int64
+		r        *rand.Rand
+		lock     sync.Mutex
+	}
+
+	subConn struct {
+		inflight int64
+		conn     balancer.SubConn
+	}
+)
+
+// HashKeyFromContext returns the hash key in ctx.
+func HashKeyFromContext(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(hashKeyCtx{}).(string)
+	return key, ok && len(key) > 0
+}
+
+// SetHashKey returns a context with the hash key, the calls with the same hash key
+// are routed to the same node, unless the node is overloaded or removed.
+func SetHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyCtx{}, key)
+}
+
+func (b *pickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
+	if len(info.ReadySCs) == 0 {
+		return base.NewErrPicker(balancer.ErrNoSubConnAvailable)
+	}
+
+	p := &picker{
+		ring:  hash.NewConsistentHash(),
+		conns: make(map[string]*subConn),
+		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	for conn, connInfo := range info.ReadySCs {
+		// hashed by the addresses, to only remap the keys of the changed nodes
+		sc := &subConn{conn: conn}
+		p.ring.Add(connInfo.Address.Addr)
+		p.conns[connInfo.Address.Addr] = sc
+		p.list = append(p.list, sc)
+	}
+
+	return p
+}
+
+func newBuilder() balancer.Builder {
+	return base.NewBalancerBuilder(Name, new(pickerBuilder), base.Config{HealthCheck: true})
+}
+
+func (p *picker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
+	var chosen *subConn
+	if key, ok := HashKeyFromContext(info.Ctx); ok {
+		chosen = p.choose(key)
+	}
+	if chosen == nil {
+		// no hash key, just pick one randomly
+		p.lock.Lock()
+		chosen = p.list[p.r.Intn(len(p.list))]
+		p.lock.Unlock()
+	}
+
+	atomic.AddInt64(&p.inflight, 1)
+	atomic.AddInt64(&chosen.inflight, 1)
+
+	return balancer.PickResult{
+		SubConn: chosen.conn,
+		Done: func(info balancer.DoneInfo) {
+			atomic.AddInt64(&chosen.inflight, -1)
+			atomic.AddInt64(&p.inflight, -1)
+		},
+	}, nil
+}
+
+// choose returns the node of key on the ring, or the next nodes found by rehashing key
+// if the node is overloaded, which is consistent hashing with bounded loads.
+// The node of key is returned if all the probed nodes are overloaded.
+func (p *picker) choose(key string) *subConn {
+	bound := p.loadBound()
+	var first *subConn
+	for i := 0; i < len(p.list); i++ {
+		probe := key
+		if i > 0 {
+			probe = key + probeSeparator + strconv.Itoa(i)
+		}
+
+		node, ok := p.ring.Get(probe)
+		if !ok {
+			break
+		}
+
+		conn, ok := p.conns[node.(string)]
+		if !ok {
+			break
+		}
+		if first == nil {
+			first = conn
+		}
+		if atomic.LoadInt64(&conn.inflight) < bound {
+			return conn
+		}
+	}
+
+	return first
+}
+
+func (p *picker) loadBound() int64 {
+	// plus one for the current request
+	total := atomic.LoadInt64(&p.inflight) + 1
+	return int64(math.Ceil(float64(total) * loadFactor / float64(len(p.list))))
+}
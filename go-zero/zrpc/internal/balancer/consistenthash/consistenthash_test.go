@@ -0,0 +1,148 @@
+package consistenthash
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stringx"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestPicker_PickNil(t *testing.T) {
+	builder := new(pickerBuilder)
+	picker := builder.Build(base.PickerBuildInfo{})
+	_, err := picker.Pick(balancer.PickInfo{
+		FullMethodName: "/",
+		Ctx:            context.Background(),
+	})
+	assert.Equal(t, balancer.ErrNoSubConnAvailable, err)
+}
+
+func TestPicker_PickByKey(t *testing.T) {
+	picker := buildPicker(10)
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		ctx := SetHashKey(context.Background(), key)
+		first := pick(t, picker, ctx)
+		second := pick(t, picker, ctx)
+		assert.Equal(t, first, second, key)
+	}
+}
+
+func TestPicker_PickWithoutKey(t *testing.T) {
+	picker := buildPicker(3)
+	picked := make(map[balancer.SubConn]int)
+	for i := 0; i < 300; i++ {
+		picked[pick(t, picker, context.Background())]++
+	}
+	assert.Equal(t, 3, len(picked))
+}
+
+func TestPicker_PickBoundedLoad(t *testing.T) {
+	p := buildPicker(4).(*picker)
+	ctx := SetHashKey(context.Background(), "foo")
+	first := pick(t, p, ctx)
+
+	// overload the node of foo with the inflight requests
+	var chosen *subConn
+	for _, conn := range p.conns {
+		if conn.conn == first {
+			chosen = conn
+		}
+	}
+	chosen.inflight = 10
+	p.inflight = 10
+
+	result, err := p.Pick(balancer.PickInfo{Ctx: ctx})
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, result.SubConn)
+	result.Done(balancer.DoneInfo{})
+	assert.Equal(t, int64(10), p.inflight)
+
+	// all the nodes are overloaded
+	for _, conn := range p.conns {
+		conn.inflight = 20
+	}
+	p.inflight = 40
+	assert.Equal(t, first, pick(t, p, ctx))
+
+	for _, conn := range p.conns {
+		conn.inflight = 0
+	}
+	p.inflight = 0
+	assert.Equal(t, first, pick(t, p, ctx))
+}
+
+func TestPicker_MinimalRemapping(t *testing.T) {
+	const keys = 1000
+	before := buildPicker(10)
+	after := buildPicker(11)
+
+	var moved int
+	for i := 0; i < keys; i++ {
+		key := strconv.Itoa(i)
+		ctx := SetHashKey(context.Background(), key)
+		if pickAddr(t, before, ctx) != pickAddr(t, after, ctx) {
+			moved++
+		}
+	}
+
+	// about 1/11 of the keys are expected to move to the new node
+	assert.True(t, moved < keys/5, moved)
+}
+
+func TestHashKeyFromContext(t *testing.T) {
+	_, ok := HashKeyFromContext(context.Background())
+	assert.False(t, ok)
+	_, ok = HashKeyFromContext(SetHashKey(context.Background(), ""))
+	assert.False(t, ok)
+	key, ok := HashKeyFromContext(SetHashKey(context.Background(), "foo"))
+	assert.True(t, ok)
+	assert.Equal(t, "foo", key)
+}
+
+func buildPicker(nodes int) balancer.Picker {
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 0; i < nodes; i++ {
+		ready[mockClientConn{
+			id:   stringx.Rand(),
+			addr: "10.0.0." + strconv.Itoa(i),
+		}] = base.SubConnInfo{
+			Address: resolver.Address{
+				Addr: "10.0.0." + strconv.Itoa(i),
+			},
+		}
+	}
+
+	return new(pickerBuilder).Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	})
+}
+
+func pick(t *testing.T, picker balancer.Picker, ctx context.Context) balancer.SubConn {
+	result, err := picker.Pick(balancer.PickInfo{Ctx: ctx})
+	assert.Nil(t, err)
+	result.Done(balancer.DoneInfo{})
+	return result.SubConn
+}
+
+func pickAddr(t *testing.T, picker balancer.Picker, ctx context.Context) string {
+	return pick(t, picker, ctx).(mockClientConn).addr
+}
+
+type mockClientConn struct {
+	// add random string member to avoid map key equality.
+	id   string
+	addr string
+}
+
+func (m mockClientConn) UpdateAddresses(addresses []resolver.Address) {
+}
+
+func (m mockClientConn) Connect() {
+}
@@ -0,0 +1,96 @@
+package p2c
+
+import "context"
+
+type (
+	canaryRoute struct {
+		label  string
+		canary string
+	}
+
+	canaryRouteKey struct{}
+)
+
+// CanaryRouteFromContext returns the label and the canary label set by SetCanaryRoute in ctx.
+func CanaryRouteFromContext(ctx context.Context) (label, canary string, ok bool) {
+	if ctx == nil {
+		return "", "", false
+	}
+
+	route, ok := ctx.Value(canaryRouteKey{}).(canaryRoute)
+	return route.label, route.canary, ok
+}
+
+// SetCanaryRoute returns a context that routes the calls to the instances matching label,
+// or the stable instances not matching canary if label is empty or no instances match it,
+// all the instances are used if no stable instances.
+// The labels like key=value match the tags of the instances, and the others match the versions.
+func SetCanaryRoute(ctx context.Context, label, canary string) context.Context {
+	return context.WithValue(ctx, canaryRouteKey{}, canaryRoute{
+		label:  label,
+		canary: canary,
+	})
+}
+
+// candidates returns the conns to pick from by the canary route in ctx.
+func (p *p2cPicker) candidates(ctx context.Context) []*subConn {
+	label, canary, ok := CanaryRouteFromContext(ctx)
+	if !ok {
+		return p.preferred()
+	}
+
+	if len(label) > 0 {
+		if group := p.canaryGroup(label); group != nil {
+			return group.preferred()
+		}
+	}
+
+	if len(canary) > 0 {
+		if group := p.stableGroup(canary); group != nil {
+			return group.preferred()
+		}
+	}
+
+	return p.preferred()
+}
+
+func (p *p2cPicker) canaryGroup(label string) *connGroup {
+	if group, ok := p.canaries[label]; ok {
+		return group
+	}
+
+	var conns []*subConn
+	for _, conn := range p.conns {
+		if conn.md.Match(label) {
+			conns = append(conns, conn)
+		}
+	}
+	// not cached if no conns match, to avoid too many groups by the labels from the clients
+	if len(conns) == 0 {
+		return nil
+	}
+
+	group := newConnGroup(conns)
+	p.canaries[label] = group
+	return group
+}
+
+func (p *p2cPicker) stableGroup(canary string) *connGroup {
+	if group, ok := p.stables[canary]; ok {
+		return group
+	}
+
+	var conns []*subConn
+	for _, conn := range p.conns {
+		if !conn.md.Match(canary) {
+			conns = append(conns, conn)
+		}
+	}
+
+	var group *connGroup
+	if len(conns) > 0 {
+		group = newConnGroup(conns)
+	}
+	p.stables[canary] = group
+	return group
+}
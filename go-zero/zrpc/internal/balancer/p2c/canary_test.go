@@ -0,0 +1,135 @@
+package p2c
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/stringx"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
+	"google.golang.org/grpc/balancer"
+	"google.golang.org/grpc/balancer/base"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestP2cPicker_PickCanary(t *testing.T) {
+	versions := []string{"v1", "v1", "v1", "v2"}
+	tests := []struct {
+		name     string
+		versions []string
+		label    string
+		canary   string
+		expect   []string
+	}{
+		{
+			name:     "no route",
+			versions: versions,
+			expect:   []string{"v1", "v2"},
+		},
+		{
+			name:     "canary",
+			versions: versions,
+			label:    "v2",
+			canary:   "v2",
+			expect:   []string{"v2"},
+		},
+		{
+			name:     "canary by label only",
+			versions: versions,
+			label:    "v2",
+			expect:   []string{"v2"},
+		},
+		{
+			name:     "stable",
+			versions: versions,
+			canary:   "v2",
+			expect:   []string{"v1"},
+		},
+		{
+			name:     "no canary instances",
+			versions: versions,
+			label:    "v3",
+			canary:   "v2",
+			expect:   []string{"v1"},
+		},
+		{
+			name:     "no stable instances",
+			versions: []string{"v2", "v2"},
+			canary:   "v2",
+			expect:   []string{"v2"},
+		},
+		{
+			name:     "tags",
+			versions: versions,
+			label:    "env=canary",
+			canary:   "env=canary",
+			expect:   []string{"v2"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ready := make(map[balancer.SubConn]base.SubConnInfo)
+			for i, version := range test.versions {
+				md := instance.Metadata{
+					Version: version,
+				}
+				if version == "v2" {
+					md.Tags = map[string]string{"env": "canary"}
+				}
+				ready[mockClientConn{
+					id: stringx.Rand(),
+				}] = base.SubConnInfo{
+					Address: instance.WithMetadata(resolver.Address{
+						Addr: strconv.Itoa(i),
+					}, md, false),
+				}
+			}
+
+			picker := new(p2cPickerBuilder).Build(base.PickerBuildInfo{
+				ReadySCs: ready,
+			})
+			ctx := context.Background()
+			if len(test.label) > 0 || len(test.canary) > 0 {
+				ctx = SetCanaryRoute(ctx, test.label, test.canary)
+			}
+
+			picked := make(map[string]bool)
+			for i := 0; i < 100; i++ {
+				result, err := picker.Pick(balancer.PickInfo{
+					FullMethodName: "/",
+					Ctx:            ctx,
+				})
+				assert.Nil(t, err)
+				md, _ := instance.FromAddress(ready[result.SubConn].Address)
+				picked[md.Version] = true
+				result.Done(balancer.DoneInfo{})
+			}
+
+			for _, version := range test.expect {
+				assert.True(t, picked[version], version)
+			}
+			assert.Equal(t, len(test.expect), len(picked))
+		})
+	}
+}
+
+func TestP2cPicker_CanaryGroupsCached(t *testing.T) {
+	ready := map[balancer.SubConn]base.SubConnInfo{
+		mockClientConn{id: stringx.Rand()}: {
+			Address: instance.WithMetadata(resolver.Address{Addr: "0"},
+				instance.Metadata{Version: "v2"}, false),
+		},
+	}
+	picker := new(p2cPickerBuilder).Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	}).(*p2cPicker)
+
+	assert.NotNil(t, picker.canaryGroup("v2"))
+	assert.Nil(t, picker.canaryGroup("v3"))
+	assert.Nil(t, picker.stableGroup("v2"))
+	assert.Equal(t, 1, len(picker.canaries))
+	assert.Equal(t, 1, len(picker.stables))
+}
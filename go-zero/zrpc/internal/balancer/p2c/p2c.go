@@ -13,6 +13,7 @@ import (
 	"github.com/zeromicro/go-zero/core/syncx"
 	"github.com/zeromicro/go-zero/core/timex"
 	"github.com/zeromicro/go-zero/zrpc/internal/codes"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/resolver"
@@ -47,17 +48,23 @@ func (b *p2cPickerBuilder) Build(info base.PickerBuildInfo) balancer.Picker {
 
 	var conns []*subConn
 	for conn, connInfo := range readySCs {
+		md, local := instance.FromAddress(connInfo.Address)
 		conns = append(conns, &subConn{
 			addr:    connInfo.Address,
 			conn:    conn,
 			success: initSuccess,
+			md:      md,
+			local:   local,
+			weight:  int64(md.Weighted()),
 		})
 	}
 
 	return &p2cPicker{
-		conns: conns,
-		r:     rand.New(rand.NewSource(time.Now().UnixNano())),
-		stamp: syncx.NewAtomicDuration(),
+		connGroup: newConnGroup(conns),
+		canaries:  make(map[string]*connGroup),
+		stables:   make(map[string]*connGroup),
+		r:         rand.New(rand.NewSource(time.Now().UnixNano())),
+		stamp:     syncx.NewAtomicDuration(),
 	}
 }
 
@@ -65,39 +72,85 @@ func newBuilder() balancer.Builder {
 	return base.NewBalancerBuilder(Name, new(p2cPickerBuilder), base.Config{HealthCheck: true})
 }
 
-type p2cPicker struct {
-	conns []*subConn
-	r     *rand.Rand
-	stamp *syncx.AtomicDuration
-	lock  sync.Mutex
+type (
+	connGroup struct {
+		conns []*subConn
+		// the conns in the same zone with the client
+		locals []*subConn
+	}
+
+	p2cPicker struct {
+		*connGroup
+		// the conns matching the canary labels, and the conns not matching the canary labels
+		canaries map[string]*connGroup
+		stables  map[string]*connGroup
+		r        *rand.Rand
+		stamp    *syncx.AtomicDuration
+		lock     sync.Mutex
+	}
+)
+
+func newConnGroup(conns []*subConn) *connGroup {
+	var locals []*subConn
+	for _, conn := range conns {
+		if conn.local {
+			locals = append(locals, conn)
+		}
+	}
+
+	return &connGroup{
+		conns:  conns,
+		locals: locals,
+	}
+}
+
+// preferred returns the conns to pick from, the local conns are preferred,
+// and all the conns are used if less than half of the local conns are healthy.
+func (g *connGroup) preferred() []*subConn {
+	if len(g.locals) == 0 {
+		return g.conns
+	}
+
+	var healthy int
+	for _, conn := range g.locals {
+		if conn.healthy() {
+			healthy++
+		}
+	}
+	if healthy*2 < len(g.locals) {
+		return g.conns
+	}
+
+	return g.locals
 }
 
 func (p *p2cPicker) Pick(info balancer.PickInfo) (balancer.PickResult, error) {
 	p.lock.Lock()
 	defer p.lock.Unlock()
 
+	conns := p.candidates(info.Ctx)
 	var chosen *subConn
-	switch len(p.conns) {
+	switch len(conns) {
 	case 0:
 		// 没有可用链接
 		return emptyPickResult, balancer.ErrNoSubConnAvailable
 	case 1:
 		// 只有一个链接
-		chosen = p.choose(p.conns[0], nil)
+		chosen = p.choose(conns[0], nil)
 	case 2:
-		chosen = p.choose(p.conns[0], p.conns[1])
+		chosen = p.choose(conns[0], conns[1])
 	default: // 选择一个健康的节点
 		var node1, node2 *subConn
 		for i := 0; i < pickTimes; i++ {
 			// 随机数
-			a := p.r.Intn(len(p.conns))
-			b := p.r.Intn(len(p.conns) - 1)
+			a := p.r.Intn(len(conns))
+			b := p.r.Intn(len(conns) - 1)
 			if b >= a {
 				b++
 			}
 			// 随机获取所有节点中的两个节点
-			node1 = p.conns[a]
-			node2 = p.conns[b]
+			node1 = conns[a]
+			node2 = conns[b]
 			// 效验节点是否健康
 			if node1.healthy() && node2.healthy() {
 				break
@@ -196,6 +249,9 @@ type subConn struct {
 	requests int64
 	last     int64
 	pick     int64
+	weight   int64
+	local    bool
+	md       instance.Metadata
 	addr     resolver.Address
 	conn     balancer.SubConn
 }
@@ -212,5 +268,6 @@ func (c *subConn) load() int64 {
 		return penalty
 	}
 
-	return load
+	// the instances with higher weights look less loaded, to take more requests
+	return load * instance.DefaultWeight / c.weight
 }
@@ -12,6 +12,7 @@ import (
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/mathx"
 	"github.com/zeromicro/go-zero/core/stringx"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
 	"google.golang.org/grpc/balancer"
 	"google.golang.org/grpc/balancer/base"
 	"google.golang.org/grpc/codes"
@@ -123,6 +124,52 @@ func TestP2cPicker_Pick(t *testing.T) {
 	}
 }
 
+func TestP2cPicker_PickLocal(t *testing.T) {
+	builder := new(p2cPickerBuilder)
+	ready := make(map[balancer.SubConn]base.SubConnInfo)
+	for i := 0; i < 6; i++ {
+		ready[mockClientConn{
+			id: stringx.Rand(),
+		}] = base.SubConnInfo{
+			Address: instance.WithMetadata(resolver.Address{
+				Addr: strconv.Itoa(i),
+			}, instance.Metadata{}, i < 2),
+		}
+	}
+
+	picker := builder.Build(base.PickerBuildInfo{
+		ReadySCs: ready,
+	})
+	for i := 0; i < 100; i++ {
+		result, err := picker.Pick(balancer.PickInfo{
+			FullMethodName: "/",
+			Ctx:            context.Background(),
+		})
+		assert.Nil(t, err)
+		_, local := instance.FromAddress(ready[result.SubConn].Address)
+		assert.True(t, local)
+		result.Done(balancer.DoneInfo{})
+	}
+
+	// fall back to all the conns if the local conns are unhealthy
+	for _, conn := range picker.(*p2cPicker).locals {
+		conn.success = 0
+	}
+	assert.Equal(t, picker.(*p2cPicker).conns, picker.(*p2cPicker).preferred())
+}
+
+func TestSubConn_LoadWithWeight(t *testing.T) {
+	light := &subConn{
+		lag:    100,
+		weight: instance.DefaultWeight * 2,
+	}
+	heavy := &subConn{
+		lag:    100,
+		weight: instance.DefaultWeight,
+	}
+	assert.Equal(t, heavy.load()/2, light.load())
+}
+
 type mockClientConn struct {
 	// add random string member to avoid map key equality.
 	id string
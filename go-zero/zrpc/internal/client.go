@@ -7,6 +7,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/consistenthash"
 	"github.com/zeromicro/go-zero/zrpc/internal/balancer/p2c"
 	"github.com/zeromicro/go-zero/zrpc/internal/clientinterceptors"
 	"github.com/zeromicro/go-zero/zrpc/resolver"
@@ -31,10 +32,15 @@ type (
 
 	// A ClientOptions is a client options.
 	ClientOptions struct {
-		NonBlock    bool
-		Timeout     time.Duration
-		Secure      bool
-		DialOptions []grpc.DialOption
+		NonBlock      bool
+		Timeout       time.Duration
+		Secure        bool
+		HashKey       string
+		CanaryLabel   string
+		CanaryPercent int
+		Retry         clientinterceptors.RetryPolicy
+		MethodRetry   map[string]clientinterceptors.RetryPolicy
+		DialOptions   []grpc.DialOption
 	}
 
 	// ClientOption defines the method to customize a ClientOptions.
@@ -78,13 +84,21 @@ func (c *client) buildDialOptions(opts ...ClientOption) []grpc.DialOption {
 
 	options = append(options,
 		WithUnaryClientInterceptors(
+			clientinterceptors.UnaryHashKeyInterceptor(cliOpts.HashKey),
+			clientinterceptors.UnaryRequestIdInterceptor,
+			clientinterceptors.UnaryCanaryInterceptor(cliOpts.CanaryLabel, cliOpts.CanaryPercent),
 			clientinterceptors.UnaryTracingInterceptor,
 			clientinterceptors.DurationInterceptor,
 			clientinterceptors.PrometheusInterceptor,
+			// outside the breaker, to count every attempt, and stop retrying once rejected
+			clientinterceptors.RetryInterceptor(cliOpts.Retry, cliOpts.MethodRetry),
 			clientinterceptors.BreakerInterceptor,
 			clientinterceptors.TimeoutInterceptor(cliOpts.Timeout),
 		),
 		WithStreamClientInterceptors(
+			clientinterceptors.StreamHashKeyInterceptor(cliOpts.HashKey),
+			clientinterceptors.StreamRequestIdInterceptor,
+			clientinterceptors.StreamCanaryInterceptor(cliOpts.CanaryLabel, cliOpts.CanaryPercent),
 			clientinterceptors.StreamTracingInterceptor,
 		),
 	)
@@ -114,6 +128,26 @@ func (c *client) dial(server string, opts ...ClientOption) error {
 	return nil
 }
 
+// WithCanary returns a func to customize a ClientOptions with the canary routing,
+// the calls without canary labels are routed to the instances matching label on percent
+// of the calls, like v2 to match the version, or env=canary to match the tags,
+// and to the stable instances otherwise. It works with the p2c balancer.
+func WithCanary(label string, percent int) ClientOption {
+	return func(options *ClientOptions) {
+		options.CanaryLabel = label
+		options.CanaryPercent = percent
+	}
+}
+
+// WithConsistentHash returns a func to customize a ClientOptions with the consistent hash balancer,
+// the calls are hashed by the outgoing metadata of key, or the hash key set by consistenthash.SetHashKey.
+func WithConsistentHash(key string) ClientOption {
+	return func(options *ClientOptions) {
+		options.HashKey = key
+		options.DialOptions = append(options.DialOptions, grpc.WithBalancerName(consistenthash.Name))
+	}
+}
+
 // WithDialOption returns a func to customize a ClientOptions with given dial option.
 func WithDialOption(opt grpc.DialOption) ClientOption {
 	return func(options *ClientOptions) {
@@ -128,6 +162,15 @@ func WithNonBlock() ClientOption {
 	}
 }
 
+// WithRetry returns a func to customize a ClientOptions with given retry policies,
+// the policies in methods are used for the methods like /pkg.Service/Method in precedence.
+func WithRetry(policy clientinterceptors.RetryPolicy, methods map[string]clientinterceptors.RetryPolicy) ClientOption {
+	return func(options *ClientOptions) {
+		options.Retry = policy
+		options.MethodRetry = methods
+	}
+}
+
 // WithTimeout returns a func to customize a ClientOptions with given timeout.
 func WithTimeout(timeout time.Duration) ClientOption {
 	return func(options *ClientOptions) {
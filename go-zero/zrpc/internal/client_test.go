@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/zrpc/internal/clientinterceptors"
 	"google.golang.org/grpc"
 )
 
@@ -54,3 +55,31 @@ func TestBuildDialOptions(t *testing.T) {
 	opts := c.buildDialOptions(WithDialOption(agent))
 	assert.Contains(t, opts, agent)
 }
+
+func TestWithRetry(t *testing.T) {
+	var options ClientOptions
+	policy := clientinterceptors.RetryPolicy{MaxAttempts: 3}
+	methods := map[string]clientinterceptors.RetryPolicy{
+		"/foo.Foo/Bar": {MaxAttempts: 2},
+	}
+	opt := WithRetry(policy, methods)
+	opt(&options)
+	assert.Equal(t, policy, options.Retry)
+	assert.Equal(t, methods, options.MethodRetry)
+}
+
+func TestWithCanary(t *testing.T) {
+	var options ClientOptions
+	opt := WithCanary("v2", 10)
+	opt(&options)
+	assert.Equal(t, "v2", options.CanaryLabel)
+	assert.Equal(t, 10, options.CanaryPercent)
+}
+
+func TestWithConsistentHash(t *testing.T) {
+	var options ClientOptions
+	opt := WithConsistentHash("x-user-id")
+	opt(&options)
+	assert.Equal(t, "x-user-id", options.HashKey)
+	assert.Equal(t, 1, len(options.DialOptions))
+}
@@ -0,0 +1,56 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/canary"
+	"github.com/zeromicro/go-zero/core/mathx"
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/p2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamCanaryInterceptor returns an interceptor that routes the calls by the canary labels,
+// see UnaryCanaryInterceptor.
+func StreamCanaryInterceptor(label string, percent int) grpc.StreamClientInterceptor {
+	proba := mathx.NewProba()
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withCanary(ctx, label, percent, proba), desc, cc, method, opts...)
+	}
+}
+
+// UnaryCanaryInterceptor returns an interceptor that routes the calls by the canary labels.
+// The calls with the canary labels in the context, set by canary.NewContext, are routed to
+// the matching instances, and the labels are forwarded as metadata.
+// The other calls are routed to the instances matching label on the percentage percent,
+// and to the stable instances, which don't match label, otherwise.
+func UnaryCanaryInterceptor(label string, percent int) grpc.UnaryClientInterceptor {
+	proba := mathx.NewProba()
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withCanary(ctx, label, percent, proba), method, req, reply, cc, opts...)
+	}
+}
+
+func withCanary(ctx context.Context, label string, percent int, proba *mathx.Proba) context.Context {
+	if val := canary.FromContext(ctx); len(val) > 0 {
+		// don't forward the label twice, like the nested calls with the same context.
+		if md, ok := metadata.FromOutgoingContext(ctx); !ok || len(md.Get(canary.MetadataKey)) == 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, canary.MetadataKey, val)
+		}
+		return p2c.SetCanaryRoute(ctx, val, label)
+	}
+
+	if len(label) == 0 {
+		return ctx
+	}
+
+	// the calls split to the canary instances are not labeled for the downstream services,
+	// which have their own splits.
+	if percent > 0 && proba.TrueOnProba(float64(percent)/100) {
+		return p2c.SetCanaryRoute(ctx, label, label)
+	}
+
+	return p2c.SetCanaryRoute(ctx, "", label)
+}
@@ -0,0 +1,125 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/canary"
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/p2c"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestCanaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		label   string
+		percent int
+		routed  bool
+		expect  string
+		forward string
+	}{
+		{
+			name: "no canary",
+			ctx:  context.Background(),
+		},
+		{
+			name:    "from context",
+			ctx:     canary.NewContext(context.Background(), "v3"),
+			label:   "v2",
+			routed:  true,
+			expect:  "v3",
+			forward: "v3",
+		},
+		{
+			name:    "from context without config",
+			ctx:     canary.NewContext(context.Background(), "v3"),
+			routed:  true,
+			expect:  "v3",
+			forward: "v3",
+		},
+		{
+			name: "forwarded",
+			ctx: metadata.AppendToOutgoingContext(canary.NewContext(context.Background(), "v3"),
+				canary.MetadataKey, "v3"),
+			routed:  true,
+			expect:  "v3",
+			forward: "v3",
+		},
+		{
+			name:   "stable",
+			ctx:    context.Background(),
+			label:  "v2",
+			routed: true,
+		},
+		{
+			name:    "all canary",
+			ctx:     context.Background(),
+			label:   "v2",
+			percent: 100,
+			routed:  true,
+			expect:  "v2",
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			verify := func(ctx context.Context) {
+				label, c, ok := p2c.CanaryRouteFromContext(ctx)
+				assert.Equal(t, test.routed, ok)
+				assert.Equal(t, test.expect, label)
+				if ok {
+					assert.Equal(t, test.label, c)
+				}
+
+				md, _ := metadata.FromOutgoingContext(ctx)
+				vals := md.Get(canary.MetadataKey)
+				if len(test.forward) > 0 {
+					assert.Equal(t, []string{test.forward}, vals)
+				} else {
+					assert.Empty(t, vals)
+				}
+			}
+
+			unary := UnaryCanaryInterceptor(test.label, test.percent)
+			err := unary(test.ctx, "/foo", nil, nil, nil,
+				func(ctx context.Context, method string, req, reply interface{},
+					cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+					verify(ctx)
+					return nil
+				})
+			assert.Nil(t, err)
+
+			stream := StreamCanaryInterceptor(test.label, test.percent)
+			_, err = stream(test.ctx, nil, nil, "/foo",
+				func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+					method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					verify(ctx)
+					return nil, nil
+				})
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestCanaryInterceptorPercent(t *testing.T) {
+	const total = 10000
+	unary := UnaryCanaryInterceptor("v2", 20)
+	var canaries int
+	for i := 0; i < total; i++ {
+		err := unary(context.Background(), "/foo", nil, nil, nil,
+			func(ctx context.Context, method string, req, reply interface{},
+				cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				if label, _, _ := p2c.CanaryRouteFromContext(ctx); label == "v2" {
+					canaries++
+				}
+				return nil
+			})
+		assert.Nil(t, err)
+	}
+
+	assert.InDelta(t, total/5, canaries, total/20)
+}
@@ -0,0 +1,50 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/consistenthash"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// StreamHashKeyInterceptor returns an interceptor that sets the hash key from the outgoing metadata
+// of key into the context, which is used by the consistent hash balancer.
+func StreamHashKeyInterceptor(key string) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+		streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(withHashKey(ctx, key), desc, cc, method, opts...)
+	}
+}
+
+// UnaryHashKeyInterceptor returns an interceptor that sets the hash key from the outgoing metadata
+// of key into the context, which is used by the consistent hash balancer.
+func UnaryHashKeyInterceptor(key string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(withHashKey(ctx, key), method, req, reply, cc, opts...)
+	}
+}
+
+// withHashKey sets the hash key from the outgoing metadata, the one set by
+// consistenthash.SetHashKey is used in precedence.
+func withHashKey(ctx context.Context, key string) context.Context {
+	if len(key) == 0 {
+		return ctx
+	}
+	if _, ok := consistenthash.HashKeyFromContext(ctx); ok {
+		return ctx
+	}
+
+	md, ok := metadata.FromOutgoingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	vals := md.Get(key)
+	if len(vals) == 0 || len(vals[0]) == 0 {
+		return ctx
+	}
+
+	return consistenthash.SetHashKey(ctx, vals[0])
+}
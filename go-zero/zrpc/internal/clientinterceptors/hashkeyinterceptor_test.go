@@ -0,0 +1,71 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/zrpc/internal/balancer/consistenthash"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestHashKeyInterceptor(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		ctx    context.Context
+		expect string
+	}{
+		{
+			name: "no key",
+			ctx:  metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "foo"),
+		},
+		{
+			name: "no metadata",
+			key:  "x-user-id",
+			ctx:  context.Background(),
+		},
+		{
+			name:   "from metadata",
+			key:    "x-user-id",
+			ctx:    metadata.AppendToOutgoingContext(context.Background(), "x-user-id", "foo"),
+			expect: "foo",
+		},
+		{
+			name: "empty metadata",
+			key:  "x-user-id",
+			ctx:  metadata.AppendToOutgoingContext(context.Background(), "x-user-id", ""),
+		},
+		{
+			name: "set in context",
+			key:  "x-user-id",
+			ctx: consistenthash.SetHashKey(metadata.AppendToOutgoingContext(context.Background(),
+				"x-user-id", "foo"), "bar"),
+			expect: "bar",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cc := new(grpc.ClientConn)
+			err := UnaryHashKeyInterceptor(test.key)(test.ctx, "/foo", nil, nil, cc,
+				func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+					opts ...grpc.CallOption) error {
+					key, _ := consistenthash.HashKeyFromContext(ctx)
+					assert.Equal(t, test.expect, key)
+					return nil
+				})
+			assert.Nil(t, err)
+
+			_, err = StreamHashKeyInterceptor(test.key)(test.ctx, nil, cc, "/foo",
+				func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+					opts ...grpc.CallOption) (grpc.ClientStream, error) {
+					key, _ := consistenthash.HashKeyFromContext(ctx)
+					assert.Equal(t, test.expect, key)
+					return nil, nil
+				})
+			assert.Nil(t, err)
+		})
+	}
+}
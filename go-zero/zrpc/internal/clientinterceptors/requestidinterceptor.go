@@ -0,0 +1,35 @@
+package clientinterceptors
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryRequestIdInterceptor is an interceptor that forwards the request id in ctx as metadata.
+func UnaryRequestIdInterceptor(ctx context.Context, method string, req, reply interface{},
+	cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+	return invoker(withRequestId(ctx), method, req, reply, cc, opts...)
+}
+
+// StreamRequestIdInterceptor is an interceptor that forwards the request id in ctx as metadata.
+func StreamRequestIdInterceptor(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn,
+	method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return streamer(withRequestId(ctx), desc, cc, method, opts...)
+}
+
+func withRequestId(ctx context.Context) context.Context {
+	id := requestid.FromContext(ctx)
+	if len(id) == 0 {
+		return ctx
+	}
+
+	// don't forward the request id twice, like the nested calls with the same context.
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(requestid.MetadataKey)) > 0 {
+		return ctx
+	}
+
+	return metadata.AppendToOutgoingContext(ctx, requestid.MetadataKey, id)
+}
@@ -0,0 +1,63 @@
+package clientinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryRequestIdInterceptor(t *testing.T) {
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		expect []string
+	}{
+		{
+			name: "without request id",
+			ctx:  context.Background(),
+		},
+		{
+			name:   "with request id",
+			ctx:    requestid.NewContext(context.Background(), "foo"),
+			expect: []string{"foo"},
+		},
+		{
+			name: "already forwarded",
+			ctx: metadata.AppendToOutgoingContext(requestid.NewContext(context.Background(), "foo"),
+				requestid.MetadataKey, "foo"),
+			expect: []string{"foo"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			cc := new(grpc.ClientConn)
+			err := UnaryRequestIdInterceptor(test.ctx, "/foo", nil, nil, cc,
+				func(ctx context.Context, method string, req, reply interface{},
+					cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+					md, _ := metadata.FromOutgoingContext(ctx)
+					assert.Equal(t, test.expect, md.Get(requestid.MetadataKey))
+					return nil
+				})
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestStreamRequestIdInterceptor(t *testing.T) {
+	cc := new(grpc.ClientConn)
+	ctx := requestid.NewContext(context.Background(), "foo")
+	_, err := StreamRequestIdInterceptor(ctx, nil, cc, "/foo",
+		func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string,
+			opts ...grpc.CallOption) (grpc.ClientStream, error) {
+			md, _ := metadata.FromOutgoingContext(ctx)
+			assert.Equal(t, []string{"foo"}, md.Get(requestid.MetadataKey))
+			return nil, nil
+		})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,128 @@
+package clientinterceptors
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/breaker"
+	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/core/mathx"
+	"github.com/zeromicro/go-zero/core/metric"
+	"github.com/zeromicro/go-zero/core/prometheus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// make the backoffs of the clients to be in [0.8, 1.2] of the exponential ones,
+// to avoid the retries from the clients at the same time.
+const backoffDeviation = 0.2
+
+var (
+	metricClientReqRetryTotal = metric.NewCounterVec(&metric.CounterVecOpts{
+		Namespace: clientNamespace,
+		Subsystem: "requests",
+		Name:      "retry_total",
+		Help:      "rpc client requests retry count.",
+		Labels:    []string{"method"},
+	})
+
+	unstableBackoff = mathx.NewUnstable(backoffDeviation)
+)
+
+// A RetryPolicy is the retry policy of the rpc calls.
+type RetryPolicy struct {
+	// MaxAttempts is the max attempts including the first call, no retry if not greater than 1.
+	MaxAttempts int
+	// Codes are the retryable codes, codes.Unavailable is used if empty.
+	Codes []codes.Code
+	// InitialBackoff is the backoff before the first retry, which is doubled
+	// on each retry, and not greater than MaxBackoff if MaxBackoff is positive.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Budget is the overall deadline of all the attempts, not limited if not positive.
+	Budget time.Duration
+}
+
+// RetryInterceptor returns an interceptor that retries the failed calls with policy,
+// the policies in methods are used for the methods like /pkg.Service/Method in precedence.
+// The calls are not retried if rejected by the breaker, or if no time left for the next attempt.
+// Be aware that the calls might be processed by the servers even if failed, like DeadlineExceeded,
+// so only retry the idempotent methods on such codes.
+func RetryInterceptor(policy RetryPolicy, methods map[string]RetryPolicy) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		p := policy
+		if mp, ok := methods[method]; ok {
+			p = mp
+		}
+		if p.MaxAttempts <= 1 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		if p.Budget > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, p.Budget)
+			defer cancel()
+		}
+
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil || attempt >= p.MaxAttempts || !p.retryable(ctx, err) {
+				return err
+			}
+
+			backoff := p.backoff(attempt)
+			if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= backoff {
+				return err
+			}
+
+			timer := time.NewTimer(backoff)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+
+			logx.WithContext(ctx).Infof("retry rpc call %s, attempt: %d, error: %v", method, attempt+1, err)
+			if prometheus.Enabled() {
+				metricClientReqRetryTotal.Inc(method)
+			}
+		}
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	backoff := p.InitialBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if p.MaxBackoff > 0 && (backoff >= p.MaxBackoff || backoff <= 0) {
+			backoff = p.MaxBackoff
+			break
+		}
+	}
+
+	return unstableBackoff.AroundDuration(backoff)
+}
+
+func (p RetryPolicy) retryable(ctx context.Context, err error) bool {
+	// never retry if rejected by the breaker, or the call is canceled or timed out
+	if errors.Is(err, breaker.ErrServiceUnavailable) || ctx.Err() != nil {
+		return false
+	}
+
+	code := status.Code(err)
+	if len(p.Codes) == 0 {
+		return code == codes.Unavailable
+	}
+
+	for _, c := range p.Codes {
+		if c == code {
+			return true
+		}
+	}
+
+	return false
+}
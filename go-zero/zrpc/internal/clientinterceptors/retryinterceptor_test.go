@@ -0,0 +1,164 @@
+package clientinterceptors
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/breaker"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryInterceptor(t *testing.T) {
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	notFound := status.Error(codes.NotFound, "not found")
+	aborted := status.Error(codes.Aborted, "aborted")
+
+	tests := []struct {
+		name     string
+		policy   RetryPolicy
+		method   string
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{
+			name:     "no retry",
+			policy:   RetryPolicy{},
+			errs:     []error{unavailable, nil},
+			attempts: 1,
+			err:      unavailable,
+		},
+		{
+			name:     "succeeded after retries",
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			errs:     []error{unavailable, unavailable, nil},
+			attempts: 3,
+		},
+		{
+			name:     "max attempts",
+			policy:   RetryPolicy{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+			errs:     []error{unavailable, unavailable, nil},
+			attempts: 2,
+			err:      unavailable,
+		},
+		{
+			name:     "not retryable",
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			errs:     []error{notFound, nil},
+			attempts: 1,
+			err:      notFound,
+		},
+		{
+			name: "custom codes",
+			policy: RetryPolicy{
+				MaxAttempts:    3,
+				Codes:          []codes.Code{codes.Aborted},
+				InitialBackoff: time.Millisecond,
+			},
+			errs:     []error{aborted, unavailable},
+			attempts: 2,
+			err:      unavailable,
+		},
+		{
+			name:     "rejected by breaker",
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			errs:     []error{unavailable, breaker.ErrServiceUnavailable, nil},
+			attempts: 2,
+			err:      breaker.ErrServiceUnavailable,
+		},
+		{
+			name: "out of budget",
+			policy: RetryPolicy{
+				MaxAttempts:    3,
+				InitialBackoff: time.Second,
+				Budget:         time.Millisecond * 100,
+			},
+			errs:     []error{unavailable, nil},
+			attempts: 1,
+			err:      unavailable,
+		},
+		{
+			name:     "method policy",
+			policy:   RetryPolicy{MaxAttempts: 3, InitialBackoff: time.Millisecond},
+			method:   "/foo.Foo/Bar",
+			errs:     []error{unavailable, nil},
+			attempts: 1,
+			err:      unavailable,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var attempts int
+			interceptor := RetryInterceptor(test.policy, map[string]RetryPolicy{
+				"/foo.Foo/Bar": {},
+			})
+			method := test.method
+			if len(method) == 0 {
+				method = "/foo.Foo/Baz"
+			}
+			err := interceptor(context.Background(), method, nil, nil, new(grpc.ClientConn),
+				func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+					opts ...grpc.CallOption) error {
+					err := test.errs[attempts]
+					attempts++
+					return err
+				})
+			assert.Equal(t, test.err, err)
+			assert.Equal(t, test.attempts, attempts)
+		})
+	}
+}
+
+func TestRetryInterceptorCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	interceptor := RetryInterceptor(RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: time.Millisecond,
+	}, nil)
+
+	var attempts int
+	err := interceptor(ctx, "/foo.Foo/Bar", nil, nil, new(grpc.ClientConn),
+		func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn,
+			opts ...grpc.CallOption) error {
+			attempts++
+			cancel()
+			return status.Error(codes.Unavailable, "unavailable")
+		})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+	assert.Equal(t, 1, attempts)
+}
+
+func TestRetryPolicyBackoff(t *testing.T) {
+	policy := RetryPolicy{
+		InitialBackoff: time.Millisecond * 100,
+		MaxBackoff:     time.Millisecond * 300,
+	}
+
+	assertAround := func(expect, actual time.Duration) {
+		assert.True(t, actual >= time.Duration(float64(expect)*(1-backoffDeviation)), actual)
+		assert.True(t, actual <= time.Duration(float64(expect)*(1+backoffDeviation)), actual)
+	}
+	assertAround(time.Millisecond*100, policy.backoff(1))
+	assertAround(time.Millisecond*200, policy.backoff(2))
+	assertAround(time.Millisecond*300, policy.backoff(3))
+	assertAround(time.Millisecond*300, policy.backoff(100))
+}
+
+func TestRetryPolicyRetryable(t *testing.T) {
+	policy := RetryPolicy{}
+	ctx := context.Background()
+	assert.True(t, policy.retryable(ctx, status.Error(codes.Unavailable, "")))
+	assert.False(t, policy.retryable(ctx, status.Error(codes.DeadlineExceeded, "")))
+	assert.False(t, policy.retryable(ctx, errors.New("any")))
+	assert.False(t, policy.retryable(ctx, breaker.ErrServiceUnavailable))
+
+	policy.Codes = []codes.Code{codes.DeadlineExceeded, codes.ResourceExhausted}
+	assert.False(t, policy.retryable(ctx, status.Error(codes.Unavailable, "")))
+	assert.True(t, policy.retryable(ctx, status.Error(codes.ResourceExhausted, "")))
+}
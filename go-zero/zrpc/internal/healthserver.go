@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"time"
+
+	"github.com/zeromicro/go-zero/core/health"
+	"github.com/zeromicro/go-zero/core/logx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	healthCheckTimeout  = time.Second
+	healthWatchInterval = time.Second
+)
+
+// healthServer implements the standard grpc health service with the readiness probes,
+// both the empty service name and the server name are served.
+type healthServer struct {
+	healthpb.UnimplementedHealthServer
+	name     string
+	interval time.Duration
+}
+
+// RegisterHealthServer registers the standard grpc health service on server with given name,
+// which reports NOT_SERVING if any readiness probe fails, or the process is shutting down.
+func RegisterHealthServer(server *grpc.Server, name string) {
+	healthpb.RegisterHealthServer(server, newHealthServer(name))
+}
+
+func newHealthServer(name string) *healthServer {
+	return &healthServer{
+		name:     name,
+		interval: healthWatchInterval,
+	}
+}
+
+func (s *healthServer) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (
+	*healthpb.HealthCheckResponse, error) {
+	if !s.known(req.Service) {
+		return nil, status.Errorf(codes.NotFound, "unknown service: %s", req.Service)
+	}
+
+	return &healthpb.HealthCheckResponse{
+		Status: s.check(ctx),
+	}, nil
+}
+
+func (s *healthServer) Watch(req *healthpb.HealthCheckRequest, stream healthpb.Health_WatchServer) error {
+	if !s.known(req.Service) {
+		// as the protocol requires, don't terminate the call for unknown services.
+		if err := stream.Send(&healthpb.HealthCheckResponse{
+			Status: healthpb.HealthCheckResponse_SERVICE_UNKNOWN,
+		}); err != nil {
+			return err
+		}
+
+		<-stream.Context().Done()
+		return status.Error(codes.Canceled, "stream has ended")
+	}
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	last := healthpb.HealthCheckResponse_UNKNOWN
+	for {
+		if current := s.check(stream.Context()); current != last {
+			if err := stream.Send(&healthpb.HealthCheckResponse{Status: current}); err != nil {
+				return err
+			}
+			last = current
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return status.Error(codes.Canceled, "stream has ended")
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *healthServer) check(ctx context.Context) healthpb.HealthCheckResponse_ServingStatus {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	report := health.CheckReadiness(ctx)
+	if report.Healthy() {
+		return healthpb.HealthCheckResponse_SERVING
+	}
+
+	logx.WithContext(ctx).Errorf("health check failed: %v", report.Probes)
+	return healthpb.HealthCheckResponse_NOT_SERVING
+}
+
+func (s *healthServer) known(service string) bool {
+	return len(service) == 0 || service == s.name
+}
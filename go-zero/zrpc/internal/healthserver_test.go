@@ -0,0 +1,105 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/health"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+)
+
+func TestHealthServer_Check(t *testing.T) {
+	server := newHealthServer("foo")
+
+	for _, service := range []string{"", "foo"} {
+		resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{
+			Service: service,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	}
+
+	_, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{
+		Service: "bar",
+	})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+
+	const probe = "zrpc-test-db"
+	health.AddReadinessProbe(probe, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	defer health.RemoveProbe(probe)
+	resp, err := server.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.Nil(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+}
+
+func TestHealthServer_Watch(t *testing.T) {
+	server := newHealthServer("foo")
+	server.interval = time.Millisecond * 10
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &mockedWatchServer{
+		ctx:   ctx,
+		resps: make(chan *healthpb.HealthCheckResponse, 10),
+	}
+	done := make(chan error)
+	go func() {
+		done <- server.Watch(&healthpb.HealthCheckRequest{}, stream)
+	}()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, (<-stream.resps).Status)
+
+	const probe = "zrpc-test-watch"
+	health.AddReadinessProbe(probe, func(ctx context.Context) error {
+		return errors.New("connection refused")
+	})
+	defer health.RemoveProbe(probe)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, (<-stream.resps).Status)
+
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+}
+
+func TestHealthServer_WatchUnknown(t *testing.T) {
+	server := newHealthServer("foo")
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := &mockedWatchServer{
+		ctx:   ctx,
+		resps: make(chan *healthpb.HealthCheckResponse, 1),
+	}
+	done := make(chan error)
+	go func() {
+		done <- server.Watch(&healthpb.HealthCheckRequest{Service: "bar"}, stream)
+	}()
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVICE_UNKNOWN, (<-stream.resps).Status)
+	cancel()
+	assert.Equal(t, codes.Canceled, status.Code(<-done))
+}
+
+func TestRegisterHealthServer(t *testing.T) {
+	server := grpc.NewServer()
+	RegisterHealthServer(server, "foo")
+	_, ok := server.GetServiceInfo()[healthpb.Health_ServiceDesc.ServiceName]
+	assert.True(t, ok)
+}
+
+type mockedWatchServer struct {
+	grpc.ServerStream
+	ctx   context.Context
+	resps chan *healthpb.HealthCheckResponse
+}
+
+func (m *mockedWatchServer) Context() context.Context {
+	return m.ctx
+}
+
+func (m *mockedWatchServer) Send(resp *healthpb.HealthCheckResponse) error {
+	m.resps <- resp
+	return nil
+}
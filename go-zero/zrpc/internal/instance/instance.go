@@ -0,0 +1,127 @@
+package instance
+
+import (
+	"encoding/json"
+	"strings"
+
+	"google.golang.org/grpc/resolver"
+)
+
+// DefaultWeight is the weight of the instances without weights.
+const DefaultWeight = 100
+
+type (
+	// Metadata is the metadata of a rpc server instance, which is published with its address.
+	Metadata struct {
+		Zone    string            `json:"zone,omitempty"`
+		Weight  int               `json:"weight,omitempty"`
+		Version string            `json:"version,omitempty"`
+		Tags    map[string]string `json:"tags,omitempty"`
+	}
+
+	endpoint struct {
+		Addr string `json:"addr"`
+		Metadata
+	}
+
+	localKey    struct{}
+	metadataKey struct{}
+)
+
+// Decode decodes the address and the metadata from the published value val,
+// the values without metadata are the addresses themselves.
+func Decode(val string) (string, Metadata, error) {
+	if !strings.HasPrefix(val, "{") {
+		return val, Metadata{}, nil
+	}
+
+	var ep endpoint
+	if err := json.Unmarshal([]byte(val), &ep); err != nil {
+		return "", Metadata{}, err
+	}
+
+	return ep.Addr, ep.Metadata, nil
+}
+
+// Encode encodes addr and md into the value to publish, only addr is published if md is empty,
+// to keep compatible with the clients that don't know the metadata.
+func Encode(addr string, md Metadata) string {
+	if md.IsZero() {
+		return addr
+	}
+
+	val, err := json.Marshal(endpoint{
+		Addr:     addr,
+		Metadata: md,
+	})
+	if err != nil {
+		return addr
+	}
+
+	return string(val)
+}
+
+// FromAddress returns the metadata of addr, and if addr is in the same zone with the client.
+func FromAddress(addr resolver.Address) (md Metadata, local bool) {
+	md, _ = addr.BalancerAttributes.Value(metadataKey{}).(Metadata)
+	local, _ = addr.BalancerAttributes.Value(localKey{}).(bool)
+	return
+}
+
+// WithMetadata returns a copy of addr with the metadata, and if it's in the same zone with the client.
+func WithMetadata(addr resolver.Address, md Metadata, local bool) resolver.Address {
+	addr.BalancerAttributes = addr.BalancerAttributes.
+		WithValue(metadataKey{}, md).
+		WithValue(localKey{}, local)
+	return addr
+}
+
+// Equal checks if md equals o, which is used by resolver.Address to compare the attributes.
+func (md Metadata) Equal(o interface{}) bool {
+	other, ok := o.(Metadata)
+	if !ok {
+		return false
+	}
+
+	if md.Zone != other.Zone || md.Weight != other.Weight || md.Version != other.Version ||
+		len(md.Tags) != len(other.Tags) {
+		return false
+	}
+
+	for k, v := range md.Tags {
+		if ov, ok := other.Tags[k]; !ok || ov != v {
+			return false
+		}
+	}
+
+	return true
+}
+
+// IsZero checks if md is empty.
+func (md Metadata) IsZero() bool {
+	return len(md.Zone) == 0 && md.Weight == 0 && len(md.Version) == 0 && len(md.Tags) == 0
+}
+
+// Match checks if md matches label, the labels like key=value match the tags,
+// and the others match the version.
+func (md Metadata) Match(label string) bool {
+	if len(label) == 0 {
+		return false
+	}
+
+	if pos := strings.IndexByte(label, '='); pos >= 0 {
+		val, ok := md.Tags[label[:pos]]
+		return ok && val == label[pos+1:]
+	}
+
+	return md.Version == label
+}
+
+// Weighted returns the weight of md, DefaultWeight if not set.
+func (md Metadata) Weighted() int {
+	if md.Weight <= 0 {
+		return DefaultWeight
+	}
+
+	return md.Weight
+}
@@ -0,0 +1,128 @@
+package instance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/resolver"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		addr string
+		md   Metadata
+	}{
+		{
+			name: "empty",
+			addr: "localhost:8080",
+		},
+		{
+			name: "zone",
+			addr: "localhost:8080",
+			md: Metadata{
+				Zone: "us-east-1a",
+			},
+		},
+		{
+			name: "full",
+			addr: "10.0.0.1:8080",
+			md: Metadata{
+				Zone:    "us-east-1a",
+				Weight:  50,
+				Version: "v1.2.0",
+				Tags: map[string]string{
+					"env": "canary",
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			val := Encode(test.addr, test.md)
+			if test.md.IsZero() {
+				assert.Equal(t, test.addr, val)
+			}
+
+			addr, md, err := Decode(val)
+			assert.Nil(t, err)
+			assert.Equal(t, test.addr, addr)
+			assert.True(t, test.md.Equal(md))
+		})
+	}
+}
+
+func TestDecodeBadValue(t *testing.T) {
+	_, _, err := Decode("{bad")
+	assert.NotNil(t, err)
+}
+
+func TestWithMetadata(t *testing.T) {
+	addr := resolver.Address{Addr: "localhost:8080"}
+	md, local := FromAddress(addr)
+	assert.True(t, md.IsZero())
+	assert.False(t, local)
+
+	expect := Metadata{
+		Zone:   "us-east-1a",
+		Weight: 10,
+		Tags: map[string]string{
+			"env": "canary",
+		},
+	}
+	addr = WithMetadata(addr, expect, true)
+	md, local = FromAddress(addr)
+	assert.True(t, expect.Equal(md))
+	assert.True(t, local)
+	assert.True(t, addr.Equal(WithMetadata(resolver.Address{Addr: "localhost:8080"}, expect, true)))
+	assert.False(t, addr.Equal(WithMetadata(resolver.Address{Addr: "localhost:8080"}, expect, false)))
+}
+
+func TestMetadata_Equal(t *testing.T) {
+	md := Metadata{
+		Zone: "a",
+		Tags: map[string]string{
+			"foo": "bar",
+		},
+	}
+	assert.True(t, md.Equal(Metadata{
+		Zone: "a",
+		Tags: map[string]string{
+			"foo": "bar",
+		},
+	}))
+	assert.False(t, md.Equal(Metadata{
+		Zone: "a",
+		Tags: map[string]string{
+			"foo": "baz",
+		},
+	}))
+	assert.False(t, md.Equal(Metadata{Zone: "b"}))
+	assert.False(t, md.Equal("a"))
+}
+
+func TestMetadata_Weighted(t *testing.T) {
+	assert.Equal(t, DefaultWeight, Metadata{}.Weighted())
+	assert.Equal(t, DefaultWeight, Metadata{Weight: -1}.Weighted())
+	assert.Equal(t, 20, Metadata{Weight: 20}.Weighted())
+}
+
+func TestMetadata_Match(t *testing.T) {
+	md := Metadata{
+		Version: "v2",
+		Tags: map[string]string{
+			"env":   "canary",
+			"empty": "",
+		},
+	}
+	assert.True(t, md.Match("v2"))
+	assert.True(t, md.Match("env=canary"))
+	assert.True(t, md.Match("empty="))
+	assert.False(t, md.Match(""))
+	assert.False(t, md.Match("v1"))
+	assert.False(t, md.Match("env=stable"))
+	assert.False(t, md.Match("zone="))
+	assert.False(t, Metadata{}.Match("v2"))
+}
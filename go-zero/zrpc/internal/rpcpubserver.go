@@ -5,18 +5,23 @@ import (
 	"strings"
 
 	"github.com/zeromicro/go-zero/core/discov"
+	"github.com/zeromicro/go-zero/core/health"
 	"github.com/zeromicro/go-zero/core/netx"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
 )
 
 const (
-	allEths  = "0.0.0.0"
-	envPodIp = "POD_IP"
+	allEths         = "0.0.0.0"
+	envPodIp        = "POD_IP"
+	etcdHealthProbe = "etcd"
 )
 
 // NewRpcPubServer returns a Server.
 // 在 etcd 注册服务，定时更新
 // 开启 rpc 服务
-func NewRpcPubServer(etcd discov.EtcdConf, listenOn string, opts ...ServerOption) (Server, error) {
+// The metadata md is published with the address if not empty.
+func NewRpcPubServer(etcd discov.EtcdConf, listenOn string, md instance.Metadata,
+	opts ...ServerOption) (Server, error) {
 	registerEtcd := func() error {
 		pubListenOn := figureOutListenOn(listenOn) // 获取 host:port
 		var pubOpts []discov.PubOption
@@ -28,7 +33,10 @@ func NewRpcPubServer(etcd discov.EtcdConf, listenOn string, opts ...ServerOption
 				etcd.CACertFile, etcd.InsecureSkipVerify))
 		}
 		// 配置和保持更新
-		pubClient := discov.NewPublisher(etcd.Hosts, etcd.Key, pubListenOn, pubOpts...)
+		pubClient := discov.NewPublisher(etcd.Hosts, etcd.Key, instance.Encode(pubListenOn, md),
+			pubOpts...)
+		// not ready if not published, because no traffic can be routed here
+		health.AddReadinessProbe(etcdHealthProbe, discov.NewHealthProbe(pubClient))
 		return pubClient.KeepAlive()
 	}
 	server := keepAliveServer{
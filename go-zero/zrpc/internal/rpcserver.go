@@ -3,6 +3,7 @@ package internal
 import (
 	"net"
 
+	"github.com/zeromicro/go-zero/core/health"
 	"github.com/zeromicro/go-zero/core/proc"
 	"github.com/zeromicro/go-zero/core/stat"
 	"github.com/zeromicro/go-zero/zrpc/internal/serverinterceptors"
@@ -55,6 +56,8 @@ func (s *rpcServer) Start(register RegisterFn) error {
 	}
 
 	unaryInterceptors := []grpc.UnaryServerInterceptor{
+		serverinterceptors.UnaryRequestIdInterceptor, // 请求 ID
+		serverinterceptors.UnaryCanaryInterceptor, // 灰度标签
 		serverinterceptors.UnaryTracingInterceptor, // 链路跟踪拦截器
 		serverinterceptors.UnaryCrashInterceptor, // 错误捕捉拦截器
 		serverinterceptors.UnaryStatInterceptor(s.metrics), // 状态指标拦截器
@@ -63,6 +66,8 @@ func (s *rpcServer) Start(register RegisterFn) error {
 	}
 	unaryInterceptors = append(unaryInterceptors, s.unaryInterceptors...)
 	streamInterceptors := []grpc.StreamServerInterceptor{
+		serverinterceptors.StreamRequestIdInterceptor,
+		serverinterceptors.StreamCanaryInterceptor,
 		serverinterceptors.StreamTracingInterceptor,
 		serverinterceptors.StreamCrashInterceptor,
 		serverinterceptors.StreamBreakerInterceptor,
@@ -75,6 +80,8 @@ func (s *rpcServer) Start(register RegisterFn) error {
 	// we need to make sure all others are wrapped up
 	// so we do graceful stop at shutdown phase instead of wrap up phase
 	waitForCalled := proc.AddWrapUpListener(func() {
+		// report not ready and wait for the load balancers to drain the traffic
+		health.Drain()
 		server.GracefulStop()
 	})
 	defer waitForCalled()
@@ -0,0 +1,53 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/canary"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryCanaryInterceptor is an interceptor that keeps the canary label from the metadata
+// in the context, to route the downstream calls by the same label.
+func UnaryCanaryInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withCanary(ctx), req)
+}
+
+// StreamCanaryInterceptor is an interceptor that keeps the canary label from the metadata
+// in the context, to route the downstream calls by the same label.
+func StreamCanaryInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	ctx := withCanary(ss.Context())
+	if ctx == ss.Context() {
+		return handler(srv, ss)
+	}
+
+	return handler(srv, &canaryServerStream{
+		ServerStream: ss,
+		ctx:          ctx,
+	})
+}
+
+type canaryServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *canaryServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func withCanary(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	if labels := md.Get(canary.MetadataKey); len(labels) > 0 && canary.IsValid(labels[0]) {
+		return canary.NewContext(ctx, labels[0])
+	}
+
+	return ctx
+}
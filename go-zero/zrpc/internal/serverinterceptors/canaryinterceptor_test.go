@@ -0,0 +1,67 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/canary"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryCanaryInterceptor(t *testing.T) {
+	tests := []struct {
+		name   string
+		md     metadata.MD
+		expect string
+	}{
+		{
+			name: "without metadata",
+		},
+		{
+			name:   "with label",
+			md:     metadata.Pairs(canary.MetadataKey, "v2"),
+			expect: "v2",
+		},
+		{
+			name: "invalid label",
+			md:   metadata.Pairs(canary.MetadataKey, "v 2"),
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+
+			_, err := UnaryCanaryInterceptor(ctx, nil, nil,
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					assert.Equal(t, test.expect, canary.FromContext(ctx))
+					return nil, nil
+				})
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestStreamCanaryInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(canary.MetadataKey, "v2"))
+	err := StreamCanaryInterceptor(nil, &mockedServerStream{ctx: ctx}, nil,
+		func(srv interface{}, stream grpc.ServerStream) error {
+			assert.Equal(t, "v2", canary.FromContext(stream.Context()))
+			return nil
+		})
+	assert.Nil(t, err)
+
+	err = StreamCanaryInterceptor(nil, &mockedServerStream{ctx: context.Background()}, nil,
+		func(srv interface{}, stream grpc.ServerStream) error {
+			assert.Empty(t, canary.FromContext(stream.Context()))
+			return nil
+		})
+	assert.Nil(t, err)
+}
@@ -0,0 +1,45 @@
+package serverinterceptors
+
+import (
+	"context"
+
+	"github.com/zeromicro/go-zero/core/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryRequestIdInterceptor is an interceptor that keeps the request id in the context,
+// the request id is taken from the metadata if valid, otherwise a new one is generated.
+func UnaryRequestIdInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler) (interface{}, error) {
+	return handler(withRequestId(ctx), req)
+}
+
+// StreamRequestIdInterceptor is an interceptor that keeps the request id in the context,
+// the request id is taken from the metadata if valid, otherwise a new one is generated.
+func StreamRequestIdInterceptor(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler) error {
+	return handler(srv, &requestIdServerStream{
+		ServerStream: ss,
+		ctx:          withRequestId(ss.Context()),
+	})
+}
+
+type requestIdServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *requestIdServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func withRequestId(ctx context.Context) context.Context {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if ids := md.Get(requestid.MetadataKey); len(ids) > 0 && requestid.IsValid(ids[0]) {
+			return requestid.NewContext(ctx, ids[0])
+		}
+	}
+
+	return requestid.NewContext(ctx, requestid.New())
+}
@@ -0,0 +1,68 @@
+package serverinterceptors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/core/requestid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestUnaryRequestIdInterceptor(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       metadata.MD
+		expect   string
+		generate bool
+	}{
+		{
+			name:     "without metadata",
+			generate: true,
+		},
+		{
+			name:   "with request id",
+			md:     metadata.Pairs(requestid.MetadataKey, "foo"),
+			expect: "foo",
+		},
+		{
+			name:     "invalid request id",
+			md:       metadata.Pairs(requestid.MetadataKey, "foo bar"),
+			generate: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			if test.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, test.md)
+			}
+
+			_, err := UnaryRequestIdInterceptor(ctx, nil, nil,
+				func(ctx context.Context, req interface{}) (interface{}, error) {
+					id := requestid.FromContext(ctx)
+					if test.generate {
+						assert.True(t, requestid.IsValid(id))
+					} else {
+						assert.Equal(t, test.expect, id)
+					}
+					return nil, nil
+				})
+			assert.Nil(t, err)
+		})
+	}
+}
+
+func TestStreamRequestIdInterceptor(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(),
+		metadata.Pairs(requestid.MetadataKey, "foo"))
+	err := StreamRequestIdInterceptor(nil, &mockedServerStream{ctx: ctx}, nil,
+		func(srv interface{}, stream grpc.ServerStream) error {
+			assert.Equal(t, "foo", requestid.FromContext(stream.Context()))
+			return nil
+		})
+	assert.Nil(t, err)
+}
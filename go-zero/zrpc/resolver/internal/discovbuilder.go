@@ -5,6 +5,7 @@ import (
 
 	"github.com/zeromicro/go-zero/core/discov"
 	"github.com/zeromicro/go-zero/core/logx"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
 	"google.golang.org/grpc/resolver"
 )
 
@@ -22,13 +23,10 @@ func (b *discovBuilder) Build(target resolver.Target, cc resolver.ClientConn, _
 		return nil, err
 	}
 
+	// the zone of the client, like discov://etcd:2379/user.rpc?zone=us-east-1a
+	zone := target.URL.Query().Get(zoneKey)
 	update := func() {
-		var addrs []resolver.Address
-		for _, val := range subset(sub.Values(), subsetSize) {
-			addrs = append(addrs, resolver.Address{
-				Addr: val,
-			})
-		}
+		addrs := buildAddresses(subset(sub.Values(), subsetSize), zone)
 		// 调用UpdateState方法更新
 		if err := cc.UpdateState(resolver.State{
 			Addresses: addrs,
@@ -49,3 +47,23 @@ func (b *discovBuilder) Build(target resolver.Target, cc resolver.ClientConn, _
 func (b *discovBuilder) Scheme() string {
 	return DiscovScheme
 }
+
+// buildAddresses builds the addresses from the published values, with the metadata of the instances,
+// the instances are local if they are in zone.
+func buildAddresses(vals []string, zone string) []resolver.Address {
+	var addrs []resolver.Address
+	for _, val := range vals {
+		addr, md, err := instance.Decode(val)
+		if err != nil {
+			logx.Errorf("bad published value %q, error: %v", val, err)
+			continue
+		}
+
+		local := len(zone) > 0 && md.Zone == zone
+		addrs = append(addrs, instance.WithMetadata(resolver.Address{
+			Addr: addr,
+		}, md, local))
+	}
+
+	return addrs
+}
@@ -4,9 +4,43 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/zeromicro/go-zero/zrpc/internal/instance"
 )
 
 func TestDiscovBuilder_Scheme(t *testing.T) {
 	var b discovBuilder
 	assert.Equal(t, DiscovScheme, b.Scheme())
 }
+
+func TestBuildAddresses(t *testing.T) {
+	md := instance.Metadata{
+		Zone:   "us-east-1a",
+		Weight: 50,
+	}
+	vals := []string{
+		"localhost:1",
+		instance.Encode("localhost:2", md),
+		instance.Encode("localhost:3", instance.Metadata{Zone: "us-east-1b"}),
+		"{bad",
+	}
+
+	addrs := buildAddresses(vals, "us-east-1a")
+	assert.Equal(t, 3, len(addrs))
+	assert.Equal(t, "localhost:1", addrs[0].Addr)
+	assert.Equal(t, "localhost:2", addrs[1].Addr)
+	assert.Equal(t, "localhost:3", addrs[2].Addr)
+
+	val, local := instance.FromAddress(addrs[0])
+	assert.True(t, val.IsZero())
+	assert.False(t, local)
+	val, local = instance.FromAddress(addrs[1])
+	assert.True(t, md.Equal(val))
+	assert.True(t, local)
+	_, local = instance.FromAddress(addrs[2])
+	assert.False(t, local)
+
+	for _, addr := range buildAddresses(vals, "") {
+		_, local = instance.FromAddress(addr)
+		assert.False(t, local)
+	}
+}
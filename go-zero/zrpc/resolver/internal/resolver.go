@@ -19,6 +19,7 @@ const (
 	EndpointSepChar = ','
 
 	subsetSize = 32
+	zoneKey    = "zone"
 )
 
 var (
@@ -2,8 +2,10 @@ package zrpc
 
 import (
 	"log"
+	"strings"
 	"time"
 
+	"github.com/zeromicro/go-zero/core/health"
 	"github.com/zeromicro/go-zero/core/load"
 	"github.com/zeromicro/go-zero/core/logx"
 	"github.com/zeromicro/go-zero/core/stat"
@@ -11,6 +13,8 @@ import (
 	"github.com/zeromicro/go-zero/zrpc/internal/auth"
 	"github.com/zeromicro/go-zero/zrpc/internal/serverinterceptors"
 	"google.golang.org/grpc"
+	channelzservice "google.golang.org/grpc/channelz/service"
+	"google.golang.org/grpc/reflection"
 )
 
 // A RpcServer is a rpc server.
@@ -51,7 +55,8 @@ func NewServer(c RpcServerConf, register internal.RegisterFn) (*RpcServer, error
 	if c.HasEtcd() {
 		// 1.在 etcd 注册服务
 		// 2. 配置 rpc 服务
-		server, err = internal.NewRpcPubServer(c.Etcd, c.ListenOn, serverOptions...)
+		server, err = internal.NewRpcPubServer(c.Etcd, c.ListenOn, c.Metadata.toInstanceMetadata(),
+			serverOptions...)
 		if err != nil {
 			return nil, err
 		}
@@ -66,6 +71,14 @@ func NewServer(c RpcServerConf, register internal.RegisterFn) (*RpcServer, error
 		return nil, err
 	}
 
+	if c.Health {
+		health.SetDrainDelay(c.DrainDelay)
+		register = withHealthServer(register, c.Name)
+	}
+	if c.IsDebugMode() {
+		register = withDebugServers(register, c.Reflection, c.Channelz)
+	}
+
 	rpcServer := &RpcServer{
 		server:   server, // rpc 服务对象
 		register: register, // rpc 服务方法注册 func
@@ -74,6 +87,10 @@ func NewServer(c RpcServerConf, register internal.RegisterFn) (*RpcServer, error
 	if err = c.SetUp(); err != nil {
 		return nil, err
 	}
+	if ignored := ignoredDebugServers(c); len(ignored) > 0 {
+		logx.Infof("%s ignored in mode %q, which is not in DebugModes %v",
+			strings.Join(ignored, ", "), c.Mode, c.DebugModes)
+	}
 
 	return rpcServer, nil
 }
@@ -114,6 +131,49 @@ func SetServerSlowThreshold(threshold time.Duration) {
 	serverinterceptors.SetSlowThreshold(threshold)
 }
 
+// ignoredDebugServers returns the names of the debug services enabled but not allowed in the mode.
+func ignoredDebugServers(c RpcServerConf) []string {
+	if c.IsDebugMode() {
+		return nil
+	}
+
+	var ignored []string
+	if c.Reflection {
+		ignored = append(ignored, "Reflection")
+	}
+	if c.Channelz {
+		ignored = append(ignored, "Channelz")
+	}
+
+	return ignored
+}
+
+// withHealthServer returns a RegisterFn that registers the health service after calling register.
+func withHealthServer(register internal.RegisterFn, name string) internal.RegisterFn {
+	return func(server *grpc.Server) {
+		register(server)
+		internal.RegisterHealthServer(server, name)
+	}
+}
+
+// withDebugServers returns a RegisterFn that registers the reflection service and
+// the channelz service if enabled, after calling register.
+func withDebugServers(register internal.RegisterFn, reflect, channelz bool) internal.RegisterFn {
+	if !reflect && !channelz {
+		return register
+	}
+
+	return func(server *grpc.Server) {
+		register(server)
+		if reflect {
+			reflection.Register(server)
+		}
+		if channelz {
+			channelzservice.RegisterChannelzServiceToServer(server)
+		}
+	}
+}
+
 // setupInterceptors 拦截器
 // 自适应、超时、认证
 func setupInterceptors(server internal.Server, c RpcServerConf, metrics *stat.Metrics) error {
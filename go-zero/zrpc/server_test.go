@@ -13,6 +13,7 @@ import (
 	"github.com/zeromicro/go-zero/zrpc/internal"
 	"github.com/zeromicro/go-zero/zrpc/internal/serverinterceptors"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func TestServer_setupInterceptors(t *testing.T) {
@@ -59,6 +60,82 @@ func TestServer(t *testing.T) {
 	srv.Stop()
 }
 
+func TestServer_withHealthServer(t *testing.T) {
+	var registered bool
+	register := withHealthServer(func(server *grpc.Server) {
+		registered = true
+	}, "foo")
+	server := grpc.NewServer()
+	register(server)
+	assert.True(t, registered)
+	_, ok := server.GetServiceInfo()[healthpb.Health_ServiceDesc.ServiceName]
+	assert.True(t, ok)
+}
+
+func TestIgnoredDebugServers(t *testing.T) {
+	c := RpcServerConf{
+		Health:     true,
+		Reflection: true,
+		Channelz:   true,
+		DebugModes: []string{"dev", "test"},
+	}
+	c.Mode = "dev"
+	assert.Empty(t, ignoredDebugServers(c))
+	c.Mode = "pro"
+	assert.Equal(t, []string{"Reflection", "Channelz"}, ignoredDebugServers(c))
+	c.Reflection = false
+	c.Channelz = false
+	assert.Empty(t, ignoredDebugServers(c))
+}
+
+func TestServer_withDebugServers(t *testing.T) {
+	const (
+		reflectionService = "grpc.reflection.v1alpha.ServerReflection"
+		channelzService   = "grpc.channelz.v1.Channelz"
+	)
+
+	tests := []struct {
+		name     string
+		reflect  bool
+		channelz bool
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:    "reflection",
+			reflect: true,
+		},
+		{
+			name:     "channelz",
+			channelz: true,
+		},
+		{
+			name:     "both",
+			reflect:  true,
+			channelz: true,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			var registered bool
+			register := withDebugServers(func(server *grpc.Server) {
+				registered = true
+			}, test.reflect, test.channelz)
+			server := grpc.NewServer()
+			register(server)
+			assert.True(t, registered)
+			info := server.GetServiceInfo()
+			_, ok := info[reflectionService]
+			assert.Equal(t, test.reflect, ok)
+			_, ok = info[channelzService]
+			assert.Equal(t, test.channelz, ok)
+		})
+	}
+}
+
 func TestServerError(t *testing.T) {
 	_, err := NewServer(RpcServerConf{
 		ServiceConf: service.ServiceConf{